@@ -0,0 +1,342 @@
+// Package acp lets editors run the agent as a subprocess and drive it with
+// JSON-RPC over stdio, following the Agent Client Protocol.
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Agent serves an application to a single editor.
+type Agent struct {
+	app  *app.App
+	conn *Conn
+
+	mu   sync.Mutex
+	caps ClientCapabilities
+	// sessions are the sessions the editor created or loaded. Only their
+	// messages and permission requests are sent to it.
+	sessions map[string]*sessionStream
+}
+
+// Serve serves the application over the reader and writer until the
+// reader is closed or the context is done.
+func Serve(ctx context.Context, a *app.App, r io.Reader, w io.Writer) error {
+	ag := &Agent{app: a, sessions: make(map[string]*sessionStream)}
+	ag.conn = NewConn(r, w, ag.handle)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go a.SubscribeFunc(ctx, ag.onEvent)
+	return ag.conn.Serve(ctx)
+}
+
+func (a *Agent) handle(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case MethodInitialize:
+		var p InitializeParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.initialize(p), nil
+	case MethodSessionNew:
+		var p NewSessionParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.newSession(ctx, p)
+	case MethodSessionLoad:
+		var p LoadSessionParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, a.loadSession(ctx, p)
+	case MethodSessionPrompt:
+		var p PromptParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.prompt(ctx, p)
+	case MethodSessionCancel:
+		var p CancelParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if a.app.CoderAgent != nil {
+			a.app.CoderAgent.Cancel(p.SessionID)
+		}
+		return nil, nil
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", method)}
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (a *Agent) initialize(p InitializeParams) InitializeResult {
+	a.mu.Lock()
+	a.caps = p.ClientCapabilities
+	a.mu.Unlock()
+	return InitializeResult{
+		ProtocolVersion: ProtocolVersion,
+		AgentCapabilities: AgentCapabilities{
+			LoadSession:        true,
+			PromptCapabilities: PromptCapabilities{EmbeddedContext: true},
+		},
+		AuthMethods: []any{},
+	}
+}
+
+// checkCWD makes sure the editor works in the directory the agent was
+// started in, since the tools are bound to it.
+func (a *Agent) checkCWD(cwd string) error {
+	if cwd == "" || a.app.Config() == nil {
+		return nil
+	}
+	if filepath.Clean(cwd) != filepath.Clean(a.app.Config().WorkingDir()) {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("cwd %s is not the working directory of the agent, %s", cwd, a.app.Config().WorkingDir())}
+	}
+	return nil
+}
+
+func (a *Agent) newSession(ctx context.Context, p NewSessionParams) (NewSessionResult, error) {
+	if err := a.checkCWD(p.CWD); err != nil {
+		return NewSessionResult{}, err
+	}
+	sess, err := a.app.Sessions.Create(ctx, "New Session")
+	if err != nil {
+		return NewSessionResult{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	a.stream(sess.ID)
+	return NewSessionResult{SessionID: sess.ID}, nil
+}
+
+// loadSession replays the messages of a session as updates.
+func (a *Agent) loadSession(ctx context.Context, p LoadSessionParams) error {
+	if err := a.checkCWD(p.CWD); err != nil {
+		return err
+	}
+	sess, err := a.app.Sessions.Get(ctx, p.SessionID)
+	if err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("session %s not found", p.SessionID)}
+	}
+	msgs, err := a.app.Messages.List(ctx, sess.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	s := a.stream(sess.ID)
+	for _, msg := range msgs {
+		s.send(a.conn, msg)
+	}
+	return nil
+}
+
+// stream returns the stream of a session, which starts sending its updates
+// to the editor.
+func (a *Agent) stream(sessionID string) *sessionStream {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[sessionID]
+	if !ok {
+		s = newSessionStream(sessionID)
+		a.sessions[sessionID] = s
+	}
+	return s
+}
+
+func (a *Agent) session(sessionID string) (*sessionStream, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[sessionID]
+	return s, ok
+}
+
+// prompt runs the agent and waits for the end of the turn.
+func (a *Agent) prompt(ctx context.Context, p PromptParams) (PromptResult, error) {
+	s, ok := a.session(p.SessionID)
+	if !ok {
+		return PromptResult{}, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("session %s is not open, create or load it first", p.SessionID)}
+	}
+	if a.app.CoderAgent == nil {
+		return PromptResult{}, errors.New("no agent is configured")
+	}
+	prompt, err := promptText(p.Prompt)
+	if err != nil {
+		return PromptResult{}, err
+	}
+	if a.app.CoderAgent.IsSessionBusy(p.SessionID) {
+		return PromptResult{}, &Error{Code: CodeInvalidRequest, Message: "the session is already running a prompt"}
+	}
+
+	a.mu.Lock()
+	fsys := &clientFS{conn: a.conn, caps: a.caps.FS}
+	a.mu.Unlock()
+	if fsys.caps.ReadTextFile || fsys.caps.WriteTextFile {
+		ctx = tools.WithFileSystem(ctx, fsys)
+	}
+
+	done, err := a.app.CoderAgent.Run(ctx, p.SessionID, prompt)
+	if err != nil {
+		return PromptResult{}, fmt.Errorf("failed to start the agent: %w", err)
+	}
+	if done == nil {
+		return PromptResult{}, &Error{Code: CodeInvalidRequest, Message: "the session is already running a prompt"}
+	}
+	result := <-done
+	if result.Error != nil {
+		if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
+			return PromptResult{StopReason: StopCancelled}, nil
+		}
+		return PromptResult{}, result.Error
+	}
+
+	// Send what is left of the last message before the turn ends, in case
+	// its last update is still on its way.
+	s.send(a.conn, result.Message)
+	switch result.Message.FinishReason() {
+	case message.FinishReasonMaxTokens:
+		return PromptResult{StopReason: StopMaxTokens}, nil
+	case message.FinishReasonCanceled:
+		return PromptResult{StopReason: StopCancelled}, nil
+	}
+	return PromptResult{StopReason: StopEndTurn}, nil
+}
+
+// promptText turns the content blocks of a prompt into the text sent to
+// the model, with embedded resources in file tags.
+func promptText(blocks []ContentBlock) (string, error) {
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			parts = append(parts, block.Text)
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("<file_reference uri=%q />", block.URI))
+		case "resource":
+			if block.Resource == nil {
+				return "", &Error{Code: CodeInvalidParams, Message: "resource block without a resource"}
+			}
+			parts = append(parts, fmt.Sprintf("<file uri=%q>\n%s\n</file>", block.Resource.URI, block.Resource.Text))
+		default:
+			return "", &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("unsupported content block %q", block.Type)}
+		}
+	}
+	prompt := strings.Join(parts, "\n\n")
+	if strings.TrimSpace(prompt) == "" {
+		return "", &Error{Code: CodeInvalidParams, Message: "prompt is empty"}
+	}
+	return prompt, nil
+}
+
+func (a *Agent) onEvent(msg tea.Msg) {
+	switch e := msg.(type) {
+	case pubsub.Event[message.Message]:
+		// The editor shows the prompts it sends; they are only replayed
+		// when loading a session.
+		if e.Payload.Role == message.User {
+			return
+		}
+		if s, ok := a.session(e.Payload.SessionID); ok {
+			s.send(a.conn, e.Payload)
+		}
+	case pubsub.Event[permission.PermissionRequest]:
+		if _, ok := a.session(e.Payload.SessionID); ok {
+			go a.requestPermission(e.Payload)
+		}
+	}
+}
+
+// Ids of the permission options offered to the editor.
+const (
+	optionAllow        = "allow"
+	optionAllowSession = "allow_session"
+	optionAllowAlways  = "allow_always"
+	optionDeny         = "deny"
+)
+
+var permissionOptions = []PermissionOption{
+	{OptionID: optionAllow, Name: "Allow", Kind: "allow_once"},
+	{OptionID: optionAllowSession, Name: "Allow for this session", Kind: "allow_always"},
+	{OptionID: optionAllowAlways, Name: "Always allow", Kind: "allow_always"},
+	{OptionID: optionDeny, Name: "Deny", Kind: "reject_once"},
+}
+
+// offeredPermissionOptions returns the options for req, without always
+// allowing the requests that can't be.
+func offeredPermissionOptions(req permission.PermissionRequest) []PermissionOption {
+	if !req.NoPersistentGrant {
+		return permissionOptions
+	}
+	var options []PermissionOption
+	for _, option := range permissionOptions {
+		if option.OptionID != optionAllowAlways {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// requestPermission asks the editor to answer a permission request, which
+// is denied when the editor fails to answer or cancels it.
+func (a *Agent) requestPermission(req permission.PermissionRequest) {
+	call := ToolCall{
+		ToolCallID: req.ToolCallID,
+		Title:      req.Description,
+		Kind:       toolKind(req.ToolName),
+		Status:     ToolCallPending,
+	}
+	if params, err := json.Marshal(req.Params); err == nil {
+		call.RawInput = params
+	}
+	if req.Path != "" {
+		call.Locations = []ToolCallLocation{{Path: req.Path}}
+	}
+
+	var result RequestPermissionResult
+	err := a.conn.Call(context.Background(), MethodRequestPermission, RequestPermissionParams{
+		SessionID: req.SessionID,
+		ToolCall:  call,
+		Options:   offeredPermissionOptions(req),
+	}, &result)
+	if err != nil {
+		slog.Error("Failed to request permission", "tool", req.ToolName, "error", err)
+		a.app.Permissions.Deny(req)
+		return
+	}
+	if result.Outcome.Outcome != "selected" {
+		a.app.Permissions.Deny(req)
+		return
+	}
+	switch result.Outcome.OptionID {
+	case optionAllow:
+		a.app.Permissions.Grant(req)
+	case optionAllowSession:
+		a.app.Permissions.GrantForSession(req)
+	case optionAllowAlways:
+		a.app.Permissions.GrantPersistent(req)
+	default:
+		a.app.Permissions.Deny(req)
+	}
+}
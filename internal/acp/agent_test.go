@@ -0,0 +1,214 @@
+package acp
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient plays the editor.
+type testClient struct {
+	t      *testing.T
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID int
+}
+
+func (c *testClient) request(method string, params any) int {
+	c.t.Helper()
+	c.nextID++
+	data, err := json.Marshal(params)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.enc.Encode(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(strconv.Itoa(c.nextID)), Method: method, Params: data}))
+	return c.nextID
+}
+
+func (c *testClient) respond(id json.RawMessage, result any) {
+	c.t.Helper()
+	data, err := json.Marshal(result)
+	require.NoError(c.t, err)
+	require.NoError(c.t, c.enc.Encode(rpcMessage{JSONRPC: "2.0", ID: id, Result: data}))
+}
+
+func (c *testClient) read() rpcMessage {
+	c.t.Helper()
+	var msg rpcMessage
+	require.NoError(c.t, c.dec.Decode(&msg))
+	return msg
+}
+
+// call sends a request and returns its response, with the messages the
+// agent sent in between.
+func (c *testClient) call(method string, params any) (rpcMessage, []rpcMessage) {
+	c.t.Helper()
+	id := strconv.Itoa(c.request(method, params))
+	var before []rpcMessage
+	for {
+		msg := c.read()
+		if msg.Method == "" && string(msg.ID) == id {
+			return msg, before
+		}
+		before = append(before, msg)
+	}
+}
+
+func newTestAgent(t *testing.T) (*Agent, *testClient) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	a := &app.App{
+		Sessions:    session.NewService(q),
+		Messages:    message.NewService(q),
+		Permissions: permission.NewPermissionService(t.TempDir(), false, nil, nil, permission.NewGrantStore(q, 0), permission.NewAuditLog(q)),
+	}
+
+	agentIn, clientOut := io.Pipe()
+	clientIn, agentOut := io.Pipe()
+	t.Cleanup(func() {
+		clientOut.Close()
+		agentOut.Close()
+	})
+	ag := &Agent{app: a, sessions: make(map[string]*sessionStream)}
+	ag.conn = NewConn(agentIn, agentOut, ag.handle)
+	go ag.conn.Serve(t.Context())
+
+	return ag, &testClient{t: t, enc: json.NewEncoder(clientOut), dec: json.NewDecoder(clientIn)}
+}
+
+func TestAgent_Sessions(t *testing.T) {
+	t.Parallel()
+
+	ag, client := newTestAgent(t)
+
+	resp, _ := client.call(MethodInitialize, InitializeParams{ProtocolVersion: 1})
+	require.Nil(t, resp.Error)
+	var initResult InitializeResult
+	require.NoError(t, json.Unmarshal(resp.Result, &initResult))
+	require.Equal(t, ProtocolVersion, initResult.ProtocolVersion)
+	require.True(t, initResult.AgentCapabilities.LoadSession)
+
+	resp, _ = client.call(MethodSessionNew, NewSessionParams{})
+	require.Nil(t, resp.Error)
+	var newResult NewSessionResult
+	require.NoError(t, json.Unmarshal(resp.Result, &newResult))
+	require.NotEmpty(t, newResult.SessionID)
+
+	resp, _ = client.call(MethodSessionPrompt, PromptParams{SessionID: "missing", Prompt: []ContentBlock{textBlock("hi")}})
+	require.Equal(t, CodeInvalidParams, resp.Error.Code)
+	resp, _ = client.call("session/unknown", nil)
+	require.Equal(t, CodeMethodNotFound, resp.Error.Code)
+
+	ctx := t.Context()
+	sess, err := ag.app.Sessions.Create(ctx, "Earlier")
+	require.NoError(t, err)
+	_, err = ag.app.Messages.Create(ctx, sess.ID, message.CreateMessageParams{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "Fix it"}}})
+	require.NoError(t, err)
+	_, err = ag.app.Messages.Create(ctx, sess.ID, message.CreateMessageParams{Role: message.Assistant, Parts: []message.ContentPart{
+		message.TextContent{Text: "Done"},
+		message.ToolCall{ID: "call", Name: tools.ViewToolName, Input: `{"file_path":"main.go"}`, Finished: true},
+	}})
+	require.NoError(t, err)
+
+	resp, updates := client.call(MethodSessionLoad, LoadSessionParams{SessionID: sess.ID})
+	require.Nil(t, resp.Error)
+	require.Len(t, updates, 3)
+	var kinds []string
+	for _, update := range updates {
+		require.Equal(t, MethodSessionUpdate, update.Method)
+		var n struct {
+			SessionID string `json:"sessionId"`
+			Update    struct {
+				SessionUpdate string             `json:"sessionUpdate"`
+				Kind          string             `json:"kind"`
+				Locations     []ToolCallLocation `json:"locations"`
+			} `json:"update"`
+		}
+		require.NoError(t, json.Unmarshal(update.Params, &n))
+		require.Equal(t, sess.ID, n.SessionID)
+		kinds = append(kinds, n.Update.SessionUpdate)
+		if n.Update.SessionUpdate == UpdateToolCall {
+			require.Equal(t, "read", n.Update.Kind)
+			require.Equal(t, []ToolCallLocation{{Path: "main.go"}}, n.Update.Locations)
+		}
+	}
+	require.Equal(t, []string{UpdateUserMessageChunk, UpdateAgentMessageChunk, UpdateToolCall}, kinds)
+}
+
+func TestAgent_Permissions(t *testing.T) {
+	t.Parallel()
+
+	ag, client := newTestAgent(t)
+	resp, _ := client.call(MethodSessionNew, NewSessionParams{})
+	var newResult NewSessionResult
+	require.NoError(t, json.Unmarshal(resp.Result, &newResult))
+
+	// Forward the requests like the application does.
+	requests := ag.app.Permissions.Subscribe(t.Context())
+	go func() {
+		for event := range requests {
+			ag.onEvent(event)
+		}
+	}()
+
+	granted := make(chan bool)
+	go func() {
+		granted <- ag.app.Permissions.Request(permission.CreatePermissionRequest{
+			SessionID:   newResult.SessionID,
+			ToolCallID:  "call",
+			ToolName:    tools.BashToolName,
+			Description: "Run go test",
+			Action:      "execute",
+			Path:        t.TempDir(),
+		})
+	}()
+
+	req := client.read()
+	require.Equal(t, MethodRequestPermission, req.Method)
+	var params RequestPermissionParams
+	require.NoError(t, json.Unmarshal(req.Params, &params))
+	require.Equal(t, "call", params.ToolCall.ToolCallID)
+	require.Equal(t, "execute", params.ToolCall.Kind)
+	client.respond(req.ID, RequestPermissionResult{Outcome: PermissionOutcome{Outcome: "selected", OptionID: optionAllow}})
+	require.True(t, <-granted)
+}
+
+func TestClientFS(t *testing.T) {
+	t.Parallel()
+
+	ag, client := newTestAgent(t)
+	fsys := &clientFS{conn: ag.conn, caps: FileSystemCapabilities{ReadTextFile: true, WriteTextFile: true}}
+
+	read := make(chan []byte)
+	go func() {
+		content, _ := fsys.ReadFile(t.Context(), "/work/main.go")
+		read <- content
+	}()
+	req := client.read()
+	require.Equal(t, MethodReadTextFile, req.Method)
+	client.respond(req.ID, ReadTextFileResult{Content: "unsaved"})
+	require.Equal(t, "unsaved", string(<-read))
+
+	written := make(chan error)
+	go func() {
+		written <- fsys.WriteFile(t.Context(), "/work/main.go", []byte("new"))
+	}()
+	req = client.read()
+	require.Equal(t, MethodWriteTextFile, req.Method)
+	var params WriteTextFileParams
+	require.NoError(t, json.Unmarshal(req.Params, &params))
+	require.Equal(t, "new", params.Content)
+	require.NoError(t, client.enc.Encode(rpcMessage{JSONRPC: "2.0", ID: req.ID, Error: &Error{Code: CodeInternalError, Message: "read-only buffer"}}))
+	require.ErrorContains(t, <-written, "read-only buffer")
+}
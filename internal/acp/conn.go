@@ -0,0 +1,233 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/csync"
+	"github.com/JyotirmoyDas05/openpilot/internal/log"
+)
+
+// JSON-RPC error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is a JSON-RPC error. Handlers return it to choose the code of the
+// error response; other errors are internal errors.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// rpcMessage is a JSON-RPC 2.0 request, notification or response.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Handler handles a request or a notification from the client. The result
+// of notifications is discarded.
+type Handler func(ctx context.Context, method string, params json.RawMessage) (any, error)
+
+// ErrClosed is returned by the calls that were waiting for a response when
+// the connection was closed.
+var ErrClosed = errors.New("connection closed")
+
+// Conn is a JSON-RPC 2.0 connection over newline-delimited JSON messages.
+// Both sides send requests: the client's are passed to the handler, while
+// the agent's are sent with Call.
+type Conn struct {
+	r       io.Reader
+	w       io.Writer
+	writeMu sync.Mutex
+	handler Handler
+
+	nextID  atomic.Int64
+	pending *csync.Map[int64, chan *rpcMessage]
+	done    chan struct{}
+}
+
+func NewConn(r io.Reader, w io.Writer, handler Handler) *Conn {
+	return &Conn{
+		r:       r,
+		w:       w,
+		handler: handler,
+		pending: csync.NewMap[int64, chan *rpcMessage](),
+		done:    make(chan struct{}),
+	}
+}
+
+// Serve reads messages until the reader is closed or the context is done.
+// Requests are handled concurrently, with a context that is cancelled when
+// Serve returns.
+func (c *Conn) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(c.done)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(c.r)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case line = <-lines:
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			c.reply(nil, nil, &Error{Code: CodeParseError, Message: err.Error()})
+			continue
+		}
+		if msg.Method == "" {
+			c.resolve(&msg)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer log.RecoverPanic("acp.handle", func() {
+				if msg.ID != nil {
+					c.reply(msg.ID, nil, &Error{Code: CodeInternalError, Message: "panic while handling " + msg.Method})
+				}
+			})
+			result, err := c.handler(ctx, msg.Method, msg.Params)
+			if msg.ID == nil {
+				if err != nil {
+					slog.Error("Failed to handle notification", "method", msg.Method, "error", err)
+				}
+				return
+			}
+			c.reply(msg.ID, result, err)
+		}()
+	}
+}
+
+// resolve passes a response to the call waiting for it.
+func (c *Conn) resolve(msg *rpcMessage) {
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		slog.Warn("Received a response with an unknown id", "id", string(msg.ID))
+		return
+	}
+	if ch, ok := c.pending.Take(id); ok {
+		ch <- msg
+	}
+}
+
+func (c *Conn) reply(id json.RawMessage, result any, err error) {
+	msg := rpcMessage{JSONRPC: "2.0", ID: id}
+	if id == nil {
+		msg.ID = json.RawMessage("null")
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		msg.Error = rpcErr
+	} else {
+		data, err := json.Marshal(result)
+		if err != nil {
+			msg.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			msg.Result = data
+		}
+	}
+	if err := c.write(msg); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// Call sends a request to the client and decodes its result into result,
+// which can be nil.
+func (c *Conn) Call(ctx context.Context, method string, params, result any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	id := c.nextID.Add(1)
+	ch := make(chan *rpcMessage, 1)
+	c.pending.Set(id, ch)
+	defer c.pending.Del(id)
+
+	if err := c.write(rpcMessage{JSONRPC: "2.0", ID: json.RawMessage(strconv.FormatInt(id, 10)), Method: method, Params: data}); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return ErrClosed
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+// Notify sends a notification to the client.
+func (c *Conn) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+func (c *Conn) write(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,201 @@
+package acp
+
+import "encoding/json"
+
+// ProtocolVersion is the version of the protocol the agent speaks.
+const ProtocolVersion = 1
+
+// Methods the client calls on the agent.
+const (
+	MethodInitialize    = "initialize"
+	MethodSessionNew    = "session/new"
+	MethodSessionLoad   = "session/load"
+	MethodSessionPrompt = "session/prompt"
+	MethodSessionCancel = "session/cancel"
+)
+
+// Methods the agent calls on the client.
+const (
+	MethodSessionUpdate     = "session/update"
+	MethodRequestPermission = "session/request_permission"
+	MethodReadTextFile      = "fs/read_text_file"
+	MethodWriteTextFile     = "fs/write_text_file"
+)
+
+type InitializeParams struct {
+	ProtocolVersion    int                `json:"protocolVersion"`
+	ClientCapabilities ClientCapabilities `json:"clientCapabilities"`
+}
+
+type ClientCapabilities struct {
+	FS FileSystemCapabilities `json:"fs"`
+}
+
+// FileSystemCapabilities tells whether the client reads and writes files
+// for the agent, from and to its buffers.
+type FileSystemCapabilities struct {
+	ReadTextFile  bool `json:"readTextFile"`
+	WriteTextFile bool `json:"writeTextFile"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion   int               `json:"protocolVersion"`
+	AgentCapabilities AgentCapabilities `json:"agentCapabilities"`
+	AuthMethods       []any             `json:"authMethods"`
+}
+
+type AgentCapabilities struct {
+	LoadSession        bool               `json:"loadSession"`
+	PromptCapabilities PromptCapabilities `json:"promptCapabilities"`
+}
+
+type PromptCapabilities struct {
+	Image           bool `json:"image"`
+	Audio           bool `json:"audio"`
+	EmbeddedContext bool `json:"embeddedContext"`
+}
+
+type NewSessionParams struct {
+	CWD string `json:"cwd"`
+}
+
+type NewSessionResult struct {
+	SessionID string `json:"sessionId"`
+}
+
+type LoadSessionParams struct {
+	SessionID string `json:"sessionId"`
+	CWD       string `json:"cwd"`
+}
+
+type PromptParams struct {
+	SessionID string         `json:"sessionId"`
+	Prompt    []ContentBlock `json:"prompt"`
+}
+
+// StopReason tells why a prompt turn ended.
+type StopReason string
+
+const (
+	StopEndTurn   StopReason = "end_turn"
+	StopMaxTokens StopReason = "max_tokens"
+	StopRefusal   StopReason = "refusal"
+	StopCancelled StopReason = "cancelled"
+)
+
+type PromptResult struct {
+	StopReason StopReason `json:"stopReason"`
+}
+
+type CancelParams struct {
+	SessionID string `json:"sessionId"`
+}
+
+// ContentBlock is a piece of a prompt or of an update. Only text, resource
+// links and embedded text resources are supported.
+type ContentBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Resource *EmbeddedResource `json:"resource,omitempty"`
+}
+
+type EmbeddedResource struct {
+	URI  string `json:"uri"`
+	Text string `json:"text,omitempty"`
+}
+
+func textBlock(text string) ContentBlock {
+	return ContentBlock{Type: "text", Text: text}
+}
+
+type SessionNotification struct {
+	SessionID string        `json:"sessionId"`
+	Update    SessionUpdate `json:"update"`
+}
+
+// Kinds of session updates.
+const (
+	UpdateUserMessageChunk  = "user_message_chunk"
+	UpdateAgentMessageChunk = "agent_message_chunk"
+	UpdateAgentThoughtChunk = "agent_thought_chunk"
+	UpdateToolCall          = "tool_call"
+	UpdateToolCallUpdate    = "tool_call_update"
+)
+
+// SessionUpdate is a chunk of a message or a change of a tool call. Which
+// fields are set depends on SessionUpdate.
+type SessionUpdate struct {
+	SessionUpdate string `json:"sessionUpdate"`
+	// Content is a ContentBlock for message chunks and a list of
+	// ToolCallContent for tool calls.
+	Content any `json:"content,omitempty"`
+	ToolCall
+}
+
+// ToolCallStatus is the state of a tool call.
+type ToolCallStatus string
+
+const (
+	ToolCallPending    ToolCallStatus = "pending"
+	ToolCallInProgress ToolCallStatus = "in_progress"
+	ToolCallCompleted  ToolCallStatus = "completed"
+	ToolCallFailed     ToolCallStatus = "failed"
+)
+
+type ToolCall struct {
+	ToolCallID string             `json:"toolCallId,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Status     ToolCallStatus     `json:"status,omitempty"`
+	RawInput   json.RawMessage    `json:"rawInput,omitempty"`
+	Locations  []ToolCallLocation `json:"locations,omitempty"`
+}
+
+type ToolCallLocation struct {
+	Path string `json:"path"`
+}
+
+type ToolCallContent struct {
+	Type    string       `json:"type"`
+	Content ContentBlock `json:"content"`
+}
+
+type RequestPermissionParams struct {
+	SessionID string             `json:"sessionId"`
+	ToolCall  ToolCall           `json:"toolCall"`
+	Options   []PermissionOption `json:"options"`
+}
+
+type PermissionOption struct {
+	OptionID string `json:"optionId"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+}
+
+type RequestPermissionResult struct {
+	Outcome PermissionOutcome `json:"outcome"`
+}
+
+// PermissionOutcome is either selected, with the id of the option the user
+// picked, or cancelled.
+type PermissionOutcome struct {
+	Outcome  string `json:"outcome"`
+	OptionID string `json:"optionId,omitempty"`
+}
+
+type ReadTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+}
+
+type ReadTextFileResult struct {
+	Content string `json:"content"`
+}
+
+type WriteTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+}
@@ -0,0 +1,169 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+)
+
+// sessionStream turns the versions of the messages of a session into
+// updates, sending every piece once.
+type sessionStream struct {
+	sessionID string
+
+	mu sync.Mutex
+	// text and thought are the length of the text and reasoning already
+	// sent per message.
+	text        map[string]int
+	thought     map[string]int
+	toolCalls   map[string]bool
+	toolResults map[string]bool
+}
+
+func newSessionStream(sessionID string) *sessionStream {
+	return &sessionStream{
+		sessionID:   sessionID,
+		text:        make(map[string]int),
+		thought:     make(map[string]int),
+		toolCalls:   make(map[string]bool),
+		toolResults: make(map[string]bool),
+	}
+}
+
+func (s *sessionStream) send(conn *Conn, msg message.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, update := range s.updates(msg) {
+		if err := conn.Notify(MethodSessionUpdate, SessionNotification{SessionID: s.sessionID, Update: update}); err != nil {
+			slog.Error("Failed to send session update", "error", err)
+			return
+		}
+	}
+}
+
+func (s *sessionStream) updates(msg message.Message) []SessionUpdate {
+	var updates []SessionUpdate
+	chunk := func(kind, text string, sent map[string]int) {
+		if len(text) > sent[msg.ID] {
+			updates = append(updates, SessionUpdate{SessionUpdate: kind, Content: textBlock(text[sent[msg.ID]:])})
+			sent[msg.ID] = len(text)
+		}
+	}
+
+	switch msg.Role {
+	case message.User:
+		chunk(UpdateUserMessageChunk, msg.Content().Text, s.text)
+	case message.Assistant:
+		chunk(UpdateAgentThoughtChunk, msg.ReasoningContent().Thinking, s.thought)
+		chunk(UpdateAgentMessageChunk, msg.Content().Text, s.text)
+	}
+
+	for _, call := range msg.ToolCalls() {
+		if !call.Finished || s.toolCalls[call.ID] {
+			continue
+		}
+		s.toolCalls[call.ID] = true
+		update := SessionUpdate{
+			SessionUpdate: UpdateToolCall,
+			ToolCall: ToolCall{
+				ToolCallID: call.ID,
+				Title:      call.Name,
+				Kind:       toolKind(call.Name),
+				Status:     ToolCallPending,
+				Locations:  toolLocations(call.Input),
+			},
+		}
+		if json.Valid([]byte(call.Input)) {
+			update.RawInput = json.RawMessage(call.Input)
+		}
+		updates = append(updates, update)
+	}
+
+	for _, result := range msg.ToolResults() {
+		if s.toolResults[result.ToolCallID] {
+			continue
+		}
+		s.toolResults[result.ToolCallID] = true
+		status := ToolCallCompleted
+		if result.IsError {
+			status = ToolCallFailed
+		}
+		updates = append(updates, SessionUpdate{
+			SessionUpdate: UpdateToolCallUpdate,
+			Content:       []ToolCallContent{{Type: "content", Content: textBlock(result.Content)}},
+			ToolCall: ToolCall{
+				ToolCallID: result.ToolCallID,
+				Status:     status,
+			},
+		})
+	}
+	return updates
+}
+
+// toolKind tells the editor what a tool does, to pick an icon for it.
+func toolKind(name string) string {
+	switch name {
+	case tools.ViewToolName, tools.LSToolName:
+		return "read"
+	case tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName, tools.RenameToolName, tools.CodeActionToolName:
+		return "edit"
+	case tools.GlobToolName, tools.GrepToolName, tools.SourcegraphToolName, tools.SymbolsToolName, tools.ReferencesToolName, tools.DefinitionToolName:
+		return "search"
+	case tools.BashToolName, tools.JobOutputToolName, tools.JobKillToolName:
+		return "execute"
+	case tools.FetchToolName, tools.DownloadToolName:
+		return "fetch"
+	}
+	return "other"
+}
+
+// toolLocations returns the file a tool call works on, so the editor can
+// follow along.
+func toolLocations(input string) []ToolCallLocation {
+	var params struct {
+		FilePath string `json:"file_path"`
+		Path     string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		return nil
+	}
+	for _, path := range []string{params.FilePath, params.Path} {
+		if path != "" {
+			return []ToolCallLocation{{Path: path}}
+		}
+	}
+	return nil
+}
+
+// clientFS reads and writes files through the editor, when it supports it,
+// so the tools see its unsaved buffers.
+type clientFS struct {
+	conn *Conn
+	caps FileSystemCapabilities
+}
+
+func (f *clientFS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	if !f.caps.ReadTextFile {
+		return os.ReadFile(path)
+	}
+	sessionID, _ := tools.GetContextValues(ctx)
+	var result ReadTextFileResult
+	if err := f.conn.Call(ctx, MethodReadTextFile, ReadTextFileParams{SessionID: sessionID, Path: path}, &result); err != nil {
+		return nil, err
+	}
+	return []byte(result.Content), nil
+}
+
+func (f *clientFS) WriteFile(ctx context.Context, path string, data []byte) error {
+	if !f.caps.WriteTextFile {
+		return os.WriteFile(path, data, 0o644)
+	}
+	sessionID, _ := tools.GetContextValues(ctx)
+	return f.conn.Call(ctx, MethodWriteTextFile, WriteTextFileParams{SessionID: sessionID, Path: path, Content: string(data)}, nil)
+}
@@ -3,7 +3,6 @@ package app
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
@@ -13,7 +12,6 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/config"
 	"github.com/JyotirmoyDas05/openpilot/internal/csync"
 	"github.com/JyotirmoyDas05/openpilot/internal/db"
-	"github.com/JyotirmoyDas05/openpilot/internal/format"
 	"github.com/JyotirmoyDas05/openpilot/internal/history"
 	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
 	"github.com/JyotirmoyDas05/openpilot/internal/log"
@@ -24,6 +22,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/message"
 	"github.com/JyotirmoyDas05/openpilot/internal/permission"
 	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
 )
 
 type App struct {
@@ -31,6 +30,8 @@ type App struct {
 	Messages    message.Service
 	History     history.Service
 	Permissions permission.Service
+	Grants      permission.GrantStore
+	Jobs        shell.JobManager
 
 	CoderAgent agent.Service
 
@@ -58,18 +59,37 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	q := db.New(conn)
 	sessions := session.NewService(q)
 	messages := message.NewService(q)
-	files := history.NewService(q, conn)
+	files := history.NewService(q, conn, cfg.WorkingDir())
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	allowedTools := []string{}
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
 		allowedTools = cfg.Permissions.AllowedTools
 	}
+	var rules []permission.Rule
+	var grantExpiry time.Duration
+	if cfg.Permissions != nil {
+		for _, r := range cfg.Permissions.Rules {
+			rules = append(rules, permission.Rule{Decision: permission.Decision(r.Decision), Pattern: r.Pattern})
+		}
+		if cfg.Permissions.GrantExpiry != "" {
+			var err error
+			if grantExpiry, err = time.ParseDuration(cfg.Permissions.GrantExpiry); err != nil {
+				return nil, fmt.Errorf("invalid permissions.grant_expiry: %w", err)
+			}
+		}
+	}
+	if err := permission.ValidateRules(rules); err != nil {
+		return nil, err
+	}
+	grants := permission.NewGrantStore(q, grantExpiry)
 
 	app := &App{
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
+		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools, rules, grants, permission.NewAuditLog(q)),
+		Grants:      grants,
+		Jobs:        shell.NewJobManager(),
 		LSPClients:  make(map[string]*lsp.Client),
 
 		globalCtx: ctx,
@@ -104,97 +124,6 @@ func (app *App) Config() *config.Config {
 	return app.config
 }
 
-// RunNonInteractive handles the execution flow when a prompt is provided via
-// CLI flag.
-func (app *App) RunNonInteractive(ctx context.Context, prompt string, quiet bool) error {
-	slog.Info("Running in non-interactive mode")
-
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	// Start spinner if not in quiet mode.
-	var spinner *format.Spinner
-	if !quiet {
-		spinner = format.NewSpinner(ctx, cancel, "Generating")
-		spinner.Start()
-	}
-
-	// Helper function to stop spinner once.
-	stopSpinner := func() {
-		if !quiet && spinner != nil {
-			spinner.Stop()
-			spinner = nil
-		}
-	}
-	defer stopSpinner()
-
-	const maxPromptLengthForTitle = 100
-	titlePrefix := "Non-interactive: "
-	var titleSuffix string
-
-	if len(prompt) > maxPromptLengthForTitle {
-		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
-	} else {
-		titleSuffix = prompt
-	}
-	title := titlePrefix + titleSuffix
-
-	sess, err := app.Sessions.Create(ctx, title)
-	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
-	}
-	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
-
-	// Automatically approve all permission requests for this non-interactive session
-	app.Permissions.AutoApproveSession(sess.ID)
-
-	done, err := app.CoderAgent.Run(ctx, sess.ID, prompt)
-	if err != nil {
-		return fmt.Errorf("failed to start agent processing stream: %w", err)
-	}
-
-	messageEvents := app.Messages.Subscribe(ctx)
-	readBts := 0
-
-	for {
-		select {
-		case result := <-done:
-			stopSpinner()
-
-			if result.Error != nil {
-				if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
-					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
-					return nil
-				}
-				return fmt.Errorf("agent processing failed: %w", result.Error)
-			}
-
-			msgContent := result.Message.Content().String()
-			if len(msgContent) < readBts {
-				slog.Error("Non-interactive: message content is shorter than read bytes", "message_length", len(msgContent), "read_bytes", readBts)
-				return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(msgContent), readBts)
-			}
-			fmt.Println(msgContent[readBts:])
-
-			slog.Info("Non-interactive: run completed", "session_id", sess.ID)
-			return nil
-
-		case event := <-messageEvents:
-			msg := event.Payload
-			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
-				stopSpinner()
-				part := msg.Content().String()[readBts:]
-				fmt.Print(part)
-				readBts += len(part)
-			}
-
-		case <-ctx.Done():
-			stopSpinner()
-			return ctx.Err()
-		}
-	}
-}
-
 func (app *App) UpdateAgentModel() error {
 	return app.CoderAgent.UpdateModel()
 }
@@ -207,6 +136,7 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions", app.Permissions.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "jobs", app.Jobs.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", agent.SubscribeMCPEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
 	cleanupFunc := func() {
@@ -264,6 +194,7 @@ func (app *App) InitCoderAgent() error {
 		app.Sessions,
 		app.Messages,
 		app.History,
+		app.Jobs,
 		app.LSPClients,
 	)
 	if err != nil {
@@ -309,12 +240,33 @@ func (app *App) Subscribe(program *tea.Program) {
 	}
 }
 
+// SubscribeFunc calls fn with every event until the context is done. It is
+// used instead of Subscribe by frontends other than the TUI.
+func (app *App) SubscribeFunc(ctx context.Context, fn func(tea.Msg)) {
+	defer log.RecoverPanic("app.SubscribeFunc", nil)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-app.events:
+			if !ok {
+				return
+			}
+			fn(msg)
+		}
+	}
+}
+
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
 	if app.CoderAgent != nil {
 		app.CoderAgent.CancelAll()
 	}
 
+	// Kill the background jobs of the bash tool.
+	app.Jobs.KillAll()
+
 	for cancel := range app.watcherCancelFuncs.Seq() {
 		cancel()
 	}
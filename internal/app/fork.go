@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+)
+
+// ForkSession creates a new session that continues from the given message,
+// copying the conversation up to and including it. The original session is
+// left untouched and recorded as the parent of the fork.
+func (app *App) ForkSession(ctx context.Context, sessionID, messageID string) (session.Session, error) {
+	parent, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	idx := slices.IndexFunc(msgs, func(msg message.Message) bool {
+		return msg.ID == messageID
+	})
+	if idx == -1 {
+		return session.Session{}, fmt.Errorf("message %s not found in session %s", messageID, sessionID)
+	}
+	// Keep the results of the fork point's tool calls, since providers
+	// reject tool calls without a matching result.
+	if len(msgs[idx].ToolCalls()) > 0 && idx+1 < len(msgs) && msgs[idx+1].Role == message.Tool {
+		idx++
+	}
+
+	fork, err := app.Sessions.CreateForkSession(ctx, parent.ID, messageID, parent.Title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	for _, msg := range msgs[:idx+1] {
+		copied, err := app.Messages.Copy(ctx, fork.ID, msg)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to copy message: %w", err)
+		}
+		switch msg.ID {
+		case parent.SummaryMessageID:
+			fork.SummaryMessageID = copied.ID
+		case parent.KeptMessageID:
+			fork.KeptMessageID = copied.ID
+		}
+	}
+	// The kept messages come before the summary, so the fork may have them
+	// without it.
+	if fork.SummaryMessageID == "" {
+		fork.KeptMessageID = ""
+	}
+	if fork.SummaryMessageID != "" {
+		if fork, err = app.Sessions.Save(ctx, fork); err != nil {
+			return session.Session{}, fmt.Errorf("failed to save session: %w", err)
+		}
+	}
+	return app.Sessions.Get(ctx, fork.ID)
+}
+
+// RewindSession removes the given user message and everything after it from
+// the session, so the prompt can be sent again with different content. The
+// removed conversation is kept in a fork of the session, which is returned.
+func (app *App) RewindSession(ctx context.Context, sessionID, messageID string) (session.Session, error) {
+	if app.CoderAgent != nil && app.CoderAgent.IsSessionBusy(sessionID) {
+		return session.Session{}, fmt.Errorf("agent is busy, please wait")
+	}
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	idx := slices.IndexFunc(msgs, func(msg message.Message) bool {
+		return msg.ID == messageID
+	})
+	if idx == -1 {
+		return session.Session{}, fmt.Errorf("message %s not found in session %s", messageID, sessionID)
+	}
+	if msgs[idx].Role != message.User {
+		return session.Session{}, fmt.Errorf("only user messages can be edited")
+	}
+
+	fork, err := app.ForkSession(ctx, sessionID, msgs[len(msgs)-1].ID)
+	if err != nil {
+		return session.Session{}, err
+	}
+
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	for i := len(msgs) - 1; i >= idx; i-- {
+		if err := app.Messages.Delete(ctx, msgs[i].ID); err != nil {
+			return session.Session{}, fmt.Errorf("failed to delete message: %w", err)
+		}
+		if msgs[i].ID == sess.SummaryMessageID {
+			sess.SummaryMessageID = ""
+			sess.KeptMessageID = ""
+			if sess, err = app.Sessions.Save(ctx, sess); err != nil {
+				return session.Session{}, fmt.Errorf("failed to save session: %w", err)
+			}
+		}
+	}
+	return fork, nil
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/format"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+)
+
+// NonInteractiveOptions configures RunNonInteractive.
+type NonInteractiveOptions struct {
+	// SessionID is the session to continue. A new session is created when
+	// it is empty.
+	SessionID string
+	// Quiet hides the spinner. It is always hidden for JSON output.
+	Quiet        bool
+	OutputFormat OutputFormat
+	// Policy answers the permission requests of the run. Without one every
+	// request is granted.
+	Policy *permission.Policy
+}
+
+// RunNonInteractive handles the execution flow when a prompt is provided via
+// CLI flag.
+func (app *App) RunNonInteractive(ctx context.Context, prompt string, opts NonInteractiveOptions) error {
+	slog.Info("Running in non-interactive mode")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if opts.OutputFormat == "" {
+		opts.OutputFormat = OutputText
+	}
+	quiet := opts.Quiet || opts.OutputFormat != OutputText
+
+	// Start spinner if not in quiet mode.
+	var spinner *format.Spinner
+	if !quiet {
+		spinner = format.NewSpinner(ctx, cancel, "Generating")
+		spinner.Start()
+	}
+
+	// Helper function to stop spinner once.
+	stopSpinner := func() {
+		if !quiet && spinner != nil {
+			spinner.Stop()
+			spinner = nil
+		}
+	}
+	defer stopSpinner()
+
+	sess, err := app.nonInteractiveSession(ctx, prompt, opts.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if opts.Policy != nil {
+		app.Permissions.SetPolicy(opts.Policy)
+	} else {
+		// Automatically approve all permission requests for this non-interactive session
+		app.Permissions.AutoApproveSession(sess.ID)
+	}
+
+	out := newRunOutput(opts.OutputFormat, os.Stdout)
+	if err := out.start(sess); err != nil {
+		return err
+	}
+
+	// Subscribe before starting the agent so no message event is missed.
+	messageEvents := app.Messages.Subscribe(ctx)
+	started := time.Now()
+
+	done, err := app.CoderAgent.Run(ctx, sess.ID, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to start agent processing stream: %w", err)
+	}
+
+	for {
+		select {
+		case result := <-done:
+			stopSpinner()
+
+			if err := out.finish(runResult{
+				sessionID: sess.ID,
+				usage:     result.Usage,
+				message:   result.Message,
+				err:       result.Error,
+				duration:  time.Since(started),
+			}); err != nil {
+				return err
+			}
+
+			if result.Error != nil {
+				if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, agent.ErrRequestCancelled) {
+					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+					return nil
+				}
+				return fmt.Errorf("agent processing failed: %w", result.Error)
+			}
+
+			slog.Info("Non-interactive: run completed", "session_id", sess.ID)
+			return nil
+
+		case event := <-messageEvents:
+			msg := event.Payload
+			if msg.SessionID != sess.ID {
+				continue
+			}
+			if msg.Role == message.Assistant && len(msg.Parts) > 0 {
+				stopSpinner()
+			}
+			if err := out.message(msg); err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			stopSpinner()
+			return ctx.Err()
+		}
+	}
+}
+
+func (app *App) nonInteractiveSession(ctx context.Context, prompt, sessionID string) (session.Session, error) {
+	if sessionID != "" {
+		sess, err := app.Sessions.Get(ctx, sessionID)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("session %s not found: %w", sessionID, err)
+		}
+		slog.Info("Continuing session for non-interactive run", "session_id", sess.ID)
+		return sess, nil
+	}
+
+	const maxPromptLengthForTitle = 100
+	titlePrefix := "Non-interactive: "
+	var titleSuffix string
+
+	if len(prompt) > maxPromptLengthForTitle {
+		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
+	} else {
+		titleSuffix = prompt
+	}
+	title := titlePrefix + titleSuffix
+
+	sess, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+	}
+	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+	return sess, nil
+}
+
+// LatestSession returns the most recently updated session.
+func (app *App) LatestSession(ctx context.Context) (session.Session, error) {
+	sessions, err := app.Sessions.List(ctx)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(sessions) == 0 {
+		return session.Session{}, fmt.Errorf("no sessions to continue")
+	}
+	latest := sessions[0]
+	for _, s := range sessions[1:] {
+		if s.UpdatedAt > latest.UpdatedAt {
+			latest = s
+		}
+	}
+	return latest, nil
+}
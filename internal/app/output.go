@@ -0,0 +1,254 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+)
+
+// OutputFormat selects how a non-interactive run is reported.
+type OutputFormat string
+
+const (
+	// OutputText prints the assistant's text as it streams in.
+	OutputText OutputFormat = "text"
+	// OutputJSON prints a single JSON document once the run ends.
+	OutputJSON OutputFormat = "json"
+	// OutputStreamJSON prints one JSON object per line for every message,
+	// tool call and tool result, followed by the same document as OutputJSON.
+	OutputStreamJSON OutputFormat = "stream-json"
+)
+
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch f := OutputFormat(s); f {
+	case OutputText, OutputJSON, OutputStreamJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported output format %q, use text, json or stream-json", s)
+}
+
+type runResult struct {
+	sessionID string
+	// usage is the usage of the requests of the run alone, as reported by
+	// the agent.
+	usage    agent.Usage
+	message  message.Message
+	err      error
+	duration time.Duration
+}
+
+type runOutput interface {
+	start(sess session.Session) error
+	// message is called with every version of the messages of the session
+	// as they are created and updated.
+	message(msg message.Message) error
+	finish(result runResult) error
+}
+
+func newRunOutput(f OutputFormat, w io.Writer) runOutput {
+	switch f {
+	case OutputJSON:
+		return &jsonOutput{enc: json.NewEncoder(w)}
+	case OutputStreamJSON:
+		return &streamJSONOutput{
+			jsonOutput:  jsonOutput{enc: json.NewEncoder(w)},
+			written:     make(map[string]int),
+			toolCalls:   make(map[string]bool),
+			toolResults: make(map[string]bool),
+			finished:    make(map[string]bool),
+		}
+	default:
+		return &textOutput{w: w}
+	}
+}
+
+// textOutput prints the text of the assistant messages as it streams in.
+type textOutput struct {
+	w         io.Writer
+	messageID string
+	written   int
+}
+
+func (o *textOutput) start(session.Session) error { return nil }
+
+func (o *textOutput) message(msg message.Message) error {
+	if msg.Role != message.Assistant {
+		return nil
+	}
+	if msg.ID != o.messageID {
+		if o.written > 0 {
+			fmt.Fprintln(o.w)
+		}
+		o.messageID = msg.ID
+		o.written = 0
+	}
+	text := msg.Content().String()
+	if len(text) > o.written {
+		fmt.Fprint(o.w, text[o.written:])
+		o.written = len(text)
+	}
+	return nil
+}
+
+func (o *textOutput) finish(result runResult) error {
+	if result.err != nil {
+		return nil
+	}
+	if err := o.message(result.message); err != nil {
+		return err
+	}
+	fmt.Fprintln(o.w)
+	return nil
+}
+
+type runUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// runSummary is the document printed at the end of a JSON run.
+type runSummary struct {
+	Type         string               `json:"type"`
+	SessionID    string               `json:"session_id"`
+	Result       string               `json:"result"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	IsError      bool                 `json:"is_error"`
+	Error        string               `json:"error,omitempty"`
+	Usage        runUsage             `json:"usage"`
+	Cost         float64              `json:"cost"`
+	DurationMS   int64                `json:"duration_ms"`
+}
+
+// jsonOutput prints a summary of the run once it ends.
+type jsonOutput struct {
+	enc *json.Encoder
+}
+
+func (o *jsonOutput) start(session.Session) error { return nil }
+
+func (o *jsonOutput) message(message.Message) error { return nil }
+
+func (o *jsonOutput) finish(result runResult) error {
+	summary := runSummary{
+		Type:         "result",
+		SessionID:    result.sessionID,
+		Result:       result.message.Content().Text,
+		FinishReason: result.message.FinishReason(),
+		Usage: runUsage{
+			PromptTokens:     result.usage.PromptTokens,
+			CompletionTokens: result.usage.CompletionTokens,
+		},
+		Cost:       result.usage.Cost,
+		DurationMS: result.duration.Milliseconds(),
+	}
+	if result.err != nil {
+		summary.IsError = true
+		summary.Error = result.err.Error()
+	}
+	return o.enc.Encode(summary)
+}
+
+// runEvent is a line of stream-json output.
+type runEvent struct {
+	Type         string               `json:"type"`
+	SessionID    string               `json:"session_id"`
+	MessageID    string               `json:"message_id,omitempty"`
+	Role         message.MessageRole  `json:"role,omitempty"`
+	Text         string               `json:"text,omitempty"`
+	Reasoning    string               `json:"reasoning,omitempty"`
+	Model        string               `json:"model,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	ToolCall     *message.ToolCall    `json:"tool_call,omitempty"`
+	ToolResult   *message.ToolResult  `json:"tool_result,omitempty"`
+}
+
+// streamJSONOutput prints an event for every piece of a message once, as
+// soon as it is complete, and the text of assistant messages as it streams.
+type streamJSONOutput struct {
+	jsonOutput
+	// written is the length of the text already printed per message.
+	written     map[string]int
+	toolCalls   map[string]bool
+	toolResults map[string]bool
+	finished    map[string]bool
+}
+
+func (o *streamJSONOutput) start(sess session.Session) error {
+	return o.enc.Encode(runEvent{Type: "start", SessionID: sess.ID})
+}
+
+func (o *streamJSONOutput) message(msg message.Message) error {
+	if msg.Role == message.Assistant {
+		text := msg.Content().Text
+		if len(text) > o.written[msg.ID] {
+			if err := o.enc.Encode(runEvent{
+				Type:      "text",
+				SessionID: msg.SessionID,
+				MessageID: msg.ID,
+				Text:      text[o.written[msg.ID]:],
+			}); err != nil {
+				return err
+			}
+			o.written[msg.ID] = len(text)
+		}
+	}
+
+	for _, call := range msg.ToolCalls() {
+		if !call.Finished || o.toolCalls[call.ID] {
+			continue
+		}
+		o.toolCalls[call.ID] = true
+		if err := o.enc.Encode(runEvent{
+			Type:      "tool_call",
+			SessionID: msg.SessionID,
+			MessageID: msg.ID,
+			ToolCall:  &call,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, result := range msg.ToolResults() {
+		if o.toolResults[result.ToolCallID] {
+			continue
+		}
+		o.toolResults[result.ToolCallID] = true
+		if err := o.enc.Encode(runEvent{
+			Type:       "tool_result",
+			SessionID:  msg.SessionID,
+			MessageID:  msg.ID,
+			ToolResult: &result,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if msg.Role == message.Tool || !msg.IsFinished() || o.finished[msg.ID] {
+		return nil
+	}
+	o.finished[msg.ID] = true
+	return o.enc.Encode(runEvent{
+		Type:         "message",
+		SessionID:    msg.SessionID,
+		MessageID:    msg.ID,
+		Role:         msg.Role,
+		Text:         msg.Content().Text,
+		Reasoning:    msg.ReasoningContent().Thinking,
+		Model:        msg.Model,
+		FinishReason: msg.FinishReason(),
+	})
+}
+
+func (o *streamJSONOutput) finish(result runResult) error {
+	if result.err == nil {
+		if err := o.message(result.message); err != nil {
+			return err
+		}
+	}
+	return o.jsonOutput.finish(result)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newRunOutput(OutputStreamJSON, &buf)
+	sess := session.Session{ID: "s"}
+	require.NoError(t, out.start(sess))
+
+	call := message.ToolCall{ID: "call", Name: "ls", Input: "{}"}
+	updates := []message.Message{
+		{ID: "u", SessionID: "s", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "list"}, message.Finish{Reason: message.FinishReasonEndTurn}}},
+		{ID: "a1", SessionID: "s", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "Let"}}},
+		{ID: "a1", SessionID: "s", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "Let me look"}, call}},
+	}
+	call.Finished = true
+	updates = append(updates,
+		message.Message{ID: "a1", SessionID: "s", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "Let me look"}, call}},
+		message.Message{ID: "a1", SessionID: "s", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "Let me look"}, call, message.Finish{Reason: message.FinishReasonToolUse}}},
+		message.Message{ID: "t", SessionID: "s", Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call", Content: "a.go"}, message.Finish{Reason: message.FinishReasonEndTurn}}},
+	)
+	for _, msg := range updates {
+		require.NoError(t, out.message(msg))
+	}
+
+	final := message.Message{ID: "a2", SessionID: "s", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "a.go"}, message.Finish{Reason: message.FinishReasonEndTurn}}}
+	require.NoError(t, out.finish(runResult{
+		sessionID: "s",
+		usage:     agent.Usage{PromptTokens: 10, CompletionTokens: 5, Cost: 0.5},
+		message:   final,
+	}))
+
+	var types []string
+	var summary runSummary
+	for line := range strings.Lines(buf.String()) {
+		var event runEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		types = append(types, event.Type)
+		if event.Type == "result" {
+			require.NoError(t, json.Unmarshal([]byte(line), &summary))
+		}
+	}
+	require.Equal(t, []string{
+		"start",
+		"message",
+		"text", "text",
+		"tool_call",
+		"message",
+		"tool_result",
+		"text", "message",
+		"result",
+	}, types)
+	require.Equal(t, "a.go", summary.Result)
+	require.Equal(t, int64(10), summary.Usage.PromptTokens)
+	require.False(t, summary.IsError)
+}
+
+func TestJSONOutputError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newRunOutput(OutputJSON, &buf)
+	require.NoError(t, out.message(message.Message{ID: "a", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "ignored"}}}))
+	require.NoError(t, out.finish(runResult{
+		sessionID: "s",
+		usage:     agent.Usage{PromptTokens: 3},
+		err:       errors.New("boom"),
+	}))
+
+	var summary runSummary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.Equal(t, "s", summary.SessionID)
+	require.True(t, summary.IsError)
+	require.Equal(t, "boom", summary.Error)
+	require.Equal(t, int64(3), summary.Usage.PromptTokens, "a failed run still reports what it used")
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+)
+
+// RevertSinceMessage restores every file the message's session changed
+// since the message was sent to the content it had before.
+func (app *App) RevertSinceMessage(ctx context.Context, messageID string) ([]history.File, error) {
+	msg, err := app.Messages.Get(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if app.CoderAgent != nil && app.CoderAgent.IsSessionBusy(msg.SessionID) {
+		return nil, fmt.Errorf("agent is busy, please wait")
+	}
+	return app.History.RevertSince(ctx, msg.SessionID, msg.CreatedAtMs)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/acp"
+	"github.com/spf13/cobra"
+)
+
+var acpCmd = &cobra.Command{
+	Use:   "acp",
+	Short: "Serve the agent to an editor over stdio",
+	Long: `Run the agent as a subprocess of an editor, speaking the Agent Client Protocol:
+newline-delimited JSON-RPC messages on stdin and stdout. The editor creates sessions,
+sends prompts, receives their updates and answers permission requests, and can read
+and write files for the agent so its unsaved buffers are respected.`,
+	Example: `
+# Start the agent for the project the editor has open
+openpilot acp --cwd /path/to/project
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'openpilot' to set up a provider interactively")
+		}
+
+		return acp.Serve(cmd.Context(), app, os.Stdin, os.Stdout)
+	},
+}
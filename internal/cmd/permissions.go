@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/spf13/cobra"
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:   "permissions",
+	Short: "Manage permission grants and review the audit log",
+	Long:  `Review and revoke the permissions that were always allowed in the current project, and see every permission decision.`,
+}
+
+var permissionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List permission grants",
+	Long:  `List the tools and actions that run without asking, and the directories they may run in.`,
+	Example: `
+# List permission grants
+openpilot permissions list
+
+# List permission grants as JSON for scripting
+openpilot permissions list --json
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		grants, err := permission.NewGrantStore(db.New(conn), 0).List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list permission grants: %w", err)
+		}
+
+		if asJSON {
+			out := make([]grantJSON, 0, len(grants))
+			for _, g := range grants {
+				out = append(out, grantJSON(g))
+			}
+			return printJSON(out)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTOOL\tACTION\tPATH\tEXPIRES\tCREATED")
+		for _, g := range grants {
+			expires := "never"
+			if g.ExpiresAt != 0 {
+				expires = formatTimestamp(g.ExpiresAt)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				g.ID,
+				g.ToolName,
+				g.Action,
+				g.Path,
+				expires,
+				formatTimestamp(g.CreatedAt),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+var permissionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <grant-id>...",
+	Short: "Revoke permission grants",
+	Long:  `Revoke permission grants, so the tools ask again before running.`,
+	Example: `
+# Revoke a grant
+openpilot permissions revoke 3f1c...
+
+# Revoke every grant
+openpilot permissions revoke --all
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if all == (len(args) > 0) {
+			return fmt.Errorf("pass grant IDs or --all")
+		}
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		grants := permission.NewGrantStore(db.New(conn), 0)
+		if all {
+			list, err := grants.List(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list permission grants: %w", err)
+			}
+			for _, g := range list {
+				args = append(args, g.ID)
+			}
+		}
+		for _, id := range args {
+			if err := grants.Revoke(ctx, id); err != nil {
+				return fmt.Errorf("failed to revoke grant %s: %w", id, err)
+			}
+			fmt.Printf("Revoked grant %s\n", id)
+		}
+		return nil
+	},
+}
+
+var permissionsAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the permission audit log",
+	Long: `Show what tools asked permission for and who granted or denied it, newest
+first. The decider is the user, an earlier grant, the allowed_tools config, a
+permission rule, the policy of a run, yolo mode or the auto-approval of a
+non-interactive run.`,
+	Example: `
+# Show the last 50 decisions
+openpilot permissions audit
+
+# Show what was decided during a run
+openpilot permissions audit --session 3f1c... --json
+
+# Show the decisions of the last day
+openpilot permissions audit --since 24h
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionID, _ := cmd.Flags().GetString("session")
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		limit, _ := cmd.Flags().GetInt64("limit")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if limit < 1 {
+			return fmt.Errorf("limit must be at least 1")
+		}
+		var since int64
+		if sinceFlag != "" {
+			age, err := parseAge(sinceFlag)
+			if err != nil {
+				return err
+			}
+			since = time.Now().Add(-age).Unix()
+		}
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		entries, err := permission.NewAuditLog(db.New(conn)).List(cmd.Context(), permission.AuditFilter{
+			SessionID: sessionID,
+			Since:     since,
+			Limit:     limit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read the permission audit log: %w", err)
+		}
+
+		if asJSON {
+			out := make([]auditEntryJSON, 0, len(entries))
+			for _, e := range entries {
+				out = append(out, auditEntryJSON(e))
+			}
+			return printJSON(out)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tSESSION\tTOOL\tACTION\tDECISION\tDECIDER\tPARAMS\tREASON")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				formatTimestamp(e.CreatedAt),
+				e.SessionID,
+				e.ToolName,
+				e.Action,
+				e.Decision,
+				e.Decider,
+				summarizeText(e.Params, 60),
+				e.Reason,
+			)
+		}
+		return w.Flush()
+	},
+}
+
+type grantJSON struct {
+	ID        string `json:"id"`
+	ToolName  string `json:"tool_name"`
+	Action    string `json:"action"`
+	Path      string `json:"path"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type auditEntryJSON struct {
+	ID         int64              `json:"id"`
+	SessionID  string             `json:"session_id"`
+	ToolCallID string             `json:"tool_call_id"`
+	ToolName   string             `json:"tool_name"`
+	Action     string             `json:"action"`
+	Path       string             `json:"path"`
+	Params     string             `json:"params"`
+	Decision   string             `json:"decision"`
+	Decider    permission.Decider `json:"decider"`
+	Reason     string             `json:"reason,omitempty"`
+	CreatedAt  int64              `json:"created_at"`
+}
+
+func init() {
+	permissionsListCmd.Flags().Bool("json", false, "Print grants as JSON")
+	permissionsRevokeCmd.Flags().Bool("all", false, "Revoke every grant")
+	permissionsAuditCmd.Flags().StringP("session", "s", "", "Only show the decisions made in this session")
+	permissionsAuditCmd.Flags().String("since", "", "Only show decisions made within this age (e.g. 12h, 30d, 2w)")
+	permissionsAuditCmd.Flags().Int64P("limit", "n", 50, "Maximum number of decisions to show")
+	permissionsAuditCmd.Flags().Bool("json", false, "Print decisions as JSON")
+
+	permissionsCmd.AddCommand(permissionsListCmd)
+	permissionsCmd.AddCommand(permissionsRevokeCmd)
+	permissionsCmd.AddCommand(permissionsAuditCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"io"
 	"log/slog"
@@ -28,6 +29,10 @@ func init() {
 
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(headersCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(permissionsCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(acpCmd)
 }
 
 var rootCmd = &cobra.Command{
@@ -133,6 +138,28 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 	return appInstance, nil
 }
 
+// connectDB opens the database of the current project without starting the
+// rest of the app, for commands that only read or write stored sessions.
+func connectDB(cmd *cobra.Command) (*sql.DB, error) {
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Init(cwd, debug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := createDataDir(cfg.Options.DataDirectory); err != nil {
+		return nil, err
+	}
+
+	return db.Connect(cmd.Context(), cfg.Options.DataDirectory)
+}
+
 func MaybePrependStdin(prompt string) (string, error) {
 	if term.IsTerminal(os.Stdin.Fd()) {
 		return prompt, nil
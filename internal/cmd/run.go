@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
 	"github.com/spf13/cobra"
 )
 
@@ -22,9 +24,44 @@ echo "What is this code doing?" | openpilot run
 
 # Run with quiet mode (no spinner)
 openpilot run -q "Generate a README for this project"
+
+# Follow up in the most recent session
+openpilot run --continue "Now add tests for it"
+
+# Print every message and tool event as a JSON object per line
+openpilot run --output-format stream-json "Fix the failing test"
+
+# Review untrusted changes without letting the agent modify anything
+openpilot run --read-only "Review the changes on this branch"
+
+# Allow running commands and editing files, but not fetching URLs
+openpilot run --allow-tools bash,edit,view --deny-tools fetch "Fix the failing test"
+
+# Follow up in a specific session
+openpilot run --session 3f1c... "Fix the failing test"
 	`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		sessionID, _ := cmd.Flags().GetString("session")
+		continueLatest, _ := cmd.Flags().GetBool("continue")
+		if sessionID != "" && continueLatest {
+			return fmt.Errorf("--session and --continue cannot be used together")
+		}
+		outputFormat, _ := cmd.Flags().GetString("output-format")
+		format, err := app.ParseOutputFormat(outputFormat)
+		if err != nil {
+			return err
+		}
+		policy, err := runPolicy(cmd)
+		if err != nil {
+			return err
+		}
+		opts := app.NonInteractiveOptions{
+			SessionID:    sessionID,
+			Quiet:        quiet,
+			OutputFormat: format,
+			Policy:       policy,
+		}
 
 		app, err := setupApp(cmd)
 		if err != nil {
@@ -48,11 +85,51 @@ openpilot run -q "Generate a README for this project"
 			return fmt.Errorf("no prompt provided")
 		}
 
+		if continueLatest {
+			latest, err := app.LatestSession(cmd.Context())
+			if err != nil {
+				return err
+			}
+			opts.SessionID = latest.ID
+		}
+
 		// Run non-interactive flow using the App method
-		return app.RunNonInteractive(cmd.Context(), prompt, quiet)
+		return app.RunNonInteractive(cmd.Context(), prompt, opts)
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().StringP("session", "s", "", "Continue the session with this ID")
+	runCmd.Flags().Bool("continue", false, "Continue the most recently updated session")
+	runCmd.Flags().String("output-format", "text", "Output format (text, json, stream-json)")
+	runCmd.Flags().StringSlice("allow-tools", nil, "Only allow these tools or tool:action pairs, denying everything else")
+	runCmd.Flags().StringSlice("deny-tools", nil, "Deny these tools or tool:action pairs")
+	runCmd.Flags().Bool("read-only", false, "Deny every tool that changes files, runs commands or reaches the network")
+	runCmd.Flags().String("policy", "", "Read the permission policy from a JSON file")
+}
+
+// runPolicy builds the permission policy from the flags, or returns nil
+// when none were given and every request is granted.
+func runPolicy(cmd *cobra.Command) (*permission.Policy, error) {
+	allowTools, _ := cmd.Flags().GetStringSlice("allow-tools")
+	denyTools, _ := cmd.Flags().GetStringSlice("deny-tools")
+	readOnly, _ := cmd.Flags().GetBool("read-only")
+	policyFile, _ := cmd.Flags().GetString("policy")
+
+	policy := permission.Policy{
+		AllowTools: allowTools,
+		DenyTools:  denyTools,
+		ReadOnly:   readOnly,
+	}
+	if policyFile != "" {
+		fromFile, err := permission.LoadPolicy(policyFile)
+		if err != nil {
+			return nil, err
+		}
+		policy = fromFile.Merge(policy)
+	} else if policy.IsZero() {
+		return nil, nil
+	}
+	return &policy, nil
 }
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the agent over a local HTTP API",
+	Long: `Start a headless server that lets clients like editor plugins create sessions,
+send prompts, answer permission requests and follow the agent through server-sent events.
+Requests need the token set with --token or OPENPILOT_SERVER_TOKEN as a bearer token. Without
+one, a random token is generated and printed. Requests from web pages are refused.`,
+	Example: `
+# Serve on the default port
+openpilot serve
+
+# Serve on another port with a fixed token
+openpilot serve --port 8080 --token s3cret
+
+# Follow the events of the server
+curl -N -H "Authorization: Bearer s3cret" localhost:8080/events
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		host, _ := cmd.Flags().GetString("host")
+		port, _ := cmd.Flags().GetInt("port")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv("OPENPILOT_SERVER_TOKEN")
+		}
+		generated := token == ""
+		if generated {
+			var err error
+			if token, err = randomToken(); err != nil {
+				return fmt.Errorf("failed to generate a token: %w", err)
+			}
+		}
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		if !app.Config().IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'openpilot' to set up a provider interactively")
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return fmt.Errorf("failed to listen: %w", err)
+		}
+		srv := &http.Server{
+			Handler:           server.New(ctx, app, token, host),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				slog.Error("Failed to shut down the server", "error", err)
+			}
+		}()
+
+		fmt.Fprintf(os.Stderr, "Serving on http://%s\n", listener.Addr())
+		if generated {
+			fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+		}
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// randomToken returns a token for the server when none is given.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func init() {
+	serveCmd.Flags().String("host", "127.0.0.1", "Address to listen on")
+	serveCmd.Flags().IntP("port", "p", 4096, "Port to listen on")
+	serveCmd.Flags().String("token", "", "Bearer token required on every request, generated when empty")
+	serveCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+}
@@ -0,0 +1,688 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/config"
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/JyotirmoyDas05/openpilot/internal/transcript"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:     "sessions",
+	Aliases: []string{"session"},
+	Short:   "Manage sessions",
+	Long:    `Inspect and manage the sessions stored for the current project.`,
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions",
+	Long:  `List the sessions of the current project, most recent first, with their token usage and cost.`,
+	Example: `
+# List sessions
+openpilot sessions list
+
+# List sessions as JSON for scripting
+openpilot sessions list --json
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		sessions, err := session.NewService(db.New(conn)).List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		if asJSON {
+			out := make([]sessionJSON, 0, len(sessions))
+			for _, s := range sessions {
+				out = append(out, newSessionJSON(s))
+			}
+			return printJSON(out)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTITLE\tMESSAGES\tPROMPT\tCOMPLETION\tCOST\tUPDATED")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t$%.4f\t%s\n",
+				s.ID,
+				summarizeText(s.Title, 40),
+				s.MessageCount,
+				s.PromptTokens,
+				s.CompletionTokens,
+				s.Cost,
+				formatTimestamp(s.UpdatedAt),
+			)
+		}
+		return w.Flush()
+	},
+}
+
+var sessionShowCmd = &cobra.Command{
+	Use:   "show <session-id>",
+	Short: "Print the transcript of a session",
+	Long:  `Print the messages of a session, including reasoning, tool calls and tool results.`,
+	Example: `
+# Print a session transcript
+openpilot sessions show 3f1c...
+
+# Print a session and its messages as JSON
+openpilot sessions show 3f1c... --json
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		q := db.New(conn)
+		sess, err := session.NewService(q).Get(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("session %s not found: %w", args[0], err)
+		}
+		msgs, err := message.NewService(q).List(ctx, sess.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
+
+		if asJSON {
+			out := newSessionJSON(sess)
+			out.Messages = make([]messageJSON, 0, len(msgs))
+			for _, msg := range msgs {
+				out.Messages = append(out.Messages, newMessageJSON(msg))
+			}
+			return printJSON(out)
+		}
+
+		fmt.Printf("%s\n%s  %d messages  %d prompt / %d completion tokens  $%.4f\n",
+			sess.Title, sess.ID, sess.MessageCount, sess.PromptTokens, sess.CompletionTokens, sess.Cost)
+		for _, msg := range msgs {
+			printMessage(msg)
+		}
+		return nil
+	},
+}
+
+var sessionSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search the messages of all sessions",
+	Long: `Search the text of the messages in every session. Messages match when they
+contain all the words of the query; the last word also matches as a prefix.`,
+	Example: `
+# Find the session where a bug was fixed
+openpilot sessions search migration bug
+
+# Search and print the hits as JSON
+openpilot sessions search "rate limit" --json
+	`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		results, err := message.NewService(db.New(conn)).Search(cmd.Context(), strings.Join(args, " "), limit)
+		if err != nil {
+			return fmt.Errorf("failed to search messages: %w", err)
+		}
+
+		if asJSON {
+			out := make([]searchResultJSON, 0, len(results))
+			for _, r := range results {
+				out = append(out, searchResultJSON(r))
+			}
+			return printJSON(out)
+		}
+
+		if len(results) == 0 {
+			fmt.Println("No matching messages")
+			return nil
+		}
+		for _, r := range results {
+			fmt.Printf("%s  %s  %s\n", r.SessionID, formatTimestamp(r.CreatedAt), summarizeText(r.SessionTitle, 60))
+			fmt.Printf("  %s: %s\n", r.Role, r.Snippet)
+		}
+		return nil
+	},
+}
+
+var sessionDeleteCmd = &cobra.Command{
+	Use:   "delete <session-id>...",
+	Short: "Delete sessions",
+	Long:  `Delete sessions with their messages, file history and the sessions of their sub-agents.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		sessions := session.NewService(db.New(conn))
+		for _, id := range args {
+			if _, err := sessions.Get(ctx, id); err != nil {
+				return fmt.Errorf("session %s not found: %w", id, err)
+			}
+			if err := sessions.Delete(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete session %s: %w", id, err)
+			}
+			fmt.Printf("Deleted session %s\n", id)
+		}
+		return nil
+	},
+}
+
+var sessionRenameCmd = &cobra.Command{
+	Use:   "rename <session-id> <title>",
+	Short: "Rename a session",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		title := strings.TrimSpace(args[1])
+		if title == "" {
+			return fmt.Errorf("title cannot be empty")
+		}
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		sessions := session.NewService(db.New(conn))
+		sess, err := sessions.Get(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("session %s not found: %w", args[0], err)
+		}
+		sess.Title = title
+		if _, err := sessions.Save(ctx, sess); err != nil {
+			return fmt.Errorf("failed to rename session: %w", err)
+		}
+		fmt.Printf("Renamed session %s to %q\n", sess.ID, title)
+		return nil
+	},
+}
+
+var sessionPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old sessions",
+	Long:  `Delete the sessions that have not been updated for longer than the given age.`,
+	Example: `
+# Delete sessions untouched for a month
+openpilot sessions prune --older-than 30d
+
+# See what would be deleted
+openpilot sessions prune --older-than 2w --dry-run
+	`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-age).Unix()
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		pruned, err := pruneSessions(cmd.Context(), session.NewService(db.New(conn)), cutoff, dryRun)
+		for _, s := range pruned {
+			fmt.Printf("%s  %s  %s\n", s.ID, formatTimestamp(s.UpdatedAt), summarizeText(s.Title, 60))
+		}
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			fmt.Printf("%d sessions would be deleted\n", len(pruned))
+		} else {
+			fmt.Printf("Deleted %d sessions\n", len(pruned))
+		}
+		return nil
+	},
+}
+
+// pruneSessions deletes the sessions last updated before cutoff, a Unix
+// time, with their task and title sessions, and returns them. With dryRun
+// it only returns them.
+func pruneSessions(ctx context.Context, sessions session.Service, cutoff int64, dryRun bool) ([]session.Session, error) {
+	all, err := sessions.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var pruned []session.Session
+	for _, s := range all {
+		if s.UpdatedAt >= cutoff {
+			continue
+		}
+		if !dryRun {
+			if err := sessions.Delete(ctx, s.ID); err != nil {
+				return pruned, fmt.Errorf("failed to delete session %s: %w", s.ID, err)
+			}
+		}
+		pruned = append(pruned, s)
+	}
+	return pruned, nil
+}
+
+var sessionRevertCmd = &cobra.Command{
+	Use:   "revert <session-id>",
+	Short: "Revert file changes made in a session",
+	Long: `Restore files changed by the agent in a session from the session history.
+Without flags, the prompts and file versions that can be restored are listed.`,
+	Example: `
+# List the restore points of a session
+openpilot sessions revert 3f1c...
+
+# Undo every file change made since a prompt was sent
+openpilot sessions revert 3f1c... --message 9a2b...
+
+# Restore a single file to the content it had before the session changed it
+openpilot sessions revert 3f1c... --file internal/app/app.go
+
+# Restore a single file to a specific version
+openpilot sessions revert 3f1c... --file internal/app/app.go --version 2
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		messageID, _ := cmd.Flags().GetString("message")
+		file, _ := cmd.Flags().GetString("file")
+		version, _ := cmd.Flags().GetInt64("version")
+		if messageID != "" && file != "" {
+			return fmt.Errorf("--message and --file cannot be used together")
+		}
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		ctx := cmd.Context()
+		sess, err := app.Sessions.Get(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("session %s not found: %w", args[0], err)
+		}
+
+		switch {
+		case messageID != "":
+			msg, err := app.Messages.Get(ctx, messageID)
+			if err != nil || msg.SessionID != sess.ID {
+				return fmt.Errorf("message %s not found in session %s", messageID, sess.ID)
+			}
+			restored, err := app.RevertSinceMessage(ctx, messageID)
+			if err != nil {
+				return err
+			}
+			if len(restored) == 0 {
+				fmt.Println("No file changes to revert")
+			}
+			for _, f := range restored {
+				fmt.Printf("Restored %s\n", f.Path)
+			}
+			return nil
+
+		case file != "":
+			path := file
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(app.Config().WorkingDir(), path)
+			}
+			path, err := filepath.Abs(path)
+			if err != nil {
+				return fmt.Errorf("failed to resolve path: %w", err)
+			}
+			files, err := app.History.ListBySession(ctx, sess.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list file history: %w", err)
+			}
+			var target *history.File
+			for _, f := range files {
+				if f.Path != path {
+					continue
+				}
+				if cmd.Flags().Changed("version") {
+					if f.Version == version {
+						target = &f
+						break
+					}
+				} else if target == nil {
+					target = &f
+				}
+			}
+			if target == nil {
+				return fmt.Errorf("no matching version of %s in session %s", file, sess.ID)
+			}
+			restored, err := app.History.Restore(ctx, target.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored %s to version %d\n", restored.Path, target.Version)
+			return nil
+		}
+
+		return printRestorePoints(cmd, app.Messages, app.History, sess.ID)
+	},
+}
+
+var sessionExportCmd = &cobra.Command{
+	Use:   "export <session-id>",
+	Short: "Export a session as JSON or Markdown",
+	Long: `Export a session with its messages, tool calls and file history.
+The JSON format can be imported again with "openpilot sessions import"; the
+Markdown format is meant for reading and sharing.`,
+	Example: `
+# Export a session to a file that can be imported elsewhere
+openpilot sessions export 3f1c... -o session.json
+
+# Print a readable transcript
+openpilot sessions export 3f1c... --format markdown
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		if format != "json" && format != "markdown" {
+			return fmt.Errorf("unsupported format %q, use json or markdown", format)
+		}
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		t, err := transcript.Export(cmd.Context(), db.New(conn), args[0], config.Get().WorkingDir())
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if format == "markdown" {
+			md, err := transcript.Markdown(t)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(md)
+		} else {
+			enc := json.NewEncoder(&buf)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(t); err != nil {
+				return fmt.Errorf("failed to encode transcript: %w", err)
+			}
+		}
+
+		if output == "" {
+			_, err = io.Copy(os.Stdout, &buf)
+			return err
+		}
+		if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		return nil
+	},
+}
+
+var sessionImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a session exported as JSON",
+	Long: `Import a session exported with "openpilot sessions export", keeping its
+ID, messages and file history. Use "-" to read from stdin.`,
+	Example: `
+# Import a session into the current project
+openpilot sessions import session.json
+	`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", args[0], err)
+			}
+			defer f.Close()
+			r = f
+		}
+		t, err := transcript.Decode(r)
+		if err != nil {
+			return err
+		}
+
+		conn, err := connectDB(cmd)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		ctx := cmd.Context()
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if err := transcript.Import(ctx, db.New(conn).WithTx(tx), t, config.Get().WorkingDir()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		fmt.Printf("Imported session %s (%s)\n", t.Sessions[0].ID, t.Sessions[0].Title)
+		return nil
+	},
+}
+
+func init() {
+	sessionListCmd.Flags().Bool("json", false, "Print sessions as JSON")
+	sessionShowCmd.Flags().Bool("json", false, "Print the session and its messages as JSON")
+	sessionSearchCmd.Flags().Bool("json", false, "Print the matching messages as JSON")
+	sessionSearchCmd.Flags().IntP("limit", "n", 20, "Maximum number of messages to return")
+	sessionPruneCmd.Flags().String("older-than", "30d", "Delete sessions not updated within this age (e.g. 12h, 30d, 2w)")
+	sessionPruneCmd.Flags().Bool("dry-run", false, "List the sessions that would be deleted without deleting them")
+
+	sessionExportCmd.Flags().String("format", "json", "Output format (json, markdown)")
+	sessionExportCmd.Flags().StringP("output", "o", "", "Write to a file instead of stdout")
+
+	sessionRevertCmd.Flags().StringP("message", "m", "", "Revert every file change made since this message was sent")
+	sessionRevertCmd.Flags().StringP("file", "f", "", "Restore a single file")
+	sessionRevertCmd.Flags().Int64("version", 0, "Version to restore the file to (defaults to its content before the session)")
+
+	sessionsCmd.AddCommand(sessionListCmd)
+	sessionsCmd.AddCommand(sessionShowCmd)
+	sessionsCmd.AddCommand(sessionSearchCmd)
+	sessionsCmd.AddCommand(sessionDeleteCmd)
+	sessionsCmd.AddCommand(sessionRenameCmd)
+	sessionsCmd.AddCommand(sessionPruneCmd)
+	sessionsCmd.AddCommand(sessionRevertCmd)
+	sessionsCmd.AddCommand(sessionExportCmd)
+	sessionsCmd.AddCommand(sessionImportCmd)
+}
+
+func printRestorePoints(cmd *cobra.Command, messages message.Service, files history.Service, sessionID string) error {
+	ctx := cmd.Context()
+	msgs, err := messages.List(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list messages: %w", err)
+	}
+	versions, err := files.ListBySession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list file history: %w", err)
+	}
+
+	fmt.Println("Prompts (--message):")
+	for _, msg := range msgs {
+		if msg.Role != message.User {
+			continue
+		}
+		fmt.Printf("  %s  %s  %s\n", msg.ID, formatTimestamp(msg.CreatedAt), summarizeText(msg.Content().Text, 60))
+	}
+
+	fmt.Println("File versions (--file, --version):")
+	for _, f := range versions {
+		fmt.Printf("  %s  v%d  %s\n", f.Path, f.Version, formatTimestamp(f.CreatedAt))
+	}
+	return nil
+}
+
+func formatTimestamp(unix int64) string {
+	return time.Unix(unix, 0).Format(time.DateTime)
+}
+
+// summarizeText returns the first line of s, truncated to width runes.
+func summarizeText(s string, width int) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	if runes := []rune(s); len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return s
+}
+
+type sessionJSON struct {
+	ID               string        `json:"id"`
+	ParentSessionID  string        `json:"parent_session_id,omitempty"`
+	ForkMessageID    string        `json:"fork_message_id,omitempty"`
+	Title            string        `json:"title"`
+	MessageCount     int64         `json:"message_count"`
+	PromptTokens     int64         `json:"prompt_tokens"`
+	CompletionTokens int64         `json:"completion_tokens"`
+	Cost             float64       `json:"cost"`
+	CreatedAt        int64         `json:"created_at"`
+	UpdatedAt        int64         `json:"updated_at"`
+	Messages         []messageJSON `json:"messages,omitempty"`
+}
+
+func newSessionJSON(s session.Session) sessionJSON {
+	return sessionJSON{
+		ID:               s.ID,
+		ParentSessionID:  s.ParentSessionID,
+		ForkMessageID:    s.ForkMessageID,
+		Title:            s.Title,
+		MessageCount:     s.MessageCount,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+type messageJSON struct {
+	ID          string               `json:"id"`
+	Role        message.MessageRole  `json:"role"`
+	Model       string               `json:"model,omitempty"`
+	Text        string               `json:"text,omitempty"`
+	Reasoning   string               `json:"reasoning,omitempty"`
+	ToolCalls   []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []message.ToolResult `json:"tool_results,omitempty"`
+	CreatedAt   int64                `json:"created_at"`
+}
+
+func newMessageJSON(msg message.Message) messageJSON {
+	return messageJSON{
+		ID:          msg.ID,
+		Role:        msg.Role,
+		Model:       msg.Model,
+		Text:        msg.Content().Text,
+		Reasoning:   msg.ReasoningContent().Thinking,
+		ToolCalls:   msg.ToolCalls(),
+		ToolResults: msg.ToolResults(),
+		CreatedAt:   msg.CreatedAt,
+	}
+}
+
+type searchResultJSON struct {
+	MessageID    string              `json:"message_id"`
+	SessionID    string              `json:"session_id"`
+	SessionTitle string              `json:"session_title"`
+	Role         message.MessageRole `json:"role"`
+	Snippet      string              `json:"snippet"`
+	CreatedAt    int64               `json:"created_at"`
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printMessage(msg message.Message) {
+	fmt.Printf("\n[%s] %s", formatTimestamp(msg.CreatedAt), msg.Role)
+	if msg.Role == message.Assistant && msg.Model != "" {
+		fmt.Printf(" (%s)", msg.Model)
+	}
+	fmt.Println()
+	if reasoning := strings.TrimSpace(msg.ReasoningContent().Thinking); reasoning != "" {
+		fmt.Printf("Thinking: %s\n", reasoning)
+	}
+	if text := strings.TrimSpace(msg.Content().Text); text != "" {
+		fmt.Println(text)
+	}
+	for _, call := range msg.ToolCalls() {
+		fmt.Printf("-> %s %s\n", call.Name, call.Input)
+	}
+	for _, result := range msg.ToolResults() {
+		prefix := "<-"
+		if result.IsError {
+			prefix = "<- error:"
+		}
+		fmt.Printf("%s %s\n", prefix, strings.TrimSpace(result.Content))
+	}
+}
+
+// parseAge parses a duration that, in addition to the units understood by
+// time.ParseDuration, may be given in days ("30d") or weeks ("2w").
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
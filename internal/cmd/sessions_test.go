@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAge(t *testing.T) {
+	t.Parallel()
+
+	for input, want := range map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1.5d":  36 * time.Hour,
+		"12h":   12 * time.Hour,
+		" 90m ": 90 * time.Minute,
+	} {
+		age, err := parseAge(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, age, input)
+	}
+	for _, input := range []string{"", "d", "-1d", "-2h", "month", "3y"} {
+		_, err := parseAge(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestPruneSessions(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	sessions := session.NewService(q)
+
+	old := time.Now().Add(-48 * time.Hour).Unix()
+	child := func(id, parentID, forkMessageID string, updatedAt int64) {
+		_, err := q.ImportSession(ctx, db.ImportSessionParams{
+			ID:              id,
+			ParentSessionID: sql.NullString{String: parentID, Valid: parentID != ""},
+			ForkMessageID:   sql.NullString{String: forkMessageID, Valid: forkMessageID != ""},
+			Title:           id,
+			UpdatedAt:       updatedAt,
+			CreatedAt:       updatedAt,
+		})
+		require.NoError(t, err)
+	}
+	child("old", "", "", old)
+	child("task", "old", "", old)
+	child("title-old", "old", "", old)
+	child("fork", "old", "message", time.Now().Unix())
+	recent, err := sessions.Create(ctx, "recent")
+	require.NoError(t, err)
+
+	_, err = q.CreateMessage(ctx, db.CreateMessageParams{ID: "task-message", SessionID: "task", Role: "user", Parts: "[]"})
+	require.NoError(t, err)
+	_, err = q.CreateFile(ctx, db.CreateFileParams{ID: "task-file", SessionID: "task", Path: "/work/main.go", Content: "package main\n"})
+	require.NoError(t, err)
+
+	cutoff := time.Now().Add(-24 * time.Hour).Unix()
+	pruned, err := pruneSessions(ctx, sessions, cutoff, true)
+	require.NoError(t, err)
+	require.Len(t, pruned, 1)
+	require.Equal(t, "old", pruned[0].ID)
+	_, err = sessions.Get(ctx, "old")
+	require.NoError(t, err, "a dry run deletes nothing")
+
+	pruned, err = pruneSessions(ctx, sessions, cutoff, false)
+	require.NoError(t, err)
+	require.Len(t, pruned, 1)
+	for _, id := range []string{"old", "task", "title-old"} {
+		_, err = sessions.Get(ctx, id)
+		require.ErrorIs(t, err, sql.ErrNoRows, "the sessions of sub-agents are deleted with their parent: %s", id)
+	}
+	_, err = q.GetMessage(ctx, "task-message")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = q.GetFile(ctx, "task-file")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, id := range []string{"fork", recent.ID} {
+		_, err = sessions.Get(ctx, id)
+		require.NoError(t, err, id)
+	}
+}
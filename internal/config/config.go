@@ -18,8 +18,11 @@ import (
 )
 
 const (
-	appName              = "surya"
-	defaultDataDirectory = ".openpilot"
+	appName                    = "surya"
+	defaultDataDirectory       = ".openpilot"
+	defaultCompactionThreshold = 0.9
+	defaultCompactionKeepTurns = 2
+	defaultPruneAfterTurns     = 4
 )
 
 var defaultContextPaths = []string{
@@ -117,12 +120,13 @@ type MCPConfig struct {
 }
 
 type LSPConfig struct {
-	Disabled  bool              `json:"enabled,omitempty" jsonschema:"description=Whether this LSP server is disabled,default=false"`
-	Command   string            `json:"command" jsonschema:"required,description=Command to execute for the LSP server,example=gopls"`
-	Args      []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the LSP server command"`
-	Env       map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set to the LSP server command"`
-	Options   any               `json:"options,omitempty" jsonschema:"description=LSP server-specific configuration options"`
-	FileTypes []string          `json:"filetypes,omitempty" jsonschema:"description=File types this LSP server handles,example=go,example=mod,example=rs,example=c,example=js,example=ts"`
+	Disabled      bool              `json:"enabled,omitempty" jsonschema:"description=Whether this LSP server is disabled,default=false"`
+	Command       string            `json:"command" jsonschema:"required,description=Command to execute for the LSP server,example=gopls"`
+	Args          []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the LSP server command"`
+	Env           map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set to the LSP server command"`
+	Options       any               `json:"options,omitempty" jsonschema:"description=LSP server-specific configuration options"`
+	FileTypes     []string          `json:"filetypes,omitempty" jsonschema:"description=File types this LSP server handles,example=go,example=mod,example=rs,example=c,example=js,example=ts"`
+	FormatOnWrite bool              `json:"format_on_write,omitempty" jsonschema:"description=Format files handled by this LSP server after the agent edits or writes them,default=false"`
 }
 
 type TUIOptions struct {
@@ -134,15 +138,73 @@ type TUIOptions struct {
 type Permissions struct {
 	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
 	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	// Rules are evaluated in order; a matching deny rule always wins.
+	Rules       []PermissionRule `json:"rules,omitempty" jsonschema:"description=Rules that allow or deny tool requests or always ask for them. Deny rules take precedence and apply even when permission prompts are skipped"`
+	GrantExpiry string           `json:"grant_expiry,omitempty" jsonschema:"description=How long permissions granted with Always Allow last as a duration like 720h. They never expire when unset,example=720h"`
+}
+
+type PermissionRule struct {
+	Decision string `json:"decision" jsonschema:"required,description=What to do with matching requests,enum=allow,enum=deny,enum=ask"`
+	// Pattern is a tool name with an optional specifier in parentheses: a
+	// command prefix or glob for bash or a path glob for file tools.
+	Pattern string `json:"pattern" jsonschema:"required,description=Tool name with an optional command prefix or glob for bash or path glob for file tools in parentheses,example=bash(go test ./...),example=bash(git push*),example=edit(**/.env)"`
 }
 
 type Options struct {
-	ContextPaths         []string    `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=OPENPILOT.md"`
-	TUI                  *TUIOptions `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
-	Debug                bool        `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
-	DebugLSP             bool        `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
-	DisableAutoSummarize bool        `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
-	DataDirectory        string      `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.openpilot,example=.openpilot"` // Relative to the cwd
+	ContextPaths         []string           `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=OPENPILOT.md"`
+	TUI                  *TUIOptions        `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
+	Debug                bool               `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
+	DebugLSP             bool               `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	DisableAutoSummarize bool               `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	DataDirectory        string             `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.openpilot,example=.openpilot"` // Relative to the cwd
+	Sandbox              *SandboxOptions    `json:"sandbox,omitempty" jsonschema:"description=Sandbox for the commands of the bash tool on Linux"`
+	Bash                 *BashOptions       `json:"bash,omitempty" jsonschema:"description=Command policy of the bash tool"`
+	Compaction           *CompactionOptions `json:"compaction,omitempty" jsonschema:"description=Automatic compaction of conversations that outgrow the context window"`
+}
+
+type CompactionStrategy string
+
+const (
+	// CompactionSummarize replaces the conversation with a summary written
+	// by the summarizer model.
+	CompactionSummarize CompactionStrategy = "summarize"
+	// CompactionIncremental summarizes all but the last turns, which are
+	// kept verbatim.
+	CompactionIncremental CompactionStrategy = "incremental"
+	// CompactionTruncate drops the oldest turns without calling a model.
+	CompactionTruncate CompactionStrategy = "truncate"
+)
+
+// CompactionOptions control when the agent compacts a conversation before
+// a request, and how, and when it leaves old tool results out of the
+// requests. Compaction is off when auto-summarize is disabled; pruning is
+// not.
+type CompactionOptions struct {
+	Threshold       float64            `json:"threshold,omitempty" jsonschema:"description=Fraction of the context window of the model the next request may fill before the conversation is compacted,default=0.9,minimum=0.1,maximum=1"`
+	Strategy        CompactionStrategy `json:"strategy,omitempty" jsonschema:"description=How the conversation is compacted,enum=summarize,enum=incremental,enum=truncate,default=summarize"`
+	KeepTurns       int                `json:"keep_turns,omitempty" jsonschema:"description=Number of recent turns the incremental strategy keeps verbatim,default=2,minimum=1"`
+	PruneAfterTurns int                `json:"prune_after_turns,omitempty" jsonschema:"description=Number of turns after which large tool results are left out of the requests,default=4,minimum=1"`
+}
+
+// BashOptions extend the built-in lists of commands the bash tool blocks and
+// runs without permission prompts. Patterns are written like commands: "rm
+// -rf" matches rm with the -r and -f flags and "git push" matches git with
+// push among its arguments.
+type BashOptions struct {
+	BlockedCommands []string `json:"blocked_commands,omitempty" jsonschema:"description=Patterns of commands the bash tool refuses to run,example=rm -rf,example=git push --force"`
+	AllowedCommands []string `json:"allowed_commands,omitempty" jsonschema:"description=Patterns of commands exempt from the built-in blocked commands. They still need permission unless they are safe,example=curl http://localhost*"`
+	SafeCommands    []string `json:"safe_commands,omitempty" jsonschema:"description=Patterns of read-only commands that run without permission prompts,example=make lint"`
+}
+
+// SandboxOptions restrict the commands of the bash tool to the working
+// directory and the temp directory, without network access. The system
+// directories are always readable.
+type SandboxOptions struct {
+	Enabled bool `json:"enabled,omitempty" jsonschema:"description=Run bash commands in a sandbox,default=false"`
+	// Paths are relative to the working directory.
+	ReadOnlyPaths  []string `json:"read_only_paths,omitempty" jsonschema:"description=Additional paths commands can read,example=~/go/pkg/mod"`
+	ReadWritePaths []string `json:"read_write_paths,omitempty" jsonschema:"description=Additional paths commands can read and write besides the working directory,example=~/.cache/go-build"`
+	AllowNetwork   bool     `json:"allow_network,omitempty" jsonschema:"description=Allow network access in the sandbox,default=false"`
 }
 
 type MCPs map[string]MCPConfig
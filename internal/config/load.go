@@ -38,15 +38,22 @@ func LoadReader(fd io.Reader) (*Config, error) {
 	return &config, err
 }
 
+// ProjectConfigPaths returns the paths of the config files of the project in
+// workingDir, which override the global ones.
+func ProjectConfigPaths(workingDir string) []string {
+	return []string{
+		filepath.Join(workingDir, fmt.Sprintf("%s.json", appName)),
+		filepath.Join(workingDir, fmt.Sprintf(".%s.json", appName)),
+	}
+}
+
 // Load loads the configuration from the default paths.
 func Load(workingDir string, debug bool) (*Config, error) {
 	// uses default config paths
-	configPaths := []string{
+	configPaths := append([]string{
 		globalConfig(),
 		GlobalConfigData(),
-		filepath.Join(workingDir, fmt.Sprintf("%s.json", appName)),
-		filepath.Join(workingDir, fmt.Sprintf(".%s.json", appName)),
-	}
+	}, ProjectConfigPaths(workingDir)...)
 	cfg, err := loadFromConfigPaths(configPaths)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config from paths %v: %w", configPaths, err)
@@ -314,6 +321,25 @@ func (c *Config) setDefaults(workingDir string) {
 	if c.Options.DataDirectory == "" {
 		c.Options.DataDirectory = filepath.Join(workingDir, defaultDataDirectory)
 	}
+	if c.Options.Sandbox != nil {
+		c.Options.Sandbox.ReadOnlyPaths = resolvePaths(workingDir, c.Options.Sandbox.ReadOnlyPaths)
+		c.Options.Sandbox.ReadWritePaths = resolvePaths(workingDir, c.Options.Sandbox.ReadWritePaths)
+	}
+	if c.Options.Compaction == nil {
+		c.Options.Compaction = &CompactionOptions{}
+	}
+	if c.Options.Compaction.Threshold <= 0 || c.Options.Compaction.Threshold > 1 {
+		c.Options.Compaction.Threshold = defaultCompactionThreshold
+	}
+	if c.Options.Compaction.Strategy == "" {
+		c.Options.Compaction.Strategy = CompactionSummarize
+	}
+	if c.Options.Compaction.KeepTurns <= 0 {
+		c.Options.Compaction.KeepTurns = defaultCompactionKeepTurns
+	}
+	if c.Options.Compaction.PruneAfterTurns <= 0 {
+		c.Options.Compaction.PruneAfterTurns = defaultPruneAfterTurns
+	}
 	if c.Providers == nil {
 		c.Providers = csync.NewMap[string, ProviderConfig]()
 	}
@@ -336,6 +362,21 @@ func (c *Config) setDefaults(workingDir string) {
 	c.Options.ContextPaths = slices.Compact(c.Options.ContextPaths)
 }
 
+// resolvePaths expands ~ and makes the paths absolute.
+func resolvePaths(workingDir string, paths []string) []string {
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		if p == "~" || strings.HasPrefix(p, "~/") {
+			p = filepath.Join(HomeDir(), p[1:])
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(workingDir, p)
+		}
+		resolved[i] = filepath.Clean(p)
+	}
+	return resolved
+}
+
 var defaultLSPFileTypes = map[string][]string{
 	"gopls":                      {"go", "mod", "sum", "work"},
 	"typescript-language-server": {"ts", "tsx", "js", "jsx", "mjs", "cjs"},
@@ -16,12 +16,14 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
-    updated_at
+    updated_at,
+    created_at_ms
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), CAST(unixepoch('subsec') * 1000 AS INTEGER)
 )
-RETURNING id, session_id, path, content, version, created_at, updated_at
+RETURNING id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 `
 
 type CreateFileParams struct {
@@ -30,6 +32,7 @@ type CreateFileParams struct {
 	Path      string `json:"path"`
 	Content   string `json:"content"`
 	Version   int64  `json:"version"`
+	IsNew     bool   `json:"is_new"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
@@ -39,6 +42,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		arg.Path,
 		arg.Content,
 		arg.Version,
+		arg.IsNew,
 	)
 	var i File
 	err := row.Scan(
@@ -49,6 +53,8 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreatedAtMs,
+		&i.IsNew,
 	)
 	return i, err
 }
@@ -74,7 +80,7 @@ func (q *Queries) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 }
 
 const getFile = `-- name: GetFile :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 FROM files
 WHERE id = ? LIMIT 1
 `
@@ -90,12 +96,14 @@ func (q *Queries) GetFile(ctx context.Context, id string) (File, error) {
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreatedAtMs,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const getFileByPathAndSession = `-- name: GetFileByPathAndSession :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 FROM files
 WHERE path = ? AND session_id = ?
 ORDER BY version DESC, created_at DESC
@@ -118,12 +126,70 @@ func (q *Queries) GetFileByPathAndSession(ctx context.Context, arg GetFileByPath
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CreatedAtMs,
+		&i.IsNew,
+	)
+	return i, err
+}
+
+const importFile = `-- name: ImportFile :one
+INSERT INTO files (
+    id,
+    session_id,
+    path,
+    content,
+    version,
+    created_at,
+    updated_at,
+    created_at_ms,
+    is_new
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
+`
+
+type ImportFileParams struct {
+	ID          string `json:"id"`
+	SessionID   string `json:"session_id"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Version     int64  `json:"version"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+	CreatedAtMs int64  `json:"created_at_ms"`
+	IsNew       bool   `json:"is_new"`
+}
+
+func (q *Queries) ImportFile(ctx context.Context, arg ImportFileParams) (File, error) {
+	row := q.queryRow(ctx, q.importFileStmt, importFile,
+		arg.ID,
+		arg.SessionID,
+		arg.Path,
+		arg.Content,
+		arg.Version,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.CreatedAtMs,
+		arg.IsNew,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Path,
+		&i.Content,
+		&i.Version,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CreatedAtMs,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const listFilesByPath = `-- name: ListFilesByPath :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 FROM files
 WHERE path = ?
 ORDER BY version DESC, created_at DESC
@@ -146,6 +212,8 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAtMs,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +229,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 }
 
 const listFilesBySession = `-- name: ListFilesBySession :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 FROM files
 WHERE session_id = ?
 ORDER BY version ASC, created_at ASC
@@ -184,6 +252,8 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAtMs,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -199,7 +269,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 }
 
 const listLatestSessionFiles = `-- name: ListLatestSessionFiles :many
-SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at
+SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at, f.created_at_ms, f.is_new
 FROM files f
 INNER JOIN (
     SELECT path, MAX(version) as max_version, MAX(created_at) as max_created_at
@@ -227,6 +297,8 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAtMs,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -242,7 +314,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 }
 
 const listNewFiles = `-- name: ListNewFiles :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, created_at_ms, is_new
 FROM files
 WHERE is_new = 1
 ORDER BY version DESC, created_at DESC
@@ -265,6 +337,8 @@ func (q *Queries) ListNewFiles(ctx context.Context) ([]File, error) {
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CreatedAtMs,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -10,6 +10,66 @@ import (
 	"database/sql"
 )
 
+const copyMessage = `-- name: CopyMessage :one
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    created_at,
+    updated_at,
+    finished_at,
+    created_at_ms
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, created_at_ms
+`
+
+type CopyMessageParams struct {
+	ID          string         `json:"id"`
+	SessionID   string         `json:"session_id"`
+	Role        string         `json:"role"`
+	Parts       string         `json:"parts"`
+	Model       sql.NullString `json:"model"`
+	Provider    sql.NullString `json:"provider"`
+	CreatedAt   int64          `json:"created_at"`
+	UpdatedAt   int64          `json:"updated_at"`
+	FinishedAt  sql.NullInt64  `json:"finished_at"`
+	CreatedAtMs int64          `json:"created_at_ms"`
+}
+
+func (q *Queries) CopyMessage(ctx context.Context, arg CopyMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.copyMessageStmt, copyMessage,
+		arg.ID,
+		arg.SessionID,
+		arg.Role,
+		arg.Parts,
+		arg.Model,
+		arg.Provider,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.FinishedAt,
+		arg.CreatedAtMs,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Role,
+		&i.Parts,
+		&i.Model,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.FinishedAt,
+		&i.Provider,
+		&i.CreatedAtMs,
+	)
+	return i, err
+}
+
 const createMessage = `-- name: CreateMessage :one
 INSERT INTO messages (
     id,
@@ -19,11 +79,12 @@ INSERT INTO messages (
     model,
     provider,
     created_at,
-    updated_at
+    updated_at,
+    created_at_ms
 ) VALUES (
-    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), CAST(unixepoch('subsec') * 1000 AS INTEGER)
 )
-RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, created_at_ms
 `
 
 type CreateMessageParams struct {
@@ -55,6 +116,7 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.UpdatedAt,
 		&i.FinishedAt,
 		&i.Provider,
+		&i.CreatedAtMs,
 	)
 	return i, err
 }
@@ -80,7 +142,7 @@ func (q *Queries) DeleteSessionMessages(ctx context.Context, sessionID string) e
 }
 
 const getMessage = `-- name: GetMessage :one
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, created_at_ms
 FROM messages
 WHERE id = ? LIMIT 1
 `
@@ -98,12 +160,13 @@ func (q *Queries) GetMessage(ctx context.Context, id string) (Message, error) {
 		&i.UpdatedAt,
 		&i.FinishedAt,
 		&i.Provider,
+		&i.CreatedAtMs,
 	)
 	return i, err
 }
 
 const listMessagesBySession = `-- name: ListMessagesBySession :many
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, created_at_ms
 FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC
@@ -128,6 +191,68 @@ func (q *Queries) ListMessagesBySession(ctx context.Context, sessionID string) (
 			&i.UpdatedAt,
 			&i.FinishedAt,
 			&i.Provider,
+			&i.CreatedAtMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMessages = `-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    m.role,
+    m.created_at,
+    s.title AS session_title,
+    CAST(snippet(messages_fts, 0, '', '', '…', 16) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages AS m ON m.rowid = messages_fts.rowid
+JOIN sessions AS s ON s.id = m.session_id
+WHERE messages_fts MATCH ?
+    AND (s.parent_session_id IS NULL OR s.fork_message_id IS NOT NULL)
+ORDER BY rank
+LIMIT ?
+`
+
+type SearchMessagesParams struct {
+	Query      string `json:"query"`
+	MaxResults int64  `json:"max_results"`
+}
+
+type SearchMessagesRow struct {
+	ID           string `json:"id"`
+	SessionID    string `json:"session_id"`
+	Role         string `json:"role"`
+	CreatedAt    int64  `json:"created_at"`
+	SessionTitle string `json:"session_title"`
+	Snippet      string `json:"snippet"`
+}
+
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.query(ctx, q.searchMessagesStmt, searchMessages, arg.Query, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.SessionTitle,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
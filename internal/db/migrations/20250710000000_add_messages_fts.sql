@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+    content,
+    tokenize = 'porter unicode61'
+);
+-- +goose StatementEnd
+
+-- The index is keyed by the rowid of the message and holds the text parts of
+-- its content, joined by newlines.
+-- +goose StatementBegin
+INSERT INTO messages_fts (rowid, content)
+SELECT m.rowid, (
+    SELECT group_concat(json_extract(p.value, '$.data.text'), char(10))
+    FROM json_each(m.parts) AS p
+    WHERE json_extract(p.value, '$.type') = 'text'
+)
+FROM messages AS m;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert
+AFTER INSERT ON messages
+BEGIN
+INSERT INTO messages_fts (rowid, content)
+SELECT NEW.rowid, (
+    SELECT group_concat(json_extract(p.value, '$.data.text'), char(10))
+    FROM json_each(NEW.parts) AS p
+    WHERE json_extract(p.value, '$.type') = 'text'
+);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS messages_fts_update
+AFTER UPDATE OF parts ON messages
+BEGIN
+DELETE FROM messages_fts WHERE rowid = OLD.rowid;
+INSERT INTO messages_fts (rowid, content)
+SELECT NEW.rowid, (
+    SELECT group_concat(json_extract(p.value, '$.data.text'), char(10))
+    FROM json_each(NEW.parts) AS p
+    WHERE json_extract(p.value, '$.type') = 'text'
+);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete
+AFTER DELETE ON messages
+BEGIN
+DELETE FROM messages_fts WHERE rowid = OLD.rowid;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS messages_fts_delete;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS messages_fts_update;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS messages_fts_insert;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS messages_fts;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS permission_grants (
+    id TEXT PRIMARY KEY,
+    tool_name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    path TEXT NOT NULL,
+    expires_at INTEGER,  -- Unix timestamp in seconds, NULL when it never expires
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS permission_grants;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS permission_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    tool_call_id TEXT NOT NULL,
+    tool_name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    path TEXT NOT NULL,
+    params TEXT NOT NULL,
+    decision TEXT NOT NULL CHECK (decision IN ('granted', 'denied')),
+    decider TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at INTEGER NOT NULL  -- Unix timestamp in seconds
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_permission_audit_session_id ON permission_audit (session_id);
+-- +goose StatementEnd
+
+-- Entries outlive their sessions and can't be changed once written.
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS permission_audit_no_update
+BEFORE UPDATE ON permission_audit
+BEGIN
+    SELECT RAISE(ABORT, 'the permission audit log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS permission_audit_no_delete
+BEFORE DELETE ON permission_audit
+BEGIN
+    SELECT RAISE(ABORT, 'the permission audit log is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS permission_audit_no_delete;
+DROP TRIGGER IF EXISTS permission_audit_no_update;
+DROP INDEX IF EXISTS idx_permission_audit_session_id;
+DROP TABLE IF EXISTS permission_audit;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- created_at only has second precision, which cannot order a prompt against
+-- the file edits made in the same second.
+ALTER TABLE messages ADD COLUMN created_at_ms INTEGER NOT NULL DEFAULT 0;
+UPDATE messages SET created_at_ms = created_at * 1000;
+ALTER TABLE files ADD COLUMN created_at_ms INTEGER NOT NULL DEFAULT 0;
+UPDATE files SET created_at_ms = created_at * 1000;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN created_at_ms;
+ALTER TABLE messages DROP COLUMN created_at_ms;
+-- +goose StatementEnd
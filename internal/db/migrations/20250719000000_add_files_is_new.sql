@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An empty initial version can't tell a file the session created from a file
+-- that existed but was empty, and reverting must only remove the former.
+ALTER TABLE files ADD COLUMN is_new BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE files SET is_new = TRUE WHERE version = 0 AND content = '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN is_new;
+-- +goose StatementEnd
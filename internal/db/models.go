@@ -9,25 +9,51 @@ import (
 )
 
 type File struct {
-	ID        string `json:"id"`
-	SessionID string `json:"session_id"`
-	Path      string `json:"path"`
-	Content   string `json:"content"`
-	Version   int64  `json:"version"`
-	CreatedAt int64  `json:"created_at"`
-	UpdatedAt int64  `json:"updated_at"`
+	ID          string `json:"id"`
+	SessionID   string `json:"session_id"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Version     int64  `json:"version"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
+	CreatedAtMs int64  `json:"created_at_ms"`
+	IsNew       bool   `json:"is_new"`
 }
 
 type Message struct {
-	ID         string         `json:"id"`
-	SessionID  string         `json:"session_id"`
-	Role       string         `json:"role"`
-	Parts      string         `json:"parts"`
-	Model      sql.NullString `json:"model"`
-	CreatedAt  int64          `json:"created_at"`
-	UpdatedAt  int64          `json:"updated_at"`
-	FinishedAt sql.NullInt64  `json:"finished_at"`
-	Provider   sql.NullString `json:"provider"`
+	ID          string         `json:"id"`
+	SessionID   string         `json:"session_id"`
+	Role        string         `json:"role"`
+	Parts       string         `json:"parts"`
+	Model       sql.NullString `json:"model"`
+	CreatedAt   int64          `json:"created_at"`
+	UpdatedAt   int64          `json:"updated_at"`
+	FinishedAt  sql.NullInt64  `json:"finished_at"`
+	Provider    sql.NullString `json:"provider"`
+	CreatedAtMs int64          `json:"created_at_ms"`
+}
+
+type PermissionAudit struct {
+	ID         int64  `json:"id"`
+	SessionID  string `json:"session_id"`
+	ToolCallID string `json:"tool_call_id"`
+	ToolName   string `json:"tool_name"`
+	Action     string `json:"action"`
+	Path       string `json:"path"`
+	Params     string `json:"params"`
+	Decision   string `json:"decision"`
+	Decider    string `json:"decider"`
+	Reason     string `json:"reason"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type PermissionGrant struct {
+	ID        string        `json:"id"`
+	ToolName  string        `json:"tool_name"`
+	Action    string        `json:"action"`
+	Path      string        `json:"path"`
+	ExpiresAt sql.NullInt64 `json:"expires_at"`
+	CreatedAt int64         `json:"created_at"`
 }
 
 type Session struct {
@@ -41,4 +67,6 @@ type Session struct {
 	UpdatedAt        int64          `json:"updated_at"`
 	CreatedAt        int64          `json:"created_at"`
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
+	ForkMessageID    sql.NullString `json:"fork_message_id"`
+	KeptMessageID    sql.NullString `json:"kept_message_id"`
 }
@@ -9,11 +9,16 @@ import (
 )
 
 type Querier interface {
+	CopyMessage(ctx context.Context, arg CopyMessageParams) (Message, error)
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreatePermissionAuditEntry(ctx context.Context, arg CreatePermissionAuditEntryParams) error
+	CreatePermissionGrant(ctx context.Context, arg CreatePermissionGrantParams) (PermissionGrant, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
+	DeletePermissionGrant(ctx context.Context, id string) (int64, error)
+	// The task and title sessions of a session go with it, but not its forks.
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
@@ -21,12 +26,18 @@ type Querier interface {
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	ImportFile(ctx context.Context, arg ImportFileParams) (File, error)
+	ImportSession(ctx context.Context, arg ImportSessionParams) (Session, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListPermissionAudit(ctx context.Context, arg ListPermissionAuditParams) ([]PermissionAudit, error)
+	ListPermissionGrants(ctx context.Context) ([]PermissionGrant, error)
+	ListSessionPermissionAudit(ctx context.Context, arg ListSessionPermissionAuditParams) ([]PermissionAudit, error)
 	ListSessions(ctx context.Context) ([]Session, error)
+	SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
 }
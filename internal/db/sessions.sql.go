@@ -20,6 +20,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    fork_message_id,
     updated_at,
     created_at
 ) VALUES (
@@ -31,9 +32,10 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, fork_message_id, kept_message_id
 `
 
 type CreateSessionParams struct {
@@ -44,6 +46,7 @@ type CreateSessionParams struct {
 	PromptTokens     int64          `json:"prompt_tokens"`
 	CompletionTokens int64          `json:"completion_tokens"`
 	Cost             float64        `json:"cost"`
+	ForkMessageID    sql.NullString `json:"fork_message_id"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -55,6 +58,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.Cost,
+		arg.ForkMessageID,
 	)
 	var i Session
 	err := row.Scan(
@@ -68,22 +72,33 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.ForkMessageID,
+		&i.KeptMessageID,
 	)
 	return i, err
 }
 
 const deleteSession = `-- name: DeleteSession :exec
+WITH RECURSIVE tree(id) AS (
+    SELECT ?
+    UNION ALL
+    SELECT s.id
+    FROM sessions s
+    JOIN tree ON s.parent_session_id = tree.id
+    WHERE s.fork_message_id IS NULL
+)
 DELETE FROM sessions
-WHERE id = ?
+WHERE id IN tree
 `
 
+// The task and title sessions of a session go with it, but not its forks.
 func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 	_, err := q.exec(ctx, q.deleteSessionStmt, deleteSession, id)
 	return err
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, fork_message_id, kept_message_id
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -102,14 +117,80 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.ForkMessageID,
+		&i.KeptMessageID,
+	)
+	return i, err
+}
+
+const importSession = `-- name: ImportSession :one
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    title,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    summary_message_id,
+    kept_message_id,
+    fork_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, fork_message_id, kept_message_id
+`
+
+type ImportSessionParams struct {
+	ID               string         `json:"id"`
+	ParentSessionID  sql.NullString `json:"parent_session_id"`
+	Title            string         `json:"title"`
+	PromptTokens     int64          `json:"prompt_tokens"`
+	CompletionTokens int64          `json:"completion_tokens"`
+	Cost             float64        `json:"cost"`
+	SummaryMessageID sql.NullString `json:"summary_message_id"`
+	KeptMessageID    sql.NullString `json:"kept_message_id"`
+	ForkMessageID    sql.NullString `json:"fork_message_id"`
+	UpdatedAt        int64          `json:"updated_at"`
+	CreatedAt        int64          `json:"created_at"`
+}
+
+func (q *Queries) ImportSession(ctx context.Context, arg ImportSessionParams) (Session, error) {
+	row := q.queryRow(ctx, q.importSessionStmt, importSession,
+		arg.ID,
+		arg.ParentSessionID,
+		arg.Title,
+		arg.PromptTokens,
+		arg.CompletionTokens,
+		arg.Cost,
+		arg.SummaryMessageID,
+		arg.KeptMessageID,
+		arg.ForkMessageID,
+		arg.UpdatedAt,
+		arg.CreatedAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.ForkMessageID,
+		&i.KeptMessageID,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, fork_message_id, kept_message_id
 FROM sessions
-WHERE parent_session_id is NULL
+WHERE parent_session_id is NULL OR fork_message_id IS NOT NULL
 ORDER BY created_at DESC
 `
 
@@ -133,6 +214,8 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.UpdatedAt,
 			&i.CreatedAt,
 			&i.SummaryMessageID,
+			&i.ForkMessageID,
+			&i.KeptMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -154,9 +237,10 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
+    kept_message_id = ?,
     cost = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, fork_message_id, kept_message_id
 `
 
 type UpdateSessionParams struct {
@@ -164,6 +248,7 @@ type UpdateSessionParams struct {
 	PromptTokens     int64          `json:"prompt_tokens"`
 	CompletionTokens int64          `json:"completion_tokens"`
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
+	KeptMessageID    sql.NullString `json:"kept_message_id"`
 	Cost             float64        `json:"cost"`
 	ID               string         `json:"id"`
 }
@@ -174,6 +259,7 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.SummaryMessageID,
+		arg.KeptMessageID,
 		arg.Cost,
 		arg.ID,
 	)
@@ -189,6 +275,8 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UpdatedAt,
 		&i.CreatedAt,
 		&i.SummaryMessageID,
+		&i.ForkMessageID,
+		&i.KeptMessageID,
 	)
 	return i, err
 }
@@ -29,10 +29,28 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
-    updated_at
+    updated_at,
+    created_at_ms
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), CAST(unixepoch('subsec') * 1000 AS INTEGER)
+)
+RETURNING *;
+
+-- name: ImportFile :one
+INSERT INTO files (
+    id,
+    session_id,
+    path,
+    content,
+    version,
+    created_at,
+    updated_at,
+    created_at_ms,
+    is_new
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
@@ -18,9 +18,27 @@ INSERT INTO messages (
     model,
     provider,
     created_at,
-    updated_at
+    updated_at,
+    created_at_ms
 ) VALUES (
-    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now'), CAST(unixepoch('subsec') * 1000 AS INTEGER)
+)
+RETURNING *;
+
+-- name: CopyMessage :one
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    created_at,
+    updated_at,
+    finished_at,
+    created_at_ms
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
@@ -40,3 +58,19 @@ WHERE id = ?;
 -- name: DeleteSessionMessages :exec
 DELETE FROM messages
 WHERE session_id = ?;
+
+-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    m.role,
+    m.created_at,
+    s.title AS session_title,
+    CAST(snippet(messages_fts, 0, '', '', '…', 16) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages AS m ON m.rowid = messages_fts.rowid
+JOIN sessions AS s ON s.id = m.session_id
+WHERE messages_fts MATCH sqlc.arg(query)
+    AND (s.parent_session_id IS NULL OR s.fork_message_id IS NOT NULL)
+ORDER BY rank
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,29 @@
+-- name: CreatePermissionAuditEntry :exec
+INSERT INTO permission_audit (
+    session_id,
+    tool_call_id,
+    tool_name,
+    action,
+    path,
+    params,
+    decision,
+    decider,
+    reason,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: ListPermissionAudit :many
+SELECT *
+FROM permission_audit
+WHERE created_at >= sqlc.arg(since)
+ORDER BY id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListSessionPermissionAudit :many
+SELECT *
+FROM permission_audit
+WHERE session_id = sqlc.arg(session_id) AND created_at >= sqlc.arg(since)
+ORDER BY id DESC
+LIMIT sqlc.arg(max_results);
@@ -8,6 +8,7 @@ INSERT INTO sessions (
     completion_tokens,
     cost,
     summary_message_id,
+    fork_message_id,
     updated_at,
     created_at
 ) VALUES (
@@ -19,10 +20,28 @@ INSERT INTO sessions (
     ?,
     ?,
     null,
+    ?,
     strftime('%s', 'now'),
     strftime('%s', 'now')
 ) RETURNING *;
 
+-- name: ImportSession :one
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    title,
+    prompt_tokens,
+    completion_tokens,
+    cost,
+    summary_message_id,
+    kept_message_id,
+    fork_message_id,
+    updated_at,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+) RETURNING *;
+
 -- name: GetSessionByID :one
 SELECT *
 FROM sessions
@@ -31,7 +50,7 @@ WHERE id = ? LIMIT 1;
 -- name: ListSessions :many
 SELECT *
 FROM sessions
-WHERE parent_session_id is NULL
+WHERE parent_session_id is NULL OR fork_message_id IS NOT NULL
 ORDER BY created_at DESC;
 
 -- name: UpdateSession :one
@@ -41,11 +60,21 @@ SET
     prompt_tokens = ?,
     completion_tokens = ?,
     summary_message_id = ?,
+    kept_message_id = ?,
     cost = ?
 WHERE id = ?
 RETURNING *;
 
 
 -- name: DeleteSession :exec
+-- The task and title sessions of a session go with it, but not its forks.
+WITH RECURSIVE tree(id) AS (
+    SELECT ?
+    UNION ALL
+    SELECT s.id
+    FROM sessions s
+    JOIN tree ON s.parent_session_id = tree.id
+    WHERE s.fork_message_id IS NULL
+)
 DELETE FROM sessions
-WHERE id = ?;
+WHERE id IN tree;
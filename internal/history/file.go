@@ -4,9 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/fsext"
 	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -23,11 +27,20 @@ type File struct {
 	Version   int64
 	CreatedAt int64
 	UpdatedAt int64
+	// CreatedAtMs orders the version against messages created in the same
+	// second.
+	CreatedAtMs int64
+	// IsNew marks the initial version of a file that did not exist before
+	// the session created it.
+	IsNew bool
 }
 
 type Service interface {
 	pubsub.Suscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
+	// CreateNew records the initial version of a file that did not exist,
+	// which restoring removes.
+	CreateNew(ctx context.Context, sessionID, path string) (File, error)
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
 	Get(ctx context.Context, id string) (File, error)
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
@@ -35,24 +48,39 @@ type Service interface {
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+	// Restore writes the given version back to disk and records it as a new
+	// version, so the restore itself can be undone.
+	Restore(ctx context.Context, id string) (File, error)
+	// RevertSince restores every file the session changed at or after the
+	// given unix timestamp in milliseconds to the content it had before that
+	// time.
+	RevertSince(ctx context.Context, sessionID string, since int64) ([]File, error)
 }
 
 type service struct {
 	*pubsub.Broker[File]
 	db *sql.DB
 	q  *db.Queries
+	// workingDir bounds the files Restore and RevertSince write to, since
+	// versions can come from imported transcripts.
+	workingDir string
 }
 
-func NewService(q *db.Queries, db *sql.DB) Service {
+func NewService(q *db.Queries, db *sql.DB, workingDir string) Service {
 	return &service{
-		Broker: pubsub.NewBroker[File](),
-		q:      q,
-		db:     db,
+		Broker:     pubsub.NewBroker[File](),
+		q:          q,
+		db:         db,
+		workingDir: workingDir,
 	}
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
+}
+
+func (s *service) CreateNew(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createWithVersion(ctx, sessionID, path, "", InitialVersion, true)
 }
 
 func (s *service) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
@@ -71,10 +99,10 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, false)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64, isNew bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -98,6 +126,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			IsNew:     isNew,
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -197,14 +226,104 @@ func (s *service) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 	return nil
 }
 
+func (s *service) Restore(ctx context.Context, id string) (File, error) {
+	file, err := s.Get(ctx, id)
+	if err != nil {
+		return File{}, err
+	}
+	return s.restore(ctx, file)
+}
+
+func (s *service) RevertSince(ctx context.Context, sessionID string, since int64) ([]File, error) {
+	files, err := s.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var restored []File
+	for _, target := range revertTargets(files, since) {
+		file, err := s.restore(ctx, target)
+		if err != nil {
+			return restored, err
+		}
+		restored = append(restored, file)
+	}
+	return restored, nil
+}
+
+// restore writes target to disk, or removes the file when target is the
+// version of a file that did not exist yet.
+func (s *service) restore(ctx context.Context, target File) (File, error) {
+	if !s.inWorkingDir(target.Path) {
+		return File{}, fmt.Errorf("refusing to restore %s, which is outside the working directory %s", target.Path, s.workingDir)
+	}
+	if target.IsNew {
+		if err := os.Remove(target.Path); err != nil && !os.IsNotExist(err) {
+			return File{}, fmt.Errorf("failed to remove %s: %w", target.Path, err)
+		}
+	} else {
+		content := target.Content
+		if current, err := os.ReadFile(target.Path); err == nil {
+			if _, isCrlf := fsext.ToUnixLineEndings(string(current)); isCrlf {
+				content, _ = fsext.ToWindowsLineEndings(content)
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(target.Path), 0o755); err != nil {
+			return File{}, fmt.Errorf("failed to create parent directories: %w", err)
+		}
+		if err := os.WriteFile(target.Path, []byte(content), 0o644); err != nil {
+			return File{}, fmt.Errorf("failed to write %s: %w", target.Path, err)
+		}
+	}
+	return s.CreateVersion(ctx, target.SessionID, target.Path, target.Content)
+}
+
+func (s *service) inWorkingDir(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	rel, err := filepath.Rel(s.workingDir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// revertTargets returns, for every path changed at or after since, the
+// version to restore: the last one created before since, or the initial
+// version when the path was first touched after it. Files must be ordered
+// by version as returned by ListBySession.
+func revertTargets(files []File, since int64) []File {
+	byPath := make(map[string][]File)
+	for _, file := range files {
+		byPath[file.Path] = append(byPath[file.Path], file)
+	}
+
+	var targets []File
+	for _, versions := range byPath {
+		if versions[len(versions)-1].CreatedAtMs < since {
+			continue
+		}
+		target := versions[0]
+		for _, version := range versions {
+			if version.CreatedAtMs < since {
+				target = version
+			}
+		}
+		targets = append(targets, target)
+	}
+	slices.SortFunc(targets, func(a, b File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return targets
+}
+
 func (s *service) fromDBItem(item db.File) File {
 	return File{
-		ID:        item.ID,
-		SessionID: item.SessionID,
-		Path:      item.Path,
-		Content:   item.Content,
-		Version:   item.Version,
-		CreatedAt: item.CreatedAt,
-		UpdatedAt: item.UpdatedAt,
+		ID:          item.ID,
+		SessionID:   item.SessionID,
+		Path:        item.Path,
+		Content:     item.Content,
+		Version:     item.Version,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		CreatedAtMs: item.CreatedAtMs,
+		IsNew:       item.IsNew,
 	}
 }
@@ -0,0 +1,180 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertTargets(t *testing.T) {
+	t.Parallel()
+
+	files := []File{
+		{ID: "a0", Path: "/a.go", Version: 0, CreatedAtMs: 10_000},
+		{ID: "b0", Path: "/b.go", Version: 0, CreatedAtMs: 10_000},
+		{ID: "a1", Path: "/a.go", Version: 1, CreatedAtMs: 10_000},
+		{ID: "a2", Path: "/a.go", Version: 2, CreatedAtMs: 20_000},
+		{ID: "c0", Path: "/c.go", Version: 0, CreatedAtMs: 20_000},
+		{ID: "c1", Path: "/c.go", Version: 1, CreatedAtMs: 20_000},
+	}
+
+	targets := revertTargets(files, 20_000)
+	require.Len(t, targets, 2, "b.go was not changed since the timestamp")
+	require.Equal(t, "a1", targets[0].ID, "the last version before the timestamp is restored")
+	require.Equal(t, "c0", targets[1].ID, "files first touched after the timestamp go back to their initial version")
+
+	require.Empty(t, revertTargets(files, 30_000))
+}
+
+func TestRevertTargetsSameSecond(t *testing.T) {
+	t.Parallel()
+
+	// Both edits and the prompt fall in the same second.
+	files := []File{
+		{ID: "a0", Path: "/a.go", Version: 0, CreatedAt: 10, CreatedAtMs: 10_100},
+		{ID: "a1", Path: "/a.go", Version: 1, CreatedAt: 10, CreatedAtMs: 10_200},
+		{ID: "a2", Path: "/a.go", Version: 2, CreatedAt: 10, CreatedAtMs: 10_700},
+	}
+
+	targets := revertTargets(files, 10_500)
+	require.Len(t, targets, 1)
+	require.Equal(t, "a1", targets[0].ID, "only the edit made after the prompt is reverted")
+}
+
+func TestRevertSince(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	svc := NewService(q, conn, dir)
+
+	path := filepath.Join(dir, "file.txt")
+	_, err = svc.Create(ctx, "session", path, "one\n")
+	require.NoError(t, err)
+	before, err := svc.CreateVersion(ctx, "session", path, "two\n")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	prompt, err := q.CreateMessage(ctx, db.CreateMessageParams{ID: "prompt", SessionID: "session", Role: "user", Parts: "[]"})
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = svc.CreateVersion(ctx, "session", path, "three\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("three\n"), 0o644))
+
+	require.Less(t, before.CreatedAtMs, prompt.CreatedAtMs)
+	restored, err := svc.RevertSince(ctx, "session", prompt.CreatedAtMs)
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "two\n", string(content), "the edit made before the prompt is kept")
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	svc := NewService(q, conn, dir)
+
+	existing := filepath.Join(dir, "existing.txt")
+	created := filepath.Join(dir, "created.txt")
+
+	original, err := svc.Create(ctx, "session", existing, "before\n")
+	require.NoError(t, err)
+	_, err = svc.CreateVersion(ctx, "session", existing, "after\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(existing, []byte("after\r\n"), 0o644))
+
+	restored, err := svc.Restore(ctx, original.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), restored.Version, "a restore is recorded as a new version")
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "before\r\n", string(content), "line endings on disk are kept")
+
+	initial, err := svc.CreateNew(ctx, "session", created)
+	require.NoError(t, err)
+	_, err = svc.CreateVersion(ctx, "session", created, "new file\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(created, []byte("new file\n"), 0o644))
+
+	_, err = svc.Restore(ctx, initial.ID)
+	require.NoError(t, err)
+	_, err = os.Stat(created)
+	require.True(t, os.IsNotExist(err), "files created in the session are removed")
+}
+
+func TestRevertEmptyFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	svc := NewService(q, conn, dir)
+
+	path := filepath.Join(dir, "empty.txt")
+	_, err = svc.Create(ctx, "session", path, "")
+	require.NoError(t, err)
+	_, err = svc.CreateVersion(ctx, "session", path, "filled\n")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("filled\n"), 0o644))
+
+	_, err = svc.RevertSince(ctx, "session", 0)
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err, "a file that existed empty is kept")
+	require.Empty(t, content)
+}
+
+func TestRestoreOutsideWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	svc := NewService(q, conn, t.TempDir())
+
+	// Like a version imported from a transcript made elsewhere.
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("mine\n"), 0o644))
+	version, err := svc.Create(ctx, "session", outside, "theirs\n")
+	require.NoError(t, err)
+
+	_, err = svc.Restore(ctx, version.ID)
+	require.ErrorContains(t, err, "outside the working directory")
+	_, err = svc.RevertSince(ctx, "session", 0)
+	require.ErrorContains(t, err, "outside the working directory")
+	content, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	require.Equal(t, "mine\n", string(content))
+}
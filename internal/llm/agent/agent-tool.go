@@ -16,21 +16,17 @@ type agentTool struct {
 	messages message.Service
 }
 
-const (
-	AgentToolName = "agent"
-)
-
 type AgentParams struct {
 	Prompt string `json:"prompt"`
 }
 
 func (b *agentTool) Name() string {
-	return AgentToolName
+	return tools.AgentToolName
 }
 
 func (b *agentTool) Info() tools.ToolInfo {
 	return tools.ToolInfo{
-		Name:        AgentToolName,
+		Name:        tools.AgentToolName,
 		Description: "Launch a new agent that has access to the following tools: GlobTool, GrepTool, LS, View. When you are searching for a keyword or file and are not confident that you will find the right match on the first try, use the Agent tool to perform the search for you. For example:\n\n- If you are searching for a keyword like \"config\" or \"logger\", or for questions like \"which file does X?\", the Agent tool is strongly recommended\n- If you want to read a specific file path, use the View or GlobTool tool instead of the Agent tool, to find the match more quickly\n- If you are searching for a specific class definition like \"class Foo\", use the GlobTool tool instead, to find the match more quickly\n\nUsage notes:\n1. Launch multiple agents concurrently whenever possible, to maximize performance; to do that, use a single message with multiple tool uses\n2. When the agent is done, it will return a single message back to you. The result returned by the agent is not visible to the user. To show the user the result, you should send a text message back to the user with a concise summary of the result.\n3. Each agent invocation is stateless. You will not be able to send additional messages to the agent, nor will the agent be able to communicate with you outside of its final report. Therefore, your prompt should contain a highly detailed task description for the agent to perform autonomously and you should specify exactly what information the agent should return back to you in its final and only message to you.\n4. The agent's outputs should generally be trusted\n5. IMPORTANT: The agent can not use Bash, Replace, Edit, so can not modify files. If you want to use these tools, use them directly instead of going through the agent.",
 		Parameters: map[string]any{
 			"prompt": map[string]any{
@@ -66,6 +62,7 @@ func (b *agentTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolRes
 		return tools.ToolResponse{}, fmt.Errorf("error generating agent: %s", err)
 	}
 	result := <-done
+	addRunUsage(ctx, result.Usage)
 	if result.Error != nil {
 		return tools.ToolResponse{}, fmt.Errorf("error generating agent: %s", result.Error)
 	}
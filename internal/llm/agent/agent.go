@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -43,6 +44,8 @@ type AgentEvent struct {
 	Type    AgentEventType
 	Message message.Message
 	Error   error
+	// Usage is the usage of all the requests of the run, when it ends.
+	Usage Usage
 
 	// When summarizing
 	SessionID string
@@ -66,10 +69,12 @@ type Service interface {
 
 type agent struct {
 	*pubsub.Broker[AgentEvent]
-	agentCfg config.Agent
-	sessions session.Service
-	messages message.Service
-	mcpTools []McpTool
+	agentCfg    config.Agent
+	sessions    session.Service
+	messages    message.Service
+	permissions permission.Service
+	files       history.Service
+	mcpTools    []McpTool
 
 	tools *csync.LazySlice[tools.BaseTool]
 
@@ -98,6 +103,7 @@ func NewAgent(
 	sessions session.Service,
 	messages message.Service,
 	history history.Service,
+	jobs shell.JobManager,
 	lspClients map[string]*lsp.Client,
 ) (Service, error) {
 	cfg := config.Get()
@@ -108,7 +114,7 @@ func NewAgent(
 		if taskAgentCfg.ID == "" {
 			return nil, fmt.Errorf("task agent not found in config")
 		}
-		taskAgent, err := NewAgent(ctx, taskAgentCfg, permissions, sessions, messages, history, lspClients)
+		taskAgent, err := NewAgent(ctx, taskAgentCfg, permissions, sessions, messages, history, jobs, lspClients)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create task agent: %w", err)
 		}
@@ -173,6 +179,11 @@ func NewAgent(
 		return nil, err
 	}
 
+	bashPolicy, err := tools.NewBashPolicy(cfg.Options.Bash)
+	if err != nil {
+		return nil, err
+	}
+
 	toolFn := func() []tools.BaseTool {
 		slog.Info("Initializing agent tools", "agent", agentCfg.ID)
 		defer func() {
@@ -181,7 +192,9 @@ func NewAgent(
 
 		cwd := cfg.WorkingDir()
 		allTools := []tools.BaseTool{
-			tools.NewBashTool(permissions, cwd),
+			tools.NewBashTool(permissions, cwd, bashSandbox(cfg), jobs, bashPolicy),
+			tools.NewJobOutputTool(jobs),
+			tools.NewJobKillTool(permissions, cwd, jobs),
 			tools.NewDownloadTool(permissions, cwd),
 			tools.NewEditTool(lspClients, permissions, history, cwd),
 			tools.NewMultiEditTool(lspClients, permissions, history, cwd),
@@ -200,7 +213,15 @@ func NewAgent(
 		allTools = append(allTools, mcpTools...)
 
 		if len(lspClients) > 0 {
-			allTools = append(allTools, tools.NewDiagnosticsTool(lspClients))
+			allTools = append(allTools,
+				tools.NewDiagnosticsTool(lspClients),
+				tools.NewDefinitionTool(lspClients, cwd),
+				tools.NewReferencesTool(lspClients, cwd),
+				tools.NewHoverTool(lspClients, cwd),
+				tools.NewSymbolsTool(lspClients, cwd),
+				tools.NewRenameTool(lspClients, permissions, history, cwd),
+				tools.NewCodeActionTool(lspClients, permissions, history, cwd),
+			)
 		}
 
 		if agentTool != nil {
@@ -227,6 +248,8 @@ func NewAgent(
 		providerID:          string(providerCfg.ID),
 		messages:            messages,
 		sessions:            sessions,
+		permissions:         permissions,
+		files:               history,
 		titleProvider:       titleProvider,
 		summarizeProvider:   summarizeProvider,
 		summarizeProviderID: string(providerCfg.ID),
@@ -236,6 +259,25 @@ func NewAgent(
 	}, nil
 }
 
+// bashSandbox returns the sandbox of the bash tool, which can write the
+// working directory, or nil when it is disabled. The git directory, the
+// data directory and the project config stay read-only: they are used
+// outside the sandbox, by git hooks and by the application itself.
+func bashSandbox(cfg *config.Config) *shell.Sandbox {
+	opts := cfg.Options.Sandbox
+	if opts == nil || !opts.Enabled {
+		return nil
+	}
+	protected := []string{filepath.Join(cfg.WorkingDir(), ".git"), cfg.Options.DataDirectory}
+	protected = append(protected, config.ProjectConfigPaths(cfg.WorkingDir())...)
+	return &shell.Sandbox{
+		ReadOnlyPaths:  opts.ReadOnlyPaths,
+		ReadWritePaths: append([]string{cfg.WorkingDir()}, opts.ReadWritePaths...),
+		ProtectedPaths: protected,
+		AllowNetwork:   opts.AllowNetwork,
+	}
+}
+
 func (a *agent) Model() catwalk.Model {
 	return *config.Get().GetModelByType(a.agentCfg.Model)
 }
@@ -355,6 +397,7 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 	}
 
 	genCtx, cancel := context.WithCancel(ctx)
+	genCtx, usage := withRunUsage(genCtx)
 
 	a.activeRequests.Set(sessionID, cancel)
 	go func() {
@@ -367,6 +410,7 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 			attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
 		}
 		result := a.processGeneration(genCtx, sessionID, content, attachmentParts)
+		result.Usage = usage.total()
 		if result.Error != nil && !errors.Is(result.Error, ErrRequestCancelled) && !errors.Is(result.Error, context.Canceled) {
 			slog.Error(result.Error.Error())
 		}
@@ -383,9 +427,9 @@ func (a *agent) Run(ctx context.Context, sessionID string, content string, attac
 func (a *agent) processGeneration(ctx context.Context, sessionID, content string, attachmentParts []message.ContentPart) AgentEvent {
 	cfg := config.Get()
 	// List existing messages; if none, start title generation asynchronously.
-	msgs, err := a.messages.List(ctx, sessionID)
+	msgs, err := a.history(ctx, sessionID)
 	if err != nil {
-		return a.err(fmt.Errorf("failed to list messages: %w", err))
+		return a.err(err)
 	}
 	if len(msgs) == 0 {
 		go func() {
@@ -398,22 +442,11 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 			}
 		}()
 	}
-	session, err := a.sessions.Get(ctx, sessionID)
+	// Compact before the user message is created, so that it follows the
+	// summary.
+	msgs, err = a.compact(ctx, sessionID, msgs, estimateTokens(content))
 	if err != nil {
-		return a.err(fmt.Errorf("failed to get session: %w", err))
-	}
-	if session.SummaryMessageID != "" {
-		summaryMsgInex := -1
-		for i, msg := range msgs {
-			if msg.ID == session.SummaryMessageID {
-				summaryMsgInex = i
-				break
-			}
-		}
-		if summaryMsgInex != -1 {
-			msgs = msgs[summaryMsgInex:]
-			msgs[0].Role = message.User
-		}
+		return a.err(err)
 	}
 
 	userMsg, err := a.createUserMessage(ctx, sessionID, content, attachmentParts)
@@ -423,6 +456,8 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 	// Append the new user message to the conversation history.
 	msgHistory := append(msgs, userMsg)
 
+	// The first request was checked before the user message was created.
+	checked := true
 	for {
 		// Check for cancellation before each iteration
 		select {
@@ -431,6 +466,13 @@ func (a *agent) processGeneration(ctx context.Context, sessionID, content string
 		default:
 			// Continue processing
 		}
+		if !checked {
+			msgHistory, err = a.compact(ctx, sessionID, msgHistory, 0)
+			if err != nil {
+				return a.err(err)
+			}
+		}
+		checked = false
 		agentMessage, toolResults, err := a.streamAndHandleEvents(ctx, sessionID, msgHistory)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
@@ -515,7 +557,7 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 	}
 
 	// Now collect tools (which may block on MCP initialization)
-	eventChan := a.provider.StreamResponse(ctx, msgHistory, slices.Collect(a.tools.Seq()))
+	eventChan := a.provider.StreamResponse(ctx, a.shape(msgHistory), slices.Collect(a.tools.Seq()))
 
 	// Add the session and message ID into the context if needed by tools.
 	ctx = context.WithValue(ctx, tools.MessageIDContextKey, assistantMsg.ID)
@@ -536,105 +578,15 @@ func (a *agent) streamAndHandleEvents(ctx context.Context, sessionID string, msg
 		}
 	}
 
-	toolResults := make([]message.ToolResult, len(assistantMsg.ToolCalls()))
 	toolCalls := assistantMsg.ToolCalls()
-	for i, toolCall := range toolCalls {
-		select {
-		case <-ctx.Done():
-			a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-			// Make all future tool calls cancelled
-			for j := i; j < len(toolCalls); j++ {
-				toolResults[j] = message.ToolResult{
-					ToolCallID: toolCalls[j].ID,
-					Content:    "Tool execution canceled by user",
-					IsError:    true,
-				}
-			}
-			goto out
-		default:
-			// Continue processing
-			var tool tools.BaseTool
-			for availableTool := range a.tools.Seq() {
-				if availableTool.Info().Name == toolCall.Name {
-					tool = availableTool
-					break
-				}
-			}
-
-			// Tool not found
-			if tool == nil {
-				toolResults[i] = message.ToolResult{
-					ToolCallID: toolCall.ID,
-					Content:    fmt.Sprintf("Tool not found: %s", toolCall.Name),
-					IsError:    true,
-				}
-				continue
-			}
-
-			// Run tool in goroutine to allow cancellation
-			type toolExecResult struct {
-				response tools.ToolResponse
-				err      error
-			}
-			resultChan := make(chan toolExecResult, 1)
-
-			go func() {
-				response, err := tool.Run(ctx, tools.ToolCall{
-					ID:    toolCall.ID,
-					Name:  toolCall.Name,
-					Input: toolCall.Input,
-				})
-				resultChan <- toolExecResult{response: response, err: err}
-			}()
-
-			var toolResponse tools.ToolResponse
-			var toolErr error
-
-			select {
-			case <-ctx.Done():
-				a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
-				// Mark remaining tool calls as cancelled
-				for j := i; j < len(toolCalls); j++ {
-					toolResults[j] = message.ToolResult{
-						ToolCallID: toolCalls[j].ID,
-						Content:    "Tool execution canceled by user",
-						IsError:    true,
-					}
-				}
-				goto out
-			case result := <-resultChan:
-				toolResponse = result.response
-				toolErr = result.err
-			}
-
-			if toolErr != nil {
-				slog.Error("Tool execution error", "toolCall", toolCall.ID, "error", toolErr)
-				if errors.Is(toolErr, permission.ErrorPermissionDenied) {
-					toolResults[i] = message.ToolResult{
-						ToolCallID: toolCall.ID,
-						Content:    "Permission denied",
-						IsError:    true,
-					}
-					for j := i + 1; j < len(toolCalls); j++ {
-						toolResults[j] = message.ToolResult{
-							ToolCallID: toolCalls[j].ID,
-							Content:    "Tool execution canceled by user",
-							IsError:    true,
-						}
-					}
-					a.finishMessage(ctx, &assistantMsg, message.FinishReasonPermissionDenied, "Permission denied", "")
-					break
-				}
-			}
-			toolResults[i] = message.ToolResult{
-				ToolCallID: toolCall.ID,
-				Content:    toolResponse.Content,
-				Metadata:   toolResponse.Metadata,
-				IsError:    toolResponse.IsError,
-			}
-		}
+	toolResults := make([]message.ToolResult, len(toolCalls))
+	denied := a.executeToolCalls(ctx, toolCalls, toolResults)
+	switch {
+	case ctx.Err() != nil:
+		a.finishMessage(context.Background(), &assistantMsg, message.FinishReasonCanceled, "Request cancelled", "")
+	case denied:
+		a.finishMessage(ctx, &assistantMsg, message.FinishReasonPermissionDenied, "Permission denied", "")
 	}
-out:
 	if len(toolResults) == 0 {
 		return assistantMsg, nil, nil
 	}
@@ -728,19 +680,16 @@ func (a *agent) TrackUsage(ctx context.Context, sessionID string, model catwalk.
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
-	cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-		model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-		model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-		model.CostPer1MOut/1e6*float64(usage.OutputTokens)
-
-	sess.Cost += cost
-	sess.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
-	sess.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+	request := requestUsage(model, usage)
+	sess.Cost += request.Cost
+	sess.CompletionTokens = request.CompletionTokens
+	sess.PromptTokens = request.PromptTokens
 
 	_, err = a.sessions.Save(ctx, sess)
 	if err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
+	addRunUsage(ctx, request)
 	return nil
 }
 
@@ -763,163 +712,127 @@ func (a *agent) Summarize(ctx context.Context, sessionID string) error {
 	go func() {
 		defer a.activeRequests.Del(sessionID + "-summarize")
 		defer cancel()
-		event := AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Starting summarization...",
-		}
 
-		a.Publish(pubsub.CreatedEvent, event)
-		// Get all messages from the session
-		msgs, err := a.messages.List(summarizeCtx, sessionID)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to list messages: %w", err),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
+		msgs, err := a.history(summarizeCtx, sessionID)
+		if err == nil {
+			_, err = a.summarize(summarizeCtx, sessionID, msgs, nil)
 		}
-		summarizeCtx = context.WithValue(summarizeCtx, tools.SessionIDContextKey, sessionID)
-
-		if len(msgs) == 0 {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("no messages to summarize"),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Analyzing conversation...",
+		if err != nil {
+			a.Publish(pubsub.CreatedEvent, AgentEvent{
+				Type:      AgentEventTypeSummarize,
+				SessionID: sessionID,
+				Error:     err,
+				Done:      true,
+			})
 		}
-		a.Publish(pubsub.CreatedEvent, event)
+	}()
 
-		// Add a system message to guide the summarization
-		summarizePrompt := "Provide a detailed but concise summary of our conversation above. Focus on information that would be helpful for continuing the conversation, including what we did, what we're doing, which files we're working on, and what we're going to do next."
+	return nil
+}
 
-		// Create a new message with the summarize prompt
-		promptMsg := message.Message{
-			Role:  message.User,
-			Parts: []message.ContentPart{message.TextContent{Text: summarizePrompt}},
-		}
+// summarize replaces the conversation of a session with a summary of msgs
+// followed by the kept messages, publishing its progress, and returns the
+// summary message.
+func (a *agent) summarize(ctx context.Context, sessionID string, msgs, kept []message.Message) (message.Message, error) {
+	progress := func(text string) {
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeSummarize,
+			SessionID: sessionID,
+			Progress:  text,
+		})
+	}
 
-		// Append the prompt to the messages
-		msgsWithPrompt := append(msgs, promptMsg)
+	progress("Starting summarization...")
+	if len(msgs) == 0 {
+		return message.Message{}, fmt.Errorf("no messages to summarize")
+	}
+	ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
 
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Generating summary...",
-		}
+	progress("Analyzing conversation...")
 
-		a.Publish(pubsub.CreatedEvent, event)
-
-		// Send the messages to the summarize provider
-		response := a.summarizeProvider.StreamResponse(
-			summarizeCtx,
-			msgsWithPrompt,
-			nil,
-		)
-		var finalResponse *provider.ProviderResponse
-		for r := range response {
-			if r.Error != nil {
-				event = AgentEvent{
-					Type:  AgentEventTypeError,
-					Error: fmt.Errorf("failed to summarize: %w", err),
-					Done:  true,
-				}
-				a.Publish(pubsub.CreatedEvent, event)
-				return
-			}
-			finalResponse = r.Response
-		}
+	// Add a system message to guide the summarization
+	summarizePrompt := "Provide a detailed but concise summary of our conversation above. Focus on information that would be helpful for continuing the conversation, including what we did, what we're doing, which files we're working on, and what we're going to do next."
 
-		summary := strings.TrimSpace(finalResponse.Content)
-		if summary == "" {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("empty summary returned"),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-		shell := shell.GetPersistentShell(config.Get().WorkingDir())
-		summary += "\n\n**Current working directory of the persistent shell**\n\n" + shell.GetWorkingDir()
-		event = AgentEvent{
-			Type:     AgentEventTypeSummarize,
-			Progress: "Creating new session...",
-		}
+	// Create a new message with the summarize prompt
+	promptMsg := message.Message{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: summarizePrompt}},
+	}
 
-		a.Publish(pubsub.CreatedEvent, event)
-		oldSession, err := a.sessions.Get(summarizeCtx, sessionID)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to get session: %w", err),
-				Done:  true,
-			}
+	// Append the prompt to the messages
+	msgsWithPrompt := append(slices.Clip(msgs), promptMsg)
 
-			a.Publish(pubsub.CreatedEvent, event)
-			return
-		}
-		// Create a message in the new session with the summary
-		msg, err := a.messages.Create(summarizeCtx, oldSession.ID, message.CreateMessageParams{
-			Role: message.Assistant,
-			Parts: []message.ContentPart{
-				message.TextContent{Text: summary},
-				message.Finish{
-					Reason: message.FinishReasonEndTurn,
-					Time:   time.Now().Unix(),
-				},
-			},
-			Model:    a.summarizeProvider.Model().ID,
-			Provider: a.summarizeProviderID,
-		})
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to create summary message: %w", err),
-				Done:  true,
-			}
+	progress("Generating summary...")
 
-			a.Publish(pubsub.CreatedEvent, event)
-			return
+	// Send the messages to the summarize provider
+	response := a.summarizeProvider.StreamResponse(
+		ctx,
+		msgsWithPrompt,
+		nil,
+	)
+	var finalResponse *provider.ProviderResponse
+	for r := range response {
+		if r.Error != nil {
+			return message.Message{}, fmt.Errorf("failed to summarize: %w", r.Error)
 		}
-		oldSession.SummaryMessageID = msg.ID
-		oldSession.CompletionTokens = finalResponse.Usage.OutputTokens
-		oldSession.PromptTokens = 0
-		model := a.summarizeProvider.Model()
-		usage := finalResponse.Usage
-		cost := model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
-			model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
-			model.CostPer1MIn/1e6*float64(usage.InputTokens) +
-			model.CostPer1MOut/1e6*float64(usage.OutputTokens)
-		oldSession.Cost += cost
-		_, err = a.sessions.Save(summarizeCtx, oldSession)
-		if err != nil {
-			event = AgentEvent{
-				Type:  AgentEventTypeError,
-				Error: fmt.Errorf("failed to save session: %w", err),
-				Done:  true,
-			}
-			a.Publish(pubsub.CreatedEvent, event)
+		if r.Response != nil {
+			finalResponse = r.Response
 		}
+	}
+	if finalResponse == nil {
+		return message.Message{}, fmt.Errorf("empty summary returned")
+	}
 
-		event = AgentEvent{
-			Type:      AgentEventTypeSummarize,
-			SessionID: oldSession.ID,
-			Progress:  "Summary complete",
-			Done:      true,
-		}
-		a.Publish(pubsub.CreatedEvent, event)
-		// Send final success event with the new session ID
-	}()
+	summary := strings.TrimSpace(finalResponse.Content)
+	if summary == "" {
+		return message.Message{}, fmt.Errorf("empty summary returned")
+	}
+	shell := shell.GetPersistentShell(config.Get().WorkingDir())
+	summary += "\n\n**Current working directory of the persistent shell**\n\n" + shell.GetWorkingDir()
+	summary += a.workingSet(ctx, sessionID, append(slices.Clip(msgs), kept...))
 
-	return nil
+	progress("Creating new session...")
+	oldSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return message.Message{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	// Create a message in the new session with the summary
+	msg, err := a.messages.Create(ctx, oldSession.ID, message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.TextContent{Text: summary},
+			message.Finish{
+				Reason: message.FinishReasonEndTurn,
+				Time:   time.Now().Unix(),
+			},
+		},
+		Model:    a.summarizeProvider.Model().ID,
+		Provider: a.summarizeProviderID,
+	})
+	if err != nil {
+		return message.Message{}, fmt.Errorf("failed to create summary message: %w", err)
+	}
+	oldSession.SummaryMessageID = msg.ID
+	oldSession.KeptMessageID = ""
+	if len(kept) > 0 {
+		oldSession.KeptMessageID = kept[0].ID
+	}
+	oldSession.CompletionTokens = finalResponse.Usage.OutputTokens
+	oldSession.PromptTokens = 0
+	request := requestUsage(a.summarizeProvider.Model(), finalResponse.Usage)
+	oldSession.Cost += request.Cost
+	if _, err := a.sessions.Save(ctx, oldSession); err != nil {
+		return message.Message{}, fmt.Errorf("failed to save session: %w", err)
+	}
+	addRunUsage(ctx, request)
+
+	a.Publish(pubsub.CreatedEvent, AgentEvent{
+		Type:      AgentEventTypeSummarize,
+		SessionID: oldSession.ID,
+		Progress:  "Summary complete",
+		Done:      true,
+	})
+	return msg, nil
 }
 
 func (a *agent) ClearQueue(sessionID string) {
@@ -0,0 +1,292 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/config"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+)
+
+// imageTokens is about what the providers charge for an image.
+const imageTokens = 1500
+
+// history returns the messages of a session that are sent to the model:
+// the last summary, as a user message, then the messages it kept and those
+// that came after it.
+func (a *agent) history(ctx context.Context, sessionID string) ([]message.Message, error) {
+	msgs, err := a.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	session, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.SummaryMessageID == "" {
+		return msgs, nil
+	}
+	i := slices.IndexFunc(msgs, func(msg message.Message) bool {
+		return msg.ID == session.SummaryMessageID
+	})
+	if i == -1 {
+		return msgs, nil
+	}
+	summary := msgs[i]
+	summary.Role = message.User
+	// The kept messages were created before the summary.
+	kept := slices.IndexFunc(msgs[:i], func(msg message.Message) bool {
+		return msg.ID == session.KeptMessageID
+	})
+	history := []message.Message{summary}
+	if kept != -1 {
+		history = append(history, msgs[kept:i]...)
+	}
+	return append(history, msgs[i+1:]...), nil
+}
+
+// compact compacts the conversation of a session when the next request,
+// with msgs and pending tokens that are not in them yet, would fill more of
+// the context window than the configured threshold. It returns the
+// messages to send instead of msgs.
+//
+// Failing to compact is not fatal: the request may still fit.
+func (a *agent) compact(ctx context.Context, sessionID string, msgs []message.Message, pending int64) ([]message.Message, error) {
+	opts := config.Get().Options
+	if opts.DisableAutoSummarize || opts.Compaction == nil || len(msgs) == 0 {
+		return msgs, nil
+	}
+	contextWindow := a.Model().ContextWindow
+	if contextWindow <= 0 {
+		return msgs, nil
+	}
+	tokens, err := a.requestTokens(ctx, sessionID, a.shape(msgs))
+	if err != nil {
+		return nil, err
+	}
+	limit := int64(opts.Compaction.Threshold * float64(contextWindow))
+	if tokens+pending < limit {
+		return msgs, nil
+	}
+
+	strategy := opts.Compaction.Strategy
+	if strategy != config.CompactionTruncate && a.summarizeProvider == nil {
+		strategy = config.CompactionTruncate
+	}
+	slog.Info("Compacting conversation", "session_id", sessionID, "tokens", tokens+pending, "context_window", contextWindow, "strategy", strategy)
+
+	var compacted []message.Message
+	switch strategy {
+	case config.CompactionTruncate:
+		compacted, err = a.truncate(ctx, sessionID, msgs, limit/2)
+	default:
+		keep := len(msgs)
+		if strategy == config.CompactionIncremental {
+			keep = keptTurns(msgs, opts.Compaction.KeepTurns, limit/2)
+		}
+		var summary message.Message
+		summary, err = a.summarize(ctx, sessionID, msgs[:keep], msgs[keep:])
+		summary.Role = message.User
+		compacted = append([]message.Message{summary}, msgs[keep:]...)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		slog.Error("Failed to compact conversation", "session_id", sessionID, "error", err)
+		a.Publish(pubsub.CreatedEvent, AgentEvent{
+			Type:      AgentEventTypeSummarize,
+			SessionID: sessionID,
+			Error:     fmt.Errorf("failed to compact conversation: %w", err),
+			Done:      true,
+		})
+		return msgs, nil
+	}
+	return compacted, nil
+}
+
+// requestTokens estimates the prompt of the next request with msgs. The
+// usage of the last request also counts the system prompt and the tools,
+// so it is used for the messages up to the last assistant message and only
+// the rest is estimated.
+func (a *agent) requestTokens(ctx context.Context, sessionID string, msgs []message.Message) (int64, error) {
+	session, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	last := len(msgs) - 1
+	for last >= 0 && msgs[last].Role != message.Assistant {
+		last--
+	}
+	tokens := session.PromptTokens + session.CompletionTokens + estimateMessageTokens(msgs[last+1:]...)
+	return max(tokens, estimateMessageTokens(msgs...)), nil
+}
+
+// truncate drops the oldest turns of msgs so that the rest is about budget
+// tokens, keeping at least the last turn. The first message kept marks the
+// start of the conversation like a summary does, so the next requests
+// leave the dropped turns out too.
+func (a *agent) truncate(ctx context.Context, sessionID string, msgs []message.Message, budget int64) ([]message.Message, error) {
+	a.Publish(pubsub.CreatedEvent, AgentEvent{
+		Type:      AgentEventTypeSummarize,
+		SessionID: sessionID,
+		Progress:  "Dropping older messages...",
+	})
+
+	start := -1
+	var tokens int64
+	for i := len(msgs) - 1; i >= 0; i-- {
+		tokens += estimateMessageTokens(msgs[i])
+		if msgs[i].Role != message.User {
+			continue
+		}
+		if start != -1 && tokens > budget {
+			break
+		}
+		start = i
+	}
+	if start <= 0 {
+		return nil, errors.New("no older messages to drop")
+	}
+
+	session, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	session.SummaryMessageID = msgs[start].ID
+	session.KeptMessageID = ""
+	// The usage of the last request counts the dropped messages.
+	session.PromptTokens = 0
+	session.CompletionTokens = 0
+	if _, err := a.sessions.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+
+	a.Publish(pubsub.CreatedEvent, AgentEvent{
+		Type:      AgentEventTypeSummarize,
+		SessionID: sessionID,
+		Progress:  fmt.Sprintf("Dropped %d older messages", start),
+		Done:      true,
+	})
+	return msgs[start:], nil
+}
+
+// keptTurns returns where the last turns of msgs that the incremental
+// strategy keeps start: at most turns of them, in budget tokens. A turn
+// starts with a user message. Everything is summarized when even the last
+// turn is over budget, or when there is nothing older to summarize.
+func keptTurns(msgs []message.Message, turns int, budget int64) int {
+	keep := len(msgs)
+	var tokens int64
+	for i := len(msgs) - 1; i > 0 && turns > 0; i-- {
+		tokens += estimateMessageTokens(msgs[i])
+		if tokens > budget {
+			break
+		}
+		if msgs[i].Role == message.User {
+			keep = i
+			turns--
+		}
+	}
+	return keep
+}
+
+// workingSet lists the files the session changed and the open items of
+// the last todo list in msgs, for the summary, so the agent knows what it
+// was working on after a compaction.
+func (a *agent) workingSet(ctx context.Context, sessionID string, msgs []message.Message) string {
+	var set string
+	if files := a.changedFiles(ctx, sessionID); len(files) > 0 {
+		set += "\n\n**Files changed in this session**\n\n" + strings.Join(files, "\n")
+	}
+	if todos := openTodos(msgs); len(todos) > 0 {
+		set += "\n\n**Open todos**\n\n" + strings.Join(todos, "\n")
+	}
+	return set
+}
+
+func (a *agent) changedFiles(ctx context.Context, sessionID string) []string {
+	if a.files == nil {
+		return nil
+	}
+	files, err := a.files.ListLatestSessionFiles(ctx, sessionID)
+	if err != nil {
+		slog.Warn("Failed to list session files", "session_id", sessionID, "error", err)
+		return nil
+	}
+	cwd := config.Get().WorkingDir()
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path := file.Path
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		paths = append(paths, "- "+path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+var todoItem = regexp.MustCompile(`(?m)^\s*[-*] \[([ xX])\] (.+)$`)
+
+// openTodos returns the unchecked items of the last todo list the agent
+// wrote, which the system prompt asks for as a markdown checklist.
+func openTodos(msgs []message.Message) []string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != message.Assistant {
+			continue
+		}
+		items := todoItem.FindAllStringSubmatch(msgs[i].Content().Text, -1)
+		if len(items) == 0 {
+			continue
+		}
+		var open []string
+		for _, item := range items {
+			if item[1] == " " {
+				open = append(open, "- [ ] "+strings.TrimSpace(item[2]))
+			}
+		}
+		return open
+	}
+	return nil
+}
+
+// estimateTokens estimates the tokens of a text, at four characters per
+// token.
+func estimateTokens(text string) int64 {
+	return int64(len(text)+3) / 4
+}
+
+func estimateMessageTokens(msgs ...message.Message) int64 {
+	var tokens int64
+	for _, msg := range msgs {
+		for _, part := range msg.Parts {
+			switch p := part.(type) {
+			case message.TextContent:
+				tokens += estimateTokens(p.Text)
+			case message.ReasoningContent:
+				tokens += estimateTokens(p.Thinking)
+			case message.ToolCall:
+				tokens += estimateTokens(p.Name) + estimateTokens(p.Input)
+			case message.ToolResult:
+				tokens += estimateTokens(p.Content)
+			case message.ImageURLContent:
+				tokens += imageTokens
+			case message.BinaryContent:
+				if strings.HasPrefix(p.MIMEType, "image/") {
+					tokens += imageTokens
+				} else {
+					tokens += estimateTokens(string(p.Data))
+				}
+			}
+		}
+	}
+	return tokens
+}
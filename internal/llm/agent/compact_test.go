@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	a := &agent{
+		Broker:   pubsub.NewBroker[AgentEvent](),
+		sessions: session.NewService(q),
+		messages: message.NewService(q),
+	}
+
+	ctx := t.Context()
+	sess, err := a.sessions.Create(ctx, "Long")
+	require.NoError(t, err)
+	text := strings.Repeat("a", 400) // 100 tokens
+	for range 4 {
+		for _, role := range []message.MessageRole{message.User, message.Assistant} {
+			_, err := a.messages.Create(ctx, sess.ID, message.CreateMessageParams{Role: role, Parts: []message.ContentPart{message.TextContent{Text: text}}})
+			require.NoError(t, err)
+		}
+	}
+	sess.PromptTokens = 700
+	_, err = a.sessions.Save(ctx, sess)
+	require.NoError(t, err)
+
+	msgs, err := a.history(ctx, sess.ID)
+	require.NoError(t, err)
+	tokens, err := a.requestTokens(ctx, sess.ID, msgs)
+	require.NoError(t, err)
+	require.Equal(t, int64(800), tokens)
+
+	kept, err := a.truncate(ctx, sess.ID, msgs, 450)
+	require.NoError(t, err)
+	require.Len(t, kept, 4)
+	require.Equal(t, message.User, kept[0].Role)
+
+	// The next requests start from the first message kept.
+	msgs, err = a.history(ctx, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, kept[0].ID, msgs[0].ID)
+	tokens, err = a.requestTokens(ctx, sess.ID, msgs)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), tokens)
+
+	// The last turn is always kept.
+	_, err = a.truncate(ctx, sess.ID, msgs[2:], 10)
+	require.Error(t, err)
+}
+
+func TestKeptTurns(t *testing.T) {
+	t.Parallel()
+
+	text := func(role message.MessageRole, tokens int) message.Message {
+		return message.Message{Role: role, Parts: []message.ContentPart{message.TextContent{Text: strings.Repeat("a", tokens*4)}}}
+	}
+	msgs := []message.Message{
+		text(message.User, 10),
+		text(message.Assistant, 10),
+		text(message.User, 10),
+		text(message.Assistant, 10),
+		text(message.Tool, 10),
+		text(message.Assistant, 10),
+		text(message.User, 10),
+		text(message.Assistant, 10),
+	}
+	require.Equal(t, 6, keptTurns(msgs, 1, 100))
+	require.Equal(t, 2, keptTurns(msgs, 2, 100))
+	// The first turn is always summarized.
+	require.Equal(t, 2, keptTurns(msgs, 5, 100))
+	// Only the turns that fit are kept.
+	require.Equal(t, 6, keptTurns(msgs, 2, 50))
+	require.Equal(t, len(msgs), keptTurns(msgs, 2, 15))
+}
+
+func TestHistory_KeptMessages(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	a := &agent{
+		Broker:   pubsub.NewBroker[AgentEvent](),
+		sessions: session.NewService(q),
+		messages: message.NewService(q),
+	}
+
+	ctx := t.Context()
+	sess, err := a.sessions.Create(ctx, "Compacted")
+	require.NoError(t, err)
+	var ids []string
+	for _, role := range []message.MessageRole{message.User, message.Assistant, message.User, message.Assistant, message.Assistant, message.User} {
+		msg, err := a.messages.Create(ctx, sess.ID, message.CreateMessageParams{Role: role, Parts: []message.ContentPart{message.TextContent{Text: "text"}}})
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+	// The fifth message is the summary of the first turn, and the sixth came
+	// after it.
+	sess.SummaryMessageID = ids[4]
+	sess.KeptMessageID = ids[2]
+	_, err = a.sessions.Save(ctx, sess)
+	require.NoError(t, err)
+
+	msgs, err := a.history(ctx, sess.ID)
+	require.NoError(t, err)
+	var got []string
+	for _, msg := range msgs {
+		got = append(got, msg.ID)
+	}
+	require.Equal(t, []string{ids[4], ids[2], ids[3], ids[5]}, got)
+	require.Equal(t, message.User, msgs[0].Role)
+}
+
+func TestOpenTodos(t *testing.T) {
+	t.Parallel()
+
+	text := func(role message.MessageRole, text string) message.Message {
+		return message.Message{Role: role, Parts: []message.ContentPart{message.TextContent{Text: text}}}
+	}
+	msgs := []message.Message{
+		text(message.Assistant, "```markdown\n- [ ] Step 1: old plan\n```"),
+		text(message.User, "- [ ] not the agent's list"),
+		text(message.Assistant, "Progress:\n```markdown\n- [x] Step 1: read the code\n- [ ] Step 2: fix the bug\n* [ ] Step 3: add a test\n```"),
+		text(message.Assistant, "Running the tests now."),
+	}
+	require.Equal(t, []string{"- [ ] Step 2: fix the bug", "- [ ] Step 3: add a test"}, openTodos(msgs))
+
+	msgs = append(msgs, text(message.Assistant, "- [x] Step 1\n- [x] Step 2"))
+	require.Empty(t, openTodos(msgs), "a finished list has no open todos")
+	require.Empty(t, openTodos(nil))
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/config"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+)
+
+// prunedMinTokens is the size under which old tool results are kept, since
+// a placeholder would not be much shorter.
+const prunedMinTokens = 100
+
+// shape returns the messages sent to the model for msgs, with the output
+// budgets of the tools of the agent.
+func (a *agent) shape(msgs []message.Message) []message.Message {
+	budgets := make(map[string]int)
+	if a.tools != nil {
+		for tool := range a.tools.Seq() {
+			info := tool.Info()
+			budgets[info.Name] = info.OutputTokens
+		}
+	}
+	pruneAfter := 0
+	if opts := config.Get().Options.Compaction; opts != nil {
+		pruneAfter = opts.PruneAfterTurns
+	}
+	return shapeHistory(msgs, budgets, pruneAfter, config.Get().WorkingDir())
+}
+
+// shapeHistory cuts the tool results of msgs to the output budget of their
+// tool. Results of turns older than pruneAfter turns are replaced with
+// placeholders, as are views of a file viewed again since. To keep the
+// prompt cache of the provider valid, old turns are pruned pruneAfter at a
+// time rather than one per turn, and only by what happened in them, so the
+// start of the history only changes every pruneAfter turns. The messages
+// are copied rather than changed, since they are also what the session
+// stores.
+func shapeHistory(msgs []message.Message, budgets map[string]int, pruneAfter int, workingDir string) []message.Message {
+	calls := make(map[string]message.ToolCall)
+	for _, msg := range msgs {
+		for _, call := range msg.ToolCalls() {
+			calls[call.ID] = call
+		}
+	}
+	pruned := prunedUntil(msgs, pruneAfter)
+
+	// Walk the pruned messages backwards to know which files are viewed
+	// again later.
+	viewed := make(map[string]bool)
+	shaped := make([]message.Message, len(msgs))
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if msg.Role != message.Tool {
+			shaped[i] = msg
+			continue
+		}
+		parts := make([]message.ContentPart, len(msg.Parts))
+		for j := len(msg.Parts) - 1; j >= 0; j-- {
+			result, ok := msg.Parts[j].(message.ToolResult)
+			if !ok {
+				parts[j] = msg.Parts[j]
+				continue
+			}
+			call := calls[result.ToolCallID]
+			path, isView := viewedFile(call, workingDir)
+			switch {
+			case result.IsError:
+			case i >= pruned:
+				result.Content = tools.TruncateOutput(result.Content, budgets[call.Name])
+			case isView && viewed[path]:
+				result.Content = fmt.Sprintf("[Superseded by a later view of %s.]", path)
+			case estimateTokens(result.Content) > prunedMinTokens:
+				result.Content = fmt.Sprintf("[Output of the %s tool left out of the history. Run it again if you need it.]", call.Name)
+			default:
+				result.Content = tools.TruncateOutput(result.Content, budgets[call.Name])
+			}
+			if isView && !result.IsError && i < pruned {
+				viewed[path] = true
+			}
+			parts[j] = result
+		}
+		msg.Parts = parts
+		shaped[i] = msg
+	}
+	return shaped
+}
+
+// prunedUntil returns the index of the first message of msgs whose tool
+// results are kept. A turn starts with a user message. The last pruneAfter
+// turns are always kept, and the older ones are pruned in batches of
+// pruneAfter turns.
+func prunedUntil(msgs []message.Message, pruneAfter int) int {
+	if pruneAfter <= 0 {
+		return 0
+	}
+	var turns []int
+	for i, msg := range msgs {
+		if msg.Role == message.User {
+			turns = append(turns, i)
+		}
+	}
+	batches := len(turns)/pruneAfter - 1
+	if batches <= 0 {
+		return 0
+	}
+	return turns[batches*pruneAfter]
+}
+
+// viewedFile returns the file a call of the view tool reads, as an absolute
+// path. Views of a file supersede each other, whatever lines they read.
+func viewedFile(call message.ToolCall, workingDir string) (string, bool) {
+	if call.Name != tools.ViewToolName {
+		return "", false
+	}
+	var params tools.ViewParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil || params.FilePath == "" {
+		return "", false
+	}
+	path := params.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	return filepath.Clean(path), true
+}
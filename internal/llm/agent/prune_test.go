@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShapeHistory(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("line\n", 1000) // 1250 tokens
+	turn := func(id, name, input, output string) []message.Message {
+		return []message.Message{
+			{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "go on"}}},
+			{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: id, Name: name, Input: input, Finished: true}}},
+			{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: id, Content: output}}},
+		}
+	}
+	var msgs []message.Message
+	msgs = append(msgs, turn("old", tools.GrepToolName, `{"pattern":"x"}`, large)...)
+	msgs = append(msgs, turn("small", tools.LSToolName, `{}`, "main.go")...)
+	msgs = append(msgs, turn("first", tools.ViewToolName, `{"file_path":"main.go"}`, "package main")...)
+	msgs = append(msgs, turn("other", tools.ViewToolName, `{"file_path":"./main.go","offset":100}`, "func main() {}")...)
+	msgs = append(msgs, turn("recent", tools.ViewToolName, `{"file_path":"/work/main.go"}`, "package main\n")...)
+	msgs = append(msgs, turn("bash", tools.BashToolName, `{"command":"go test"}`, large)...)
+
+	shaped := shapeHistory(msgs, map[string]int{tools.BashToolName: 100}, 2, "/work")
+	content := func(msgs []message.Message, i int) string {
+		return msgs[i*3+2].ToolResults()[0].Content
+	}
+	require.Contains(t, content(shaped, 0), "left out of the history")
+	require.Equal(t, "main.go", content(shaped, 1))
+	require.Contains(t, content(shaped, 2), "Superseded by a later view of /work/main.go", "views of other lines and equivalent paths supersede")
+	require.Equal(t, "func main() {}", content(shaped, 3), "views are only superseded by pruned views")
+	require.Equal(t, "package main\n", content(shaped, 4))
+	require.Contains(t, content(shaped, 5), "lines truncated")
+	require.Less(t, len(content(shaped, 5)), 500)
+
+	// The next turn doesn't change the pruned turns, which keeps the prompt
+	// cache of the provider valid.
+	next := append(slices.Clone(msgs), turn("next", tools.GrepToolName, `{"pattern":"y"}`, large)...)
+	require.Equal(t, shaped, shapeHistory(next, map[string]int{tools.BashToolName: 100}, 2, "/work")[:len(shaped)])
+
+	// The stored messages are left alone.
+	require.Equal(t, large, content(msgs, 0))
+	require.Equal(t, large, content(msgs, 5))
+}
+
+func TestPrunedUntil(t *testing.T) {
+	t.Parallel()
+
+	var msgs []message.Message
+	for range 9 {
+		msgs = append(msgs, message.Message{Role: message.User}, message.Message{Role: message.Assistant})
+	}
+	require.Zero(t, prunedUntil(msgs[:6], 2), "the last turns are kept")
+	require.Equal(t, 4, prunedUntil(msgs[:8], 2))
+	require.Equal(t, 4, prunedUntil(msgs[:10], 2), "turns are pruned in batches")
+	require.Equal(t, 8, prunedUntil(msgs[:12], 2))
+	require.Zero(t, prunedUntil(msgs, 0))
+}
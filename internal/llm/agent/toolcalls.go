@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+)
+
+// maxParallelToolCalls is the most read-only tool calls run at once.
+const maxParallelToolCalls = 4
+
+func (a *agent) tool(name string) tools.BaseTool {
+	for tool := range a.tools.Seq() {
+		if tool.Info().Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+// isReadOnly tells whether the tool call can run together with others.
+func (a *agent) isReadOnly(call message.ToolCall) bool {
+	tool := a.tool(call.Name)
+	if tool == nil || !tool.Info().ReadOnly {
+		return false
+	}
+	if caller, ok := tool.(tools.ReadOnlyCaller); ok {
+		return caller.ReadOnly(call.Input)
+	}
+	return true
+}
+
+// executeToolCalls runs the tool calls of a response into results, the
+// read-only ones in a row together. When the context is canceled or the user
+// denies a permission, it cancels the calls that follow, and it tells whether
+// the user denied.
+func (a *agent) executeToolCalls(ctx context.Context, calls []message.ToolCall, results []message.ToolResult) bool {
+	for i := 0; i < len(calls); {
+		j := i + 1
+		if a.isReadOnly(calls[i]) {
+			for j < len(calls) && a.isReadOnly(calls[j]) {
+				j++
+			}
+		}
+		denied := a.runToolCalls(ctx, calls[i:j], results[i:j])
+		if ctx.Err() != nil || denied {
+			cancelToolCalls(calls[j:], results[j:])
+			return denied
+		}
+		i = j
+	}
+	return false
+}
+
+// runToolCalls runs tool calls into results, concurrently when there are
+// several, which the caller only does for read-only tools. It tells whether
+// the user denied a permission, which stops the tool calls that follow.
+func (a *agent) runToolCalls(ctx context.Context, calls []message.ToolCall, results []message.ToolResult) bool {
+	if len(calls) == 1 {
+		var denied bool
+		results[0], denied = a.runToolCall(ctx, calls[0])
+		return denied
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		denied bool
+	)
+	sem := make(chan struct{}, maxParallelToolCalls)
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = canceledToolResult(call)
+				return
+			}
+			result, callDenied := a.runToolCall(ctx, call)
+			results[i] = result
+			if callDenied {
+				mu.Lock()
+				denied = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return denied
+}
+
+// runToolCall runs a tool call and returns its result. It tells whether the
+// user denied a permission the tool asked for.
+func (a *agent) runToolCall(ctx context.Context, toolCall message.ToolCall) (message.ToolResult, bool) {
+	if ctx.Err() != nil {
+		return canceledToolResult(toolCall), false
+	}
+	tool := a.tool(toolCall.Name)
+	if tool == nil {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    fmt.Sprintf("Tool not found: %s", toolCall.Name),
+			IsError:    true,
+		}, false
+	}
+
+	sessionID, _ := tools.GetContextValues(ctx)
+	if err := a.permissions.CheckTool(sessionID, toolCall.ID, toolCall.Name); err != nil {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    err.Error(),
+			IsError:    true,
+		}, false
+	}
+
+	input, err := tools.ValidateInput(tool.Info(), toolCall.Input)
+	if err != nil {
+		return message.ToolResult{
+			ToolCallID: toolCall.ID,
+			Content:    err.Error(),
+			IsError:    true,
+		}, false
+	}
+
+	// Run tool in goroutine to allow cancellation
+	type toolExecResult struct {
+		response tools.ToolResponse
+		err      error
+	}
+	resultChan := make(chan toolExecResult, 1)
+
+	go func() {
+		response, err := tool.Run(ctx, tools.ToolCall{
+			ID:    toolCall.ID,
+			Name:  toolCall.Name,
+			Input: input,
+		})
+		resultChan <- toolExecResult{response: response, err: err}
+	}()
+
+	var toolResponse tools.ToolResponse
+	var toolErr error
+
+	select {
+	case <-ctx.Done():
+		return canceledToolResult(toolCall), false
+	case result := <-resultChan:
+		toolResponse = result.response
+		toolErr = result.err
+	}
+
+	if toolErr != nil {
+		slog.Error("Tool execution error", "toolCall", toolCall.ID, "error", toolErr)
+		if errors.Is(toolErr, permission.ErrorPermissionDenied) {
+			// Denials by a rule or the policy are reported to the model, which
+			// can carry on without the tool.
+			if err := a.permissions.Denial(toolCall.ID); err != nil {
+				return message.ToolResult{
+					ToolCallID: toolCall.ID,
+					Content:    err.Error(),
+					IsError:    true,
+				}, false
+			}
+			return message.ToolResult{
+				ToolCallID: toolCall.ID,
+				Content:    "Permission denied",
+				IsError:    true,
+			}, true
+		}
+	}
+	return message.ToolResult{
+		ToolCallID: toolCall.ID,
+		Content:    toolResponse.Content,
+		Metadata:   toolResponse.Metadata,
+		IsError:    toolResponse.IsError,
+	}, false
+}
+
+func canceledToolResult(toolCall message.ToolCall) message.ToolResult {
+	return message.ToolResult{
+		ToolCallID: toolCall.ID,
+		Content:    "Tool execution canceled by user",
+		IsError:    true,
+	}
+}
+
+func cancelToolCalls(calls []message.ToolCall, results []message.ToolResult) {
+	for i, call := range calls {
+		results[i] = canceledToolResult(call)
+	}
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/csync"
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTool records how many of its calls run at once.
+type countingTool struct {
+	name     string
+	readOnly bool
+
+	mu      *sync.Mutex
+	running *int
+	peak    *int
+}
+
+func (t *countingTool) Info() tools.ToolInfo {
+	return tools.ToolInfo{Name: t.name, ReadOnly: t.readOnly}
+}
+
+func (t *countingTool) Name() string { return t.name }
+
+func (t *countingTool) Run(ctx context.Context, call tools.ToolCall) (tools.ToolResponse, error) {
+	t.mu.Lock()
+	*t.running++
+	*t.peak = max(*t.peak, *t.running)
+	t.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	t.mu.Lock()
+	*t.running--
+	t.mu.Unlock()
+	return tools.NewTextResponse(t.name + " " + call.Input), nil
+}
+
+func TestRunToolCalls(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu            sync.Mutex
+		running, peak int
+	)
+	read := &countingTool{name: "read", readOnly: true, mu: &mu, running: &running, peak: &peak}
+	write := &countingTool{name: "write", mu: &mu, running: &running, peak: &peak}
+	a := &agent{
+		permissions: permission.NewPermissionService(t.TempDir(), false, nil, nil, nil, nil),
+		tools: csync.NewLazySlice(func() []tools.BaseTool {
+			return []tools.BaseTool{read, write}
+		}),
+	}
+	require.True(t, a.isReadOnly(message.ToolCall{Name: "read"}))
+	require.False(t, a.isReadOnly(message.ToolCall{Name: "write"}))
+
+	calls := make([]message.ToolCall, 8)
+	for i := range calls {
+		calls[i] = message.ToolCall{ID: fmt.Sprint(i), Name: "read", Input: fmt.Sprintf(`{"n":%d}`, i)}
+	}
+	results := make([]message.ToolResult, len(calls))
+	require.False(t, a.runToolCalls(t.Context(), calls, results))
+	for i, result := range results {
+		require.Equal(t, calls[i].ID, result.ToolCallID)
+		require.Equal(t, fmt.Sprintf(`read {"n":%d}`, i), result.Content)
+	}
+	require.Greater(t, peak, 1)
+	require.LessOrEqual(t, peak, maxParallelToolCalls)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.False(t, a.runToolCalls(ctx, calls[:2], results[:2]))
+	require.Equal(t, "Tool execution canceled by user", results[0].Content)
+
+	result, denied := a.runToolCall(t.Context(), message.ToolCall{ID: "missing", Name: "missing"})
+	require.False(t, denied)
+	require.True(t, result.IsError)
+}
+
+func TestRunToolCallAuditsDeniedTool(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	audit := permission.NewAuditLog(db.New(conn))
+
+	write := &countingTool{name: "write", mu: &sync.Mutex{}, running: new(int), peak: new(int)}
+	a := &agent{
+		permissions: permission.NewPermissionService(t.TempDir(), false, nil, []permission.Rule{{Decision: permission.DecisionDeny, Pattern: "write"}}, nil, audit),
+		tools: csync.NewLazySlice(func() []tools.BaseTool {
+			return []tools.BaseTool{write}
+		}),
+	}
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "session")
+	result, _ := a.runToolCall(ctx, message.ToolCall{ID: "call", Name: "write", Input: "{}"})
+	require.True(t, result.IsError)
+
+	entries, err := audit.List(t.Context(), permission.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "session", entries[0].SessionID)
+	require.Equal(t, "call", entries[0].ToolCallID)
+	require.Equal(t, "write", entries[0].ToolName)
+	require.Equal(t, permission.AuditDenied, entries[0].Decision)
+	require.Equal(t, permission.DeciderRule, entries[0].Decider)
+}
+
+func TestExecuteToolCallsOutsideWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	workingDir := t.TempDir()
+	outside := t.TempDir()
+	perms := permission.NewPermissionService(workingDir, false, nil, nil, permission.NewGrantStore(q, 0), permission.NewAuditLog(q))
+	view := tools.NewViewTool(nil, perms, workingDir)
+	a := &agent{
+		permissions: perms,
+		tools: csync.NewLazySlice(func() []tools.BaseTool {
+			return []tools.BaseTool{view}
+		}),
+	}
+
+	inside := message.ToolCall{Name: tools.ViewToolName, Input: `{"file_path":"main.go"}`}
+	require.True(t, a.isReadOnly(inside))
+
+	// The user denies every request, and counts them.
+	var prompts int
+	requests := perms.Subscribe(t.Context())
+	go func() {
+		for event := range requests {
+			if event.Type == pubsub.CreatedEvent {
+				prompts++
+				perms.Deny(event.Payload)
+			}
+		}
+	}()
+
+	calls := []message.ToolCall{
+		{ID: "first", Name: tools.ViewToolName, Input: fmt.Sprintf(`{"file_path":%q}`, outside+"/a.txt")},
+		{ID: "second", Name: tools.ViewToolName, Input: fmt.Sprintf(`{"file_path":%q}`, outside+"/b.txt")},
+	}
+	require.False(t, a.isReadOnly(calls[0]))
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, "session")
+	ctx = context.WithValue(ctx, tools.MessageIDContextKey, "message")
+	results := make([]message.ToolResult, len(calls))
+	require.True(t, a.executeToolCalls(ctx, calls, results))
+	require.Equal(t, "Permission denied", results[0].Content)
+	require.Equal(t, "Tool execution canceled by user", results[1].Content)
+	require.Equal(t, 1, prompts)
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/provider"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+)
+
+// Usage is the tokens and the cost of one or more requests.
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+	Cost             float64
+}
+
+func (u *Usage) add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.Cost += other.Cost
+}
+
+// requestUsage returns the usage of a request to model.
+func requestUsage(model catwalk.Model, usage provider.TokenUsage) Usage {
+	return Usage{
+		PromptTokens:     usage.InputTokens + usage.CacheCreationTokens,
+		CompletionTokens: usage.OutputTokens + usage.CacheReadTokens,
+		Cost: model.CostPer1MInCached/1e6*float64(usage.CacheCreationTokens) +
+			model.CostPer1MOutCached/1e6*float64(usage.CacheReadTokens) +
+			model.CostPer1MIn/1e6*float64(usage.InputTokens) +
+			model.CostPer1MOut/1e6*float64(usage.OutputTokens),
+	}
+}
+
+// runUsage adds up the usage of the requests made for a run: the
+// responses, the summaries of auto-compaction and the runs of sub-agents.
+// The totals of the session can't be used for it, since compaction resets
+// them.
+type runUsage struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+type runUsageKey struct{}
+
+func withRunUsage(ctx context.Context) (context.Context, *runUsage) {
+	r := &runUsage{}
+	return context.WithValue(ctx, runUsageKey{}, r), r
+}
+
+// addRunUsage adds usage to the run of ctx, if any. Tools run in parallel,
+// so it may be called concurrently.
+func addRunUsage(ctx context.Context, usage Usage) {
+	r, ok := ctx.Value(runUsageKey{}).(*runUsage)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage.add(usage)
+}
+
+func (r *runUsage) total() Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage
+}
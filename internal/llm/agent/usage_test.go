@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/provider"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUsage(t *testing.T) {
+	t.Parallel()
+
+	model := catwalk.Model{CostPer1MIn: 1e6, CostPer1MOut: 2e6}
+	request := requestUsage(model, provider.TokenUsage{InputTokens: 3, OutputTokens: 2})
+	require.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 2, Cost: 7}, request)
+
+	// Without a run there is nothing to add to.
+	addRunUsage(t.Context(), request)
+
+	ctx, usage := withRunUsage(t.Context())
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() { addRunUsage(ctx, request) })
+	}
+	wg.Wait()
+	require.Equal(t, Usage{PromptTokens: 12, CompletionTokens: 8, Cost: 28}, usage.total())
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,13 +13,15 @@ import (
 )
 
 type BashParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background"`
 }
 
 type BashPermissionsParams struct {
-	Command string `json:"command"`
-	Timeout int    `json:"timeout"`
+	Command         string `json:"command"`
+	Timeout         int    `json:"timeout"`
+	RunInBackground bool   `json:"run_in_background,omitempty"`
 }
 
 type BashResponseMetadata struct {
@@ -26,10 +29,14 @@ type BashResponseMetadata struct {
 	EndTime          int64  `json:"end_time"`
 	Output           string `json:"output"`
 	WorkingDirectory string `json:"working_directory"`
+	// JobID is set for commands started in the background.
+	JobID string `json:"job_id,omitempty"`
 }
 type bashTool struct {
 	permissions permission.Service
 	workingDir  string
+	jobs        shell.JobManager
+	policy      *BashPolicy
 }
 
 const (
@@ -114,8 +121,7 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription() string {
-	bannedCommandsStr := strings.Join(bannedCommands, ", ")
+func bashDescription(policy *BashPolicy) string {
 	return fmt.Sprintf(`Executes a given bash command in a persistent shell session with optional timeout, ensuring proper handling and security measures.
 
 CROSS-PLATFORM SHELL SUPPORT:
@@ -134,7 +140,8 @@ Before executing the command, please follow these steps:
 
 2. Security Check:
  - For security and to limit the threat of a prompt injection attack, some commands are limited or banned. If you use a disallowed command, you will receive an error message explaining the restriction. Explain the error to the User.
- - Verify that the command is not one of the banned commands: %s.
+ - Verify that the command doesn't match one of the blocked command patterns: %s.
+ - Patterns match the program name and flags anywhere in the command, and their other words in order.
 
 3. Command Execution:
  - After ensuring proper quoting, execute the command.
@@ -152,6 +159,7 @@ Before executing the command, please follow these steps:
 Usage notes:
 - The command argument is required.
 - You can specify an optional timeout in milliseconds (up to 600000ms / 10 minutes). If not specified, commands will timeout after 30 minutes.
+- Set run_in_background to start commands that run for a long time or don't exit, like dev servers, watchers or long test suites, without waiting for them. You get a job id right away; use the job_output tool to read their output and check whether they are still running, and the job_kill tool to stop them. Background commands have no timeout and start in a copy of the shell session, so they don't change its state. Don't use '&' to run commands in the background.
 - VERY IMPORTANT: You MUST avoid using search commands like 'find' and 'grep'. Instead use Grep, Glob, or Agent tools to search. You MUST avoid read tools like 'cat', 'head', 'tail', and 'ls', and use FileRead and LS tools to read files.
 - When issuing multiple commands, use the ';' or '&&' operator to separate them. DO NOT use newlines (newlines are ok in quoted strings).
 - IMPORTANT: All commands share the same shell session. Shell state (environment variables, virtual environments, current directory, etc.) persist between commands. For example, if you set an environment variable as part of a command, the environment variable will persist for subsequent commands.
@@ -254,48 +262,50 @@ EOF
 
 Important:
 - Return an empty response - the user will see the gh output directly
-- Never update git config`, bannedCommandsStr, MaxOutputLength)
+- Never update git config`, policy.describe(), MaxOutputLength)
 }
 
-func blockFuncs() []shell.BlockFunc {
-	return []shell.BlockFunc{
-		shell.CommandsBlocker(bannedCommands),
-		shell.ArgumentsBlocker([][]string{
-			// System package managers
-			{"apk", "add"},
-			{"apt", "install"},
-			{"apt-get", "install"},
-			{"dnf", "install"},
-			{"emerge"},
-			{"pacman", "-S"},
-			{"pkg", "install"},
-			{"yum", "install"},
-			{"zypper", "install"},
-
-			// Language-specific package managers
-			{"brew", "install"},
-			{"cargo", "install"},
-			{"gem", "install"},
-			{"go", "install"},
-			{"npm", "install", "-g"},
-			{"npm", "install", "--global"},
-			{"pip", "install", "--user"},
-			{"pip3", "install", "--user"},
-			{"pnpm", "add", "-g"},
-			{"pnpm", "add", "--global"},
-			{"yarn", "global", "add"},
-		}),
-	}
+// blockedArguments are the built-in patterns of commands that install
+// packages system-wide.
+var blockedArguments = []string{
+	// System package managers
+	"apk add",
+	"apt install",
+	"apt-get install",
+	"dnf install",
+	"emerge",
+	"pacman -S",
+	"pkg install",
+	"yum install",
+	"zypper install",
+
+	// Language-specific package managers
+	"brew install",
+	"cargo install",
+	"gem install",
+	"go install",
+	"npm install -g",
+	"npm install --global",
+	"pip install --user",
+	"pip3 install --user",
+	"pnpm add -g",
+	"pnpm add --global",
+	"yarn global add",
 }
 
-func NewBashTool(permission permission.Service, workingDir string) BaseTool {
+// NewBashTool creates the bash tool. Its commands run in the sandbox when it
+// is not nil.
+func NewBashTool(permission permission.Service, workingDir string, sandbox *shell.Sandbox, jobs shell.JobManager, policy *BashPolicy) BaseTool {
 	// Set up command blocking on the persistent shell
 	persistentShell := shell.GetPersistentShell(workingDir)
-	persistentShell.SetBlockFuncs(blockFuncs())
+	persistentShell.SetBlockFuncs([]shell.BlockFunc{policy.blockFunc()})
+	persistentShell.SetSandbox(sandbox)
 
 	return &bashTool{
 		permissions: permission,
 		workingDir:  workingDir,
+		jobs:        jobs,
+		policy:      policy,
 	}
 }
 
@@ -305,8 +315,9 @@ func (b *bashTool) Name() string {
 
 func (b *bashTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        BashToolName,
-		Description: bashDescription(),
+		Name:         BashToolName,
+		Description:  bashDescription(b.policy),
+		OutputTokens: 8000,
 		Parameters: map[string]any{
 			"command": map[string]any{
 				"type":        "string",
@@ -316,6 +327,10 @@ func (b *bashTool) Info() ToolInfo {
 				"type":        "number",
 				"description": "Optional timeout in milliseconds (max 600000)",
 			},
+			"run_in_background": map[string]any{
+				"type":        "boolean",
+				"description": "Run the command in the background and return a job id without waiting for it",
+			},
 		},
 		Required: []string{"command"},
 	}
@@ -337,17 +352,10 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 		return NewTextErrorResponse("missing command"), nil
 	}
 
-	isSafeReadOnly := false
-	cmdLower := strings.ToLower(params.Command)
-
-	for _, safe := range safeCommands {
-		if strings.HasPrefix(cmdLower, safe) {
-			if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
-				isSafeReadOnly = true
-				break
-			}
-		}
+	if reason := b.policy.Refusal(params.Command); reason != "" {
+		return NewTextErrorResponse(refusalMessage(reason)), nil
 	}
+	isSafeReadOnly := b.policy.IsSafe(params.Command)
 
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
@@ -363,14 +371,21 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 				Action:      "execute",
 				Description: fmt.Sprintf("Execute command: %s", params.Command),
 				Params: BashPermissionsParams{
-					Command: params.Command,
+					Command:         params.Command,
+					RunInBackground: params.RunInBackground,
 				},
+				// The path is the working directory for every command.
+				NoPersistentGrant: true,
 			},
 		)
 		if !p {
 			return ToolResponse{}, permission.ErrorPermissionDenied
 		}
 	}
+	if params.RunInBackground {
+		return b.runInBackground(sessionID, params.Command)
+	}
+
 	startTime := time.Now()
 	if params.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -383,6 +398,10 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 
 	// Get the current working directory after command execution
 	currentWorkingDir := persistentShell.GetWorkingDir()
+	if errors.Is(err, shell.ErrBlocked) {
+		reason := strings.TrimPrefix(err.Error(), shell.ErrBlocked.Error()+": ")
+		return NewTextErrorResponse(refusalMessage(fmt.Sprintf("`%s` matches a blocked pattern once its words are expanded", reason))), nil
+	}
 	interrupted := shell.IsInterrupt(err)
 	exitCode := shell.ExitCode(err)
 	if exitCode == 0 && !interrupted && err != nil {
@@ -432,17 +451,23 @@ func (b *bashTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	return WithResponseMetadata(NewTextResponse(stdout), metadata), nil
 }
 
-func truncateOutput(content string) string {
-	if len(content) <= MaxOutputLength {
-		return content
+func (b *bashTool) runInBackground(sessionID, command string) (ToolResponse, error) {
+	persistentShell := shell.GetPersistentShell(b.workingDir)
+	job, err := b.jobs.Start(sessionID, command, persistentShell.Shell)
+	if err != nil {
+		return ToolResponse{}, fmt.Errorf("error starting command: %w", err)
 	}
+	metadata := BashResponseMetadata{
+		StartTime:        job.StartedAt.UnixMilli(),
+		WorkingDirectory: job.WorkingDir,
+		JobID:            job.ID,
+	}
+	content := fmt.Sprintf("Started background job %s. Use the job_output tool to read its output and the job_kill tool to stop it.", job.ID)
+	return WithResponseMetadata(NewTextResponse(content), metadata), nil
+}
 
-	halfLength := MaxOutputLength / 2
-	start := content[:halfLength]
-	end := content[len(content)-halfLength:]
-
-	truncatedLinesCount := countLines(content[halfLength : len(content)-halfLength])
-	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
+func truncateOutput(content string) string {
+	return TruncateOutput(content, MaxOutputLength/4)
 }
 
 func countLines(s string) int {
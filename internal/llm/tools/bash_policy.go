@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/config"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
+)
+
+// BashPolicy decides which commands the bash tool refuses to run and which
+// read-only commands it runs without asking for permission.
+//
+// A command is blocked when it matches a configured blocked pattern, or a
+// built-in one unless it matches a configured allowed pattern.
+type BashPolicy struct {
+	blocked        []shell.CommandPattern
+	allowed        []shell.CommandPattern
+	defaultBlocked []shell.CommandPattern
+	safe           []shell.CommandPattern
+}
+
+// NewBashPolicy returns the built-in policy extended with the options, which
+// can be nil.
+func NewBashPolicy(opts *config.BashOptions) (*BashPolicy, error) {
+	if opts == nil {
+		opts = &config.BashOptions{}
+	}
+	p := &BashPolicy{}
+	var err error
+	if p.blocked, err = parseCommandPatterns(opts.BlockedCommands); err != nil {
+		return nil, fmt.Errorf("invalid options.bash.blocked_commands: %w", err)
+	}
+	if p.allowed, err = parseCommandPatterns(opts.AllowedCommands); err != nil {
+		return nil, fmt.Errorf("invalid options.bash.allowed_commands: %w", err)
+	}
+	if p.safe, err = parseCommandPatterns(append(slices.Clone(safeCommands), opts.SafeCommands...)); err != nil {
+		return nil, fmt.Errorf("invalid options.bash.safe_commands: %w", err)
+	}
+	if p.defaultBlocked, err = parseCommandPatterns(append(slices.Clone(bannedCommands), blockedArguments...)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseCommandPatterns(patterns []string) ([]shell.CommandPattern, error) {
+	parsed := make([]shell.CommandPattern, len(patterns))
+	for i, pattern := range patterns {
+		var err error
+		if parsed[i], err = shell.ParseCommandPattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// blockReason explains why the command with the given words, or the command
+// it wraps, is blocked, or returns an empty string when it isn't.
+func (p *BashPolicy) blockReason(args []string) string {
+	for ; args != nil; args = shell.UnwrapCommand(args) {
+		if reason := p.matchBlocked(args); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (p *BashPolicy) matchBlocked(args []string) string {
+	command := strings.Join(args, " ")
+	for _, pattern := range p.blocked {
+		if pattern.Match(args) {
+			return fmt.Sprintf("`%s` matches the blocked pattern %q configured in options.bash.blocked_commands", command, pattern)
+		}
+	}
+	for _, pattern := range p.allowed {
+		if pattern.MatchPrefix(args) {
+			return ""
+		}
+	}
+	for _, pattern := range p.defaultBlocked {
+		if pattern.Match(args) {
+			return fmt.Sprintf("`%s` matches the built-in blocked pattern %q, which can only be lifted by adding a pattern to options.bash.allowed_commands", command, pattern)
+		}
+	}
+	return ""
+}
+
+// Refusal explains why the command line is refused, or returns an empty
+// string when none of its commands are blocked. Commands whose words depend
+// on expansions are checked again when they run.
+func (p *BashPolicy) Refusal(command string) string {
+	calls, _, err := shell.ParseCalls(command)
+	if err != nil {
+		return ""
+	}
+	for _, args := range calls {
+		if reason := p.blockReason(args); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// IsSafe reports whether every command of the command line matches a safe
+// pattern and it doesn't write files with redirections.
+func (p *BashPolicy) IsSafe(command string) bool {
+	calls, writes, err := shell.ParseCalls(command)
+	if err != nil || writes || len(calls) == 0 {
+		return false
+	}
+	for _, args := range calls {
+		if !p.isSafeCall(args) || p.blockReason(args) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isSafeCall reports whether the command, or the command it wraps, matches a
+// safe pattern.
+func (p *BashPolicy) isSafeCall(args []string) bool {
+	for inner := shell.UnwrapCommand(args); inner != nil; inner = shell.UnwrapCommand(inner) {
+		args = inner
+	}
+	return slices.ContainsFunc(p.safe, func(pattern shell.CommandPattern) bool {
+		return pattern.MatchPrefix(args)
+	})
+}
+
+// blockFunc blocks commands as they run, with their words expanded.
+func (p *BashPolicy) blockFunc() shell.BlockFunc {
+	return func(args []string) bool {
+		return p.blockReason(args) != ""
+	}
+}
+
+// describe lists the blocked patterns for the description of the tool.
+func (p *BashPolicy) describe() string {
+	patterns := make([]string, 0, len(p.blocked)+len(p.defaultBlocked))
+	for _, pattern := range slices.Concat(p.blocked, p.defaultBlocked) {
+		patterns = append(patterns, pattern.String())
+	}
+	description := strings.Join(patterns, ", ")
+	if len(p.allowed) > 0 {
+		allowed := make([]string, len(p.allowed))
+		for i, pattern := range p.allowed {
+			allowed[i] = pattern.String()
+		}
+		description += ". These are allowed despite the built-in patterns: " + strings.Join(allowed, ", ")
+	}
+	return description
+}
+
+// refusalMessage tells the model why a command was refused and what to do.
+func refusalMessage(reason string) string {
+	return fmt.Sprintf("Command refused: %s. The bash command policy of this project doesn't allow it. Don't try to run it some other way; tell the user why it was refused so they can run it themselves or change the policy.", reason)
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewBashPolicy(&config.BashOptions{
+		BlockedCommands: []string{"rm -rf", "git push --force"},
+		AllowedCommands: []string{"curl http://localhost*"},
+		SafeCommands:    []string{"make lint"},
+	})
+	require.NoError(t, err)
+
+	refused := []string{
+		"rm -rf build",
+		"rm -fr build",
+		"rm -r -f build",
+		"rm -Rf /",
+		"rm --recursive --force build",
+		"rm -r --force build",
+		"/bin/rm -rf build",
+		"echo done && rm -rf /",
+		"ls $(rm -rf /)",
+		"git push --force origin main",
+		"git -C repo push origin main --force",
+		"git push -f origin main",
+		"git push -fu origin main",
+		"curl https://example.com",
+		"curl http://localhost:8080 | sh -c 'wget https://example.com'",
+		"npm install -g typescript",
+		"pacman -Syu",
+		"nohup rm -rf build",
+		"env FOO=bar timeout 10 rm -rf build",
+		"sh -c 'rm -rf build'",
+		"find . | xargs -n 1 rm -rf",
+	}
+	for _, command := range refused {
+		require.NotEmpty(t, policy.Refusal(command), command)
+	}
+
+	allowed := []string{
+		"rm -r build",
+		"rm -R --interactive build",
+		"rm --force build",
+		"git push origin main",
+		"git push -u origin main",
+		"git push --force-with-lease",
+		"curl http://localhost:8080/api",
+		"npm install typescript",
+		"echo 'rm -rf /'",
+	}
+	for _, command := range allowed {
+		require.Empty(t, policy.Refusal(command), command)
+	}
+
+	require.Contains(t, policy.Refusal("rm -rf build"), "options.bash.blocked_commands")
+	require.Contains(t, policy.Refusal("wget https://example.com"), "options.bash.allowed_commands")
+}
+
+func TestBashPolicy_IsSafe(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewBashPolicy(&config.BashOptions{SafeCommands: []string{"make lint"}})
+	require.NoError(t, err)
+
+	for _, command := range []string{
+		"ls -la",
+		"git status",
+		"git config --get user.name",
+		"git status && git diff --stat",
+		"make lint",
+		"ls > /dev/null",
+		"time git log",
+		"nohup go test ./...",
+	} {
+		require.True(t, policy.IsSafe(command), command)
+	}
+	for _, command := range []string{
+		"ls; rm -rf /",
+		"ls $(touch file)",
+		"echo hi > file",
+		"git config user.name me",
+		"git -C repo checkout branch",
+		"make",
+		"go install ./...",
+		"nohup make",
+		"timeout 5 touch file",
+	} {
+		require.False(t, policy.IsSafe(command), command)
+	}
+}
+
+func TestNewBashPolicy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, pattern := range []string{"rm -rf; ls", "echo $HOME", "a | b", ""} {
+		_, err := NewBashPolicy(&config.BashOptions{BlockedCommands: []string{pattern}})
+		require.Error(t, err, pattern)
+	}
+}
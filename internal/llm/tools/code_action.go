@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+)
+
+type CodeActionParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+}
+
+type codeActionTool struct {
+	workspaceEditor
+}
+
+// codeActionCandidate is a code action together with the client that
+// offered it, since it has to be resolved by the same server.
+type codeActionCandidate struct {
+	client *lsp.Client
+	action protocol.CodeAction
+}
+
+const (
+	CodeActionToolName    = "code_action"
+	codeActionDescription = `List and apply quick fixes and refactorings offered by the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use to fix diagnostics the language server knows how to fix, e.g. missing imports or unused variables
+- Use for server-provided refactorings such as extract function, inline variable or organize imports
+HOW TO USE:
+- Provide the file and the 1-based line (and optionally end_line) to get the actions available there
+- Call again with the exact title of the action you want to apply it
+- Optionally restrict the actions by kind, e.g. "quickfix", "refactor" or "source.organizeImports"
+FEATURES:
+- Diagnostics on the selected lines are passed to the server so it can offer matching fixes
+- Applied actions go through the usual permission prompt with a multi-file diff
+- Every changed file is versioned in the session history
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Actions that only run a server command, or that create, move or delete files, are not supported
+TIPS:
+- Run the diagnostics tool first to find lines that have fixable problems
+`
+)
+
+func NewCodeActionTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service, workingDir string) BaseTool {
+	return &codeActionTool{
+		workspaceEditor{
+			lspClients:  lspClients,
+			permissions: permissions,
+			files:       files,
+			workingDir:  workingDir,
+		},
+	}
+}
+
+func (c *codeActionTool) Name() string {
+	return CodeActionToolName
+}
+
+func (c *codeActionTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        CodeActionToolName,
+		Description: codeActionDescription,
+		Parameters: map[string]any{
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to get code actions for",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"description": "The first line of the selection (1-based)",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "The last line of the selection (1-based, defaults to line)",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "The title of the action to apply; leave empty to list the available actions",
+			},
+			"kind": map[string]any{
+				"type":        "string",
+				"description": "Only return actions of this kind, e.g. quickfix, refactor or source",
+			},
+		},
+		Required: []string{"file_path", "line"},
+	}
+}
+
+func (c *codeActionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params CodeActionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	clients := lspClientsForFile(c.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available for this file"), nil
+	}
+
+	filePath, err := resolveLSPFile(ctx, c.workingDir, params.FilePath, clients)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	rng, err := resolveLSPLineRange(filePath, params.Line, params.EndLine)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	candidates := c.collect(ctx, clients, filePath, rng, params.Kind)
+	if len(candidates) == 0 {
+		return NewTextResponse("No code actions available"), nil
+	}
+
+	if params.Title == "" {
+		var output strings.Builder
+		output.WriteString("Available code actions (call again with the title to apply one):\n")
+		for _, candidate := range candidates {
+			output.WriteString("- " + formatCodeAction(candidate.action) + "\n")
+		}
+		return NewTextResponse(output.String()), nil
+	}
+
+	candidate, err := matchCodeAction(candidates, params.Title)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	action := candidate.action
+	if action.Disabled != nil {
+		return NewTextErrorResponse(fmt.Sprintf("code action %q is disabled: %s", action.Title, action.Disabled.Reason)), nil
+	}
+	if action.Edit == nil {
+		resolved, err := candidate.client.ResolveCodeAction(ctx, action)
+		if err != nil {
+			slog.Debug("LSP code action resolve failed", "client", candidate.client.GetName(), "error", err)
+		} else {
+			action = resolved
+		}
+	}
+	if action.Edit == nil {
+		if action.Command != nil {
+			return NewTextErrorResponse(fmt.Sprintf("code action %q runs a server command, which is not supported", action.Title)), nil
+		}
+		return NewTextErrorResponse(fmt.Sprintf("code action %q has no changes to apply", action.Title)), nil
+	}
+
+	return c.apply(ctx, call, CodeActionToolName, action.Title, *action.Edit)
+}
+
+func (c *codeActionTool) collect(ctx context.Context, clients []*lsp.Client, filePath string, rng protocol.Range, kind string) []codeActionCandidate {
+	uri := protocol.URIFromPath(filePath)
+	var only []protocol.CodeActionKind
+	if kind != "" {
+		only = []protocol.CodeActionKind{protocol.CodeActionKind(kind)}
+	}
+
+	var candidates []codeActionCandidate
+	for _, client := range clients {
+		var diagnostics []protocol.Diagnostic
+		for _, diagnostic := range client.GetFileDiagnostics(uri) {
+			if diagnostic.Range.End.Line >= rng.Start.Line && diagnostic.Range.Start.Line <= rng.End.Line {
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+		if diagnostics == nil {
+			diagnostics = []protocol.Diagnostic{}
+		}
+
+		result, err := client.CodeAction(ctx, protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Range:        rng,
+			Context: protocol.CodeActionContext{
+				Diagnostics: diagnostics,
+				Only:        only,
+			},
+		})
+		if err != nil {
+			slog.Debug("LSP code action request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		for _, item := range result {
+			// Bare commands can't be previewed or versioned, so they are
+			// never offered.
+			if action, ok := item.Value.(protocol.CodeAction); ok {
+				candidates = append(candidates, codeActionCandidate{client: client, action: action})
+			}
+		}
+	}
+	return candidates
+}
+
+// matchCodeAction finds the action with the given title, falling back to a
+// unique case-insensitive substring match.
+func matchCodeAction(candidates []codeActionCandidate, title string) (codeActionCandidate, error) {
+	for _, candidate := range candidates {
+		if candidate.action.Title == title {
+			return candidate, nil
+		}
+	}
+
+	var matches []codeActionCandidate
+	lower := strings.ToLower(title)
+	for _, candidate := range candidates {
+		if strings.Contains(strings.ToLower(candidate.action.Title), lower) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return codeActionCandidate{}, fmt.Errorf("no code action matches %q", title)
+	case 1:
+		return matches[0], nil
+	}
+
+	var titles []string
+	for _, match := range matches {
+		titles = append(titles, fmt.Sprintf("%q", match.action.Title))
+	}
+	return codeActionCandidate{}, fmt.Errorf("%q matches several code actions: %s", title, strings.Join(titles, ", "))
+}
+
+func formatCodeAction(action protocol.CodeAction) string {
+	line := action.Title
+	if action.Kind != "" {
+		line = fmt.Sprintf("[%s] %s", action.Kind, line)
+	}
+	if action.IsPreferred {
+		line += " (preferred)"
+	}
+	if action.Disabled != nil {
+		line += fmt.Sprintf(" (disabled: %s)", action.Disabled.Reason)
+	}
+	return line
+}
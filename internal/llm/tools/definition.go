@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+)
+
+type DefinitionParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Symbol   string `json:"symbol,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+type definitionTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	DefinitionToolName    = "definition"
+	definitionDescription = `Find where a symbol is defined using the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use when you need to jump from a usage of a function, type, variable or method to its definition
+- Prefer this over grep when the name is common or overloaded, since the language server resolves the exact symbol
+HOW TO USE:
+- Provide the file containing the usage and the 1-based line number
+- Provide the symbol name as it appears on that line so the column can be located
+- Alternatively provide the 1-based column instead of the symbol name
+FEATURES:
+- Follows imports, aliases and packages across the project and its dependencies
+- Returns each definition as path:line:column followed by the source line
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Results depend on the language server having indexed the project
+TIPS:
+- Use the view tool with the returned line as offset to read the full definition
+`
+)
+
+func NewDefinitionTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &definitionTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (d *definitionTool) Name() string {
+	return DefinitionToolName
+}
+
+func (d *definitionTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        DefinitionToolName,
+		Description: definitionDescription,
+		ReadOnly:    true,
+		Parameters:  lspPositionParameters(),
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (d *definitionTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params DefinitionParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	clients := lspClientsForFile(d.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available for this file"), nil
+	}
+
+	filePath, err := resolveLSPFile(ctx, d.workingDir, params.FilePath, clients)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	position, err := resolveLSPPosition(filePath, params.Line, params.Column, params.Symbol)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var locations []protocol.Location
+	for _, client := range clients {
+		result, err := client.Definition(ctx, protocol.DefinitionParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+				Position:     position,
+			},
+		})
+		if err != nil {
+			slog.Debug("LSP definition request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		locations = append(locations, definitionLocations(result.Value)...)
+	}
+
+	if len(locations) == 0 {
+		return NewTextResponse("No definition found"), nil
+	}
+	return NewTextResponse(formatLSPLocations(locations)), nil
+}
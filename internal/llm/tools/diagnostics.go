@@ -57,8 +57,10 @@ func (b *diagnosticsTool) Name() string {
 
 func (b *diagnosticsTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        DiagnosticsToolName,
-		Description: diagnosticsDescription,
+		Name:         DiagnosticsToolName,
+		Description:  diagnosticsDescription,
+		ReadOnly:     true,
+		OutputTokens: 4000,
 		Parameters: map[string]any{
 			"file_path": map[string]any{
 				"type":        "string",
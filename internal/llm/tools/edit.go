@@ -160,21 +160,14 @@ func (e *editTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	var response ToolResponse
 	var err error
 
-	if params.OldString == "" {
+	switch {
+	case params.OldString == "":
 		response, err = e.createNewFile(ctx, params.FilePath, params.NewString, call)
-		if err != nil {
-			return response, err
-		}
-	}
-
-	if params.NewString == "" {
+	case params.NewString == "":
 		response, err = e.deleteContent(ctx, params.FilePath, params.OldString, params.ReplaceAll, call)
-		if err != nil {
-			return response, err
-		}
+	default:
+		response, err = e.replaceContent(ctx, params.FilePath, params.OldString, params.NewString, params.ReplaceAll, call)
 	}
-
-	response, err = e.replaceContent(ctx, params.FilePath, params.OldString, params.NewString, params.ReplaceAll, call)
 	if err != nil {
 		return response, err
 	}
@@ -207,6 +200,8 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string,
 		return ToolResponse{}, fmt.Errorf("failed to create parent directories: %w", err)
 	}
 
+	content = formatWithLSP(ctx, e.lspClients, filePath, content)
+
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
 		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for creating a new file")
@@ -236,13 +231,13 @@ func (e *editTool) createNewFile(ctx context.Context, filePath, content string,
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0o644)
+	err = writeFile(ctx, filePath, []byte(content))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// File can't be in the history so we create a new file history
-	_, err = e.files.Create(ctx, sessionID, filePath, "")
+	_, err = e.files.CreateNew(ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -295,7 +290,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(ctx, filePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -326,6 +321,8 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		deletionCount = 1
 	}
 
+	newContent = formatWithLSP(ctx, e.lspClients, filePath, newContent)
+
 	sessionID, messageID := GetContextValues(ctx)
 
 	if sessionID == "" || messageID == "" {
@@ -361,7 +358,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = writeFile(ctx, filePath, []byte(newContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -383,7 +380,7 @@ func (e *editTool) deleteContent(ctx context.Context, filePath, oldString string
 		}
 	}
 	// Store the new version
-	_, err = e.files.CreateVersion(ctx, sessionID, filePath, "")
+	_, err = e.files.CreateVersion(ctx, sessionID, filePath, newContent)
 	if err != nil {
 		slog.Debug("Error creating file history version", "error", err)
 	}
@@ -428,7 +425,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 			)), nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(ctx, filePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -459,6 +456,8 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 		replacementCount = 1
 	}
 
+	newContent = formatWithLSP(ctx, e.lspClients, filePath, newContent)
+
 	if oldContent == newContent {
 		return NewTextErrorResponse("new content is the same as old content. No changes made."), nil
 	}
@@ -496,7 +495,7 @@ func (e *editTool) replaceContent(ctx context.Context, filePath, oldString, newS
 		newContent, _ = fsext.ToWindowsLineEndings(newContent)
 	}
 
-	err = os.WriteFile(filePath, []byte(newContent), 0o644)
+	err = writeFile(ctx, filePath, []byte(newContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
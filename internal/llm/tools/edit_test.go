@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDeleteThenRevert(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	files := history.NewService(q, conn, dir)
+
+	path := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("keep\ndrop\nkeep too\n"), 0o644))
+	recordFileRead(path)
+
+	perms := permission.NewPermissionService(dir, true, nil, nil, permission.NewGrantStore(q, 0), permission.NewAuditLog(q))
+	edit := NewEditTool(nil, perms, files, dir)
+	input, err := json.Marshal(EditParams{FilePath: path, OldString: "drop\n"})
+	require.NoError(t, err)
+
+	ctx = context.WithValue(ctx, SessionIDContextKey, "session")
+	ctx = context.WithValue(ctx, MessageIDContextKey, "message")
+	resp, err := edit.Run(ctx, ToolCall{ID: "call", Name: EditToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+
+	latest, err := files.GetByPathAndSession(ctx, path, "session")
+	require.NoError(t, err)
+	require.Equal(t, "keep\nkeep too\n", latest.Content, "the version records the content left after the delete")
+
+	_, err = files.RevertSince(ctx, "session", 0)
+	require.NoError(t, err)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "keep\ndrop\nkeep too\n", string(content))
+
+	_, err = files.Restore(ctx, latest.ID)
+	require.NoError(t, err)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "keep\nkeep too\n", string(content), "restoring the delete keeps the rest of the file")
+}
@@ -87,8 +87,9 @@ func (t *fetchTool) Name() string {
 
 func (t *fetchTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        FetchToolName,
-		Description: fetchToolDescription,
+		Name:         FetchToolName,
+		Description:  fetchToolDescription,
+		OutputTokens: 10000,
 		Parameters: map[string]any{
 			"url": map[string]any{
 				"type":        "string",
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+)
+
+// FileSystem reads and writes the files the tools view and edit. Editors
+// set one on the context of a run so the tools see their unsaved buffers.
+type FileSystem interface {
+	ReadFile(ctx context.Context, path string) ([]byte, error)
+	WriteFile(ctx context.Context, path string, data []byte) error
+}
+
+type fileSystemContextKey struct{}
+
+// WithFileSystem returns a context in which the tools read and write files
+// through fsys instead of the disk.
+func WithFileSystem(ctx context.Context, fsys FileSystem) context.Context {
+	return context.WithValue(ctx, fileSystemContextKey{}, fsys)
+}
+
+func fileSystem(ctx context.Context) FileSystem {
+	fsys, _ := ctx.Value(fileSystemContextKey{}).(FileSystem)
+	return fsys
+}
+
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	if fsys := fileSystem(ctx); fsys != nil {
+		return fsys.ReadFile(ctx, path)
+	}
+	return os.ReadFile(path)
+}
+
+func openFile(ctx context.Context, path string) (io.ReadCloser, error) {
+	if fsys := fileSystem(ctx); fsys != nil {
+		content, err := fsys.ReadFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return os.Open(path)
+}
+
+func writeFile(ctx context.Context, path string, data []byte) error {
+	if fsys := fileSystem(ctx); fsys != nil {
+		return fsys.WriteFile(ctx, path, data)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
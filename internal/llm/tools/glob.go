@@ -83,8 +83,10 @@ func (g *globTool) Name() string {
 
 func (g *globTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        GlobToolName,
-		Description: globDescription,
+		Name:         GlobToolName,
+		Description:  globDescription,
+		ReadOnly:     true,
+		OutputTokens: 2000,
 		Parameters: map[string]any{
 			"pattern": map[string]any{
 				"type":        "string",
@@ -154,8 +154,10 @@ func (g *grepTool) Name() string {
 
 func (g *grepTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        GrepToolName,
-		Description: grepDescription,
+		Name:         GrepToolName,
+		Description:  grepDescription,
+		ReadOnly:     true,
+		OutputTokens: 4000,
 		Parameters: map[string]any{
 			"pattern": map[string]any{
 				"type":        "string",
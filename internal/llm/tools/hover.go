@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+)
+
+type HoverParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Symbol   string `json:"symbol,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+type hoverTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	HoverToolName    = "hover"
+	hoverDescription = `Show type information and documentation for a symbol using the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use when you need the signature, type or doc comment of a symbol without opening its definition
+- Helpful for checking the inferred type of a variable or the parameters of a function
+HOW TO USE:
+- Provide the file containing the symbol and the 1-based line number
+- Provide the symbol name as it appears on that line so the column can be located
+- Alternatively provide the 1-based column instead of the symbol name
+FEATURES:
+- Returns the hover text exactly as the language server provides it, usually markdown
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Some language servers return little or no information for certain symbols
+TIPS:
+- Use the definition tool if you need the full implementation
+`
+)
+
+func NewHoverTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &hoverTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (h *hoverTool) Name() string {
+	return HoverToolName
+}
+
+func (h *hoverTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        HoverToolName,
+		Description: hoverDescription,
+		ReadOnly:    true,
+		Parameters:  lspPositionParameters(),
+		Required:    []string{"file_path", "line"},
+	}
+}
+
+func (h *hoverTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params HoverParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	clients := lspClientsForFile(h.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available for this file"), nil
+	}
+
+	filePath, err := resolveLSPFile(ctx, h.workingDir, params.FilePath, clients)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	position, err := resolveLSPPosition(filePath, params.Line, params.Column, params.Symbol)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var contents []string
+	for _, client := range clients {
+		result, err := client.Hover(ctx, protocol.HoverParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+				Position:     position,
+			},
+		})
+		if err != nil {
+			slog.Debug("LSP hover request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		if value := strings.TrimSpace(result.Contents.Value); value != "" {
+			contents = append(contents, value)
+		}
+	}
+
+	if len(contents) == 0 {
+		return NewTextResponse("No hover information found"), nil
+	}
+	return NewTextResponse(strings.Join(contents, "\n\n")), nil
+}
@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
+)
+
+type JobParams struct {
+	JobID string `json:"job_id"`
+}
+
+type JobKillPermissionsParams struct {
+	JobID   string `json:"job_id"`
+	Command string `json:"command"`
+}
+
+type JobResponseMetadata struct {
+	JobID    string `json:"job_id"`
+	Command  string `json:"command"`
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+}
+
+type jobOutputTool struct {
+	jobs shell.JobManager
+}
+
+type jobKillTool struct {
+	permissions permission.Service
+	workingDir  string
+	jobs        shell.JobManager
+}
+
+const (
+	JobOutputToolName    = "job_output"
+	jobOutputDescription = `Reads the output of a command started in the background with the bash tool and reports whether it is still running.
+
+HOW TO USE:
+- Provide the job id the bash tool returned
+- Each call returns only the output written since the previous call
+- Call it again later to follow a job that is still running
+
+LIMITATIONS:
+- Only jobs of the current session can be read
+- Output that was never read is capped, and the oldest part is dropped`
+
+	JobKillToolName    = "job_kill"
+	jobKillDescription = `Stops a command started in the background with the bash tool.
+
+HOW TO USE:
+- Provide the job id the bash tool returned
+- Read any remaining output with the job_output tool afterwards
+- Stop jobs you no longer need, like dev servers, once you are done with them`
+)
+
+var jobParameters = map[string]any{
+	"job_id": map[string]any{
+		"type":        "string",
+		"description": "The id of the background job",
+	},
+}
+
+func NewJobOutputTool(jobs shell.JobManager) BaseTool {
+	return &jobOutputTool{jobs: jobs}
+}
+
+func (t *jobOutputTool) Name() string {
+	return JobOutputToolName
+}
+
+func (t *jobOutputTool) Info() ToolInfo {
+	// Not read-only: reading consumes the output, so calls running in
+	// parallel would race for it.
+	return ToolInfo{
+		Name:         JobOutputToolName,
+		Description:  jobOutputDescription,
+		OutputTokens: 8000,
+		Parameters:   jobParameters,
+		Required:     []string{"job_id"},
+	}
+}
+
+func (t *jobOutputTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	job, err := sessionJob(ctx, t.jobs, call)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	job, output, err := t.jobs.Output(job.ID)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Job %s is %s", job.ID, jobState(job))
+	if stdout := truncateOutput(output.Stdout); stdout != "" {
+		fmt.Fprintf(&sb, "\n\n<stdout>\n%s\n</stdout>", strings.TrimSuffix(stdout, "\n"))
+	}
+	if stderr := truncateOutput(output.Stderr); stderr != "" {
+		fmt.Fprintf(&sb, "\n\n<stderr>\n%s\n</stderr>", strings.TrimSuffix(stderr, "\n"))
+	}
+	if output.Stdout == "" && output.Stderr == "" {
+		sb.WriteString("\n\nNo new output")
+	}
+	return WithResponseMetadata(NewTextResponse(sb.String()), jobMetadata(job)), nil
+}
+
+func NewJobKillTool(permissions permission.Service, workingDir string, jobs shell.JobManager) BaseTool {
+	return &jobKillTool{permissions: permissions, workingDir: workingDir, jobs: jobs}
+}
+
+func (t *jobKillTool) Name() string {
+	return JobKillToolName
+}
+
+func (t *jobKillTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:        JobKillToolName,
+		Description: jobKillDescription,
+		Parameters:  jobParameters,
+		Required:    []string{"job_id"},
+	}
+}
+
+func (t *jobKillTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	job, err := sessionJob(ctx, t.jobs, call)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if job.Status != shell.JobRunning {
+		return WithResponseMetadata(NewTextResponse(fmt.Sprintf("Job %s is already %s", job.ID, jobState(job))), jobMetadata(job)), nil
+	}
+	p := t.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   job.SessionID,
+			Path:        t.workingDir,
+			ToolCallID:  call.ID,
+			ToolName:    JobKillToolName,
+			Action:      "kill",
+			Description: fmt.Sprintf("Kill background job %s: %s", job.ID, job.Command),
+			Params: JobKillPermissionsParams{
+				JobID:   job.ID,
+				Command: job.Command,
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	if err := t.jobs.Kill(job.ID); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	job.Status = shell.JobKilled
+	return WithResponseMetadata(NewTextResponse(fmt.Sprintf("Job %s was killed", job.ID)), jobMetadata(job)), nil
+}
+
+// sessionJob returns the job the call is about, which has to belong to the
+// session of the call.
+func sessionJob(ctx context.Context, jobs shell.JobManager, call ToolCall) (shell.Job, error) {
+	var params JobParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return shell.Job{}, errors.New("invalid parameters")
+	}
+	if params.JobID == "" {
+		return shell.Job{}, errors.New("job_id is required")
+	}
+	sessionID, _ := GetContextValues(ctx)
+	job, err := jobs.Get(params.JobID)
+	if err != nil {
+		return shell.Job{}, err
+	}
+	if job.SessionID != sessionID {
+		return shell.Job{}, shell.ErrJobNotFound
+	}
+	return job, nil
+}
+
+func jobState(job shell.Job) string {
+	if job.Status == shell.JobExited {
+		return fmt.Sprintf("exited with code %d", job.ExitCode)
+	}
+	return string(job.Status)
+}
+
+func jobMetadata(job shell.Job) JobResponseMetadata {
+	return JobResponseMetadata{
+		JobID:    job.ID,
+		Command:  job.Command,
+		Status:   string(job.Status),
+		ExitCode: job.ExitCode,
+	}
+}
@@ -91,8 +91,10 @@ func (l *lsTool) Name() string {
 
 func (l *lsTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        LSToolName,
-		Description: lsDescription,
+		Name:         LSToolName,
+		Description:  lsDescription,
+		ReadOnly:     true,
+		OutputTokens: 2000,
 		Parameters: map[string]any{
 			"path": map[string]any{
 				"type":        "string",
@@ -110,6 +112,20 @@ func (l *lsTool) Info() ToolInfo {
 	}
 }
 
+// ReadOnly implements ReadOnlyCaller: listing outside the working directory
+// asks for a permission.
+func (l *lsTool) ReadOnly(input string) bool {
+	var params LSParams
+	if err := json.Unmarshal([]byte(input), &params); err != nil || params.Path == "" {
+		return true
+	}
+	path, err := fsext.Expand(params.Path)
+	if err != nil {
+		return true
+	}
+	return !outsideWorkingDir(l.workingDir, path)
+}
+
 func (l *lsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
 	var params LSParams
 	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
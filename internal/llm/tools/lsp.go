@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/fsext"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+)
+
+// maxLSPLocations caps the number of locations returned by the LSP
+// navigation tools so large reference lists don't flood the context.
+const maxLSPLocations = 100
+
+// lspPositionParameters returns the parameters shared by the tools that take
+// a position in a file so the model gets consistent guidance.
+func lspPositionParameters() map[string]any {
+	return map[string]any{
+		"file_path": map[string]any{
+			"type":        "string",
+			"description": "The path to the file containing the symbol",
+		},
+		"line": map[string]any{
+			"type":        "integer",
+			"description": "The line number of the symbol (1-based)",
+		},
+		"symbol": map[string]any{
+			"type":        "string",
+			"description": "The symbol name as it appears on the line, used to locate the column (preferred over column)",
+		},
+		"column": map[string]any{
+			"type":        "integer",
+			"description": "The column of the symbol (1-based); ignored when symbol is set",
+		},
+	}
+}
+
+// lspClientsForFile returns the clients that handle the given file, sorted
+// by name so results are stable between calls.
+func lspClientsForFile(lsps map[string]*lsp.Client, filePath string) []*lsp.Client {
+	var clients []*lsp.Client
+	for _, client := range lsps {
+		if filePath == "" || client.HandlesFile(filePath) {
+			clients = append(clients, client)
+		}
+	}
+	slices.SortFunc(clients, func(a, b *lsp.Client) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return clients
+}
+
+// resolveLSPFile makes the path absolute, checks that it exists, and opens
+// it in every client that handles it.
+func resolveLSPFile(ctx context.Context, workingDir, filePath string, clients []*lsp.Client) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("file_path is required")
+	}
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(workingDir, filePath)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", filePath)
+		}
+		return "", fmt.Errorf("error accessing file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory, not a file: %s", filePath)
+	}
+	for _, client := range clients {
+		_ = client.OpenFileOnDemand(ctx, filePath)
+	}
+	return filePath, nil
+}
+
+// resolveLSPPosition converts a 1-based line and either a symbol name or a
+// 1-based column into an LSP position. When neither is provided the first
+// non-blank character of the line is used.
+func resolveLSPPosition(filePath string, line, column int, symbol string) (protocol.Position, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return protocol.Position{}, fmt.Errorf("error reading file: %w", err)
+	}
+	text, _ := fsext.ToUnixLineEndings(string(content))
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return protocol.Position{}, fmt.Errorf("line %d is out of range (file has %d lines)", line, len(lines))
+	}
+	lineText := lines[line-1]
+
+	var offset int
+	switch {
+	case symbol != "":
+		offset = strings.Index(lineText, symbol)
+		if offset == -1 {
+			return protocol.Position{}, fmt.Errorf("symbol %q not found on line %d", symbol, line)
+		}
+	case column > 0:
+		runes := utf8.RuneCountInString(lineText)
+		if column > runes+1 {
+			return protocol.Position{}, fmt.Errorf("column %d is out of range (line has %d characters)", column, runes)
+		}
+		offset = len(string([]rune(lineText)[:column-1]))
+	default:
+		offset = strings.IndexFunc(lineText, func(r rune) bool { return !unicode.IsSpace(r) })
+		if offset == -1 {
+			offset = 0
+		}
+	}
+
+	return protocol.Position{
+		Line:      uint32(line - 1),
+		Character: uint32(len(utf16.Encode([]rune(lineText[:offset])))),
+	}, nil
+}
+
+// lspSourceLines caches file contents by path so a list of locations in the
+// same file only reads it once.
+type lspSourceLines map[string][]string
+
+// line returns the trimmed source line at the 0-based index, or an empty
+// string if the file can't be read.
+func (c lspSourceLines) line(path string, line int) string {
+	lines, ok := c[path]
+	if !ok {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			text, _ := fsext.ToUnixLineEndings(string(content))
+			lines = strings.Split(text, "\n")
+		}
+		c[path] = lines
+	}
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	snippet := strings.TrimSpace(lines[line])
+	if len(snippet) > MaxLineLength {
+		snippet = snippet[:MaxLineLength] + "..."
+	}
+	return snippet
+}
+
+// format renders a location as path:line:column followed by the trimmed
+// source line, if it can be read.
+func (c lspSourceLines) format(uri protocol.DocumentURI, rng protocol.Range) string {
+	path, err := uri.Path()
+	if err != nil {
+		path = string(uri)
+	}
+	location := fmt.Sprintf("%s:%d:%d", path, rng.Start.Line+1, rng.Start.Character+1)
+	if snippet := c.line(path, int(rng.Start.Line)); snippet != "" {
+		location += ": " + snippet
+	}
+	return location
+}
+
+// formatLSPLocations renders a list of locations, deduplicated and capped at
+// maxLSPLocations.
+func formatLSPLocations(locations []protocol.Location) string {
+	locations = slices.Clone(locations)
+	slices.SortFunc(locations, func(a, b protocol.Location) int {
+		if c := strings.Compare(string(a.URI), string(b.URI)); c != 0 {
+			return c
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return int(a.Range.Start.Line) - int(b.Range.Start.Line)
+		}
+		return int(a.Range.Start.Character) - int(b.Range.Start.Character)
+	})
+
+	source := lspSourceLines{}
+	seen := make(map[string]bool, len(locations))
+	var lines []string
+	for _, loc := range locations {
+		formatted := source.format(loc.URI, loc.Range)
+		if seen[formatted] {
+			continue
+		}
+		seen[formatted] = true
+		lines = append(lines, formatted)
+	}
+
+	var output strings.Builder
+	if len(lines) > maxLSPLocations {
+		output.WriteString(strings.Join(lines[:maxLSPLocations], "\n"))
+		fmt.Fprintf(&output, "\n... and %d more locations", len(lines)-maxLSPLocations)
+	} else {
+		output.WriteString(strings.Join(lines, "\n"))
+	}
+	return output.String()
+}
+
+// definitionLocations flattens the different result shapes returned by
+// definition-like requests into plain locations.
+func definitionLocations(value any) []protocol.Location {
+	switch v := value.(type) {
+	case protocol.Definition:
+		switch d := v.Value.(type) {
+		case protocol.Location:
+			return []protocol.Location{d}
+		case []protocol.Location:
+			return d
+		}
+	case []protocol.DefinitionLink:
+		locations := make([]protocol.Location, 0, len(v))
+		for _, link := range v {
+			locations = append(locations, protocol.Location{
+				URI:   link.TargetURI,
+				Range: link.TargetSelectionRange,
+			})
+		}
+		return locations
+	}
+	return nil
+}
+
+func symbolKindName(kind protocol.SymbolKind) string {
+	if name, ok := protocol.TableKindMap[kind]; ok {
+		return name
+	}
+	return "Symbol"
+}
+
+// resolveLSPLineRange returns the range covering the given 1-based lines,
+// from the start of the first line to the end of the last one.
+func resolveLSPLineRange(filePath string, startLine, endLine int) (protocol.Range, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return protocol.Range{}, fmt.Errorf("error reading file: %w", err)
+	}
+	text, _ := fsext.ToUnixLineEndings(string(content))
+	lines := strings.Split(text, "\n")
+	if endLine < startLine {
+		endLine = startLine
+	}
+	if startLine < 1 || endLine > len(lines) {
+		return protocol.Range{}, fmt.Errorf("lines %d-%d are out of range (file has %d lines)", startLine, endLine, len(lines))
+	}
+	return protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1)},
+		End: protocol.Position{
+			Line:      uint32(endLine - 1),
+			Character: uint32(len(utf16.Encode([]rune(lines[endLine-1])))),
+		},
+	}, nil
+}
+
+// formatWithLSP formats content with the first client that handles the file
+// and has format_on_write enabled. The original content is returned when no
+// client formats the file or formatting fails.
+func formatWithLSP(ctx context.Context, lsps map[string]*lsp.Client, filePath, content string) string {
+	for _, client := range lspClientsForFile(lsps, filePath) {
+		if !client.FormatsOnWrite() {
+			continue
+		}
+		formatted, err := client.FormatContent(ctx, filePath, content)
+		if err != nil {
+			slog.Warn("Failed to format file", "client", client.GetName(), "file", filePath, "error", err)
+			continue
+		}
+		return formatted
+	}
+	return content
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLSPPosition(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\n\n\tfunc héllo() { world() }\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	tests := []struct {
+		name    string
+		line    int
+		column  int
+		symbol  string
+		want    protocol.Position
+		wantErr string
+	}{
+		{name: "first non blank", line: 3, want: protocol.Position{Line: 2, Character: 1}},
+		{name: "symbol after multibyte rune", line: 3, symbol: "world", want: protocol.Position{Line: 2, Character: 16}},
+		{name: "column", line: 3, column: 7, want: protocol.Position{Line: 2, Character: 6}},
+		{name: "symbol preferred over column", line: 3, column: 1, symbol: "func", want: protocol.Position{Line: 2, Character: 1}},
+		{name: "missing symbol", line: 1, symbol: "world", wantErr: "not found on line 1"},
+		{name: "line out of range", line: 10, wantErr: "out of range"},
+		{name: "column out of range", line: 1, column: 20, wantErr: "out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveLSPPosition(path, tt.line, tt.column, tt.symbol)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatLSPLocations(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc a() {}\n\nfunc b() { a() }\n"), 0o644))
+	uri := protocol.URIFromPath(path)
+
+	at := func(line, char uint32) protocol.Location {
+		return protocol.Location{URI: uri, Range: protocol.Range{Start: protocol.Position{Line: line, Character: char}}}
+	}
+
+	output := formatLSPLocations([]protocol.Location{at(4, 11), at(2, 5), at(4, 11)})
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2, "duplicates should be removed")
+	require.Equal(t, path+":3:6: func a() {}", lines[0])
+	require.Equal(t, path+":5:12: func b() { a() }", lines[1])
+}
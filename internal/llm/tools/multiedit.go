@@ -242,6 +242,8 @@ func (m *multiEditTool) processMultiEditWithCreation(ctx context.Context, params
 		currentContent = newContent
 	}
 
+	currentContent = formatWithLSP(ctx, m.lspClients, params.FilePath, currentContent)
+
 	// Get session and message IDs
 	sessionID, messageID := GetContextValues(ctx)
 	if sessionID == "" || messageID == "" {
@@ -269,13 +271,13 @@ func (m *multiEditTool) processMultiEditWithCreation(ctx context.Context, params
 	}
 
 	// Write the file
-	err := os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err := writeFile(ctx, params.FilePath, []byte(currentContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// Update file history
-	_, err = m.files.Create(ctx, sessionID, params.FilePath, "")
+	_, err = m.files.CreateNew(ctx, sessionID, params.FilePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
@@ -330,7 +332,7 @@ func (m *multiEditTool) processMultiEditExistingFile(ctx context.Context, params
 	}
 
 	// Read current file content
-	content, err := os.ReadFile(params.FilePath)
+	content, err := readFile(ctx, params.FilePath)
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
 	}
@@ -347,6 +349,8 @@ func (m *multiEditTool) processMultiEditExistingFile(ctx context.Context, params
 		currentContent = newContent
 	}
 
+	currentContent = formatWithLSP(ctx, m.lspClients, params.FilePath, currentContent)
+
 	// Check if content actually changed
 	if oldContent == currentContent {
 		return NewTextErrorResponse("no changes made - all edits resulted in identical content"), nil
@@ -382,7 +386,7 @@ func (m *multiEditTool) processMultiEditExistingFile(ctx context.Context, params
 	}
 
 	// Write the updated content
-	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = writeFile(ctx, params.FilePath, []byte(currentContent))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+)
+
+type ReferencesParams struct {
+	FilePath           string `json:"file_path"`
+	Line               int    `json:"line"`
+	Symbol             string `json:"symbol,omitempty"`
+	Column             int    `json:"column,omitempty"`
+	IncludeDeclaration bool   `json:"include_declaration,omitempty"`
+}
+
+type referencesTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	ReferencesToolName    = "references"
+	referencesDescription = `Find all references to a symbol across the project using the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use when you need every caller of a function, every user of a type or every read of a variable
+- Prefer this over grep when the name is common, since only references to the exact symbol are returned
+HOW TO USE:
+- Provide the file containing the symbol and the 1-based line number
+- Provide the symbol name as it appears on that line so the column can be located
+- Alternatively provide the 1-based column instead of the symbol name
+- Set include_declaration to also list the declaration itself
+FEATURES:
+- Returns each reference as path:line:column followed by the source line
+- Results are sorted by file and position
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Results are limited to 100 locations
+TIPS:
+- Run before renaming or changing a signature to see what will be affected
+`
+)
+
+func NewReferencesTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &referencesTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (r *referencesTool) Name() string {
+	return ReferencesToolName
+}
+
+func (r *referencesTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["include_declaration"] = map[string]any{
+		"type":        "boolean",
+		"description": "Include the declaration of the symbol in the results (default false)",
+	}
+	return ToolInfo{
+		Name:         ReferencesToolName,
+		Description:  referencesDescription,
+		ReadOnly:     true,
+		OutputTokens: 4000,
+		Parameters:   parameters,
+		Required:     []string{"file_path", "line"},
+	}
+}
+
+func (r *referencesTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params ReferencesParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	clients := lspClientsForFile(r.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available for this file"), nil
+	}
+
+	filePath, err := resolveLSPFile(ctx, r.workingDir, params.FilePath, clients)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	position, err := resolveLSPPosition(filePath, params.Line, params.Column, params.Symbol)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var locations []protocol.Location
+	for _, client := range clients {
+		result, err := client.References(ctx, protocol.ReferenceParams{
+			Context: protocol.ReferenceContext{
+				IncludeDeclaration: params.IncludeDeclaration,
+			},
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)},
+				Position:     position,
+			},
+		})
+		if err != nil {
+			slog.Debug("LSP references request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		locations = append(locations, result...)
+	}
+
+	if len(locations) == 0 {
+		return NewTextResponse("No references found"), nil
+	}
+	return NewTextResponse(formatLSPLocations(locations)), nil
+}
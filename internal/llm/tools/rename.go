@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Symbol   string `json:"symbol,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	NewName  string `json:"new_name"`
+}
+
+type renameTool struct {
+	workspaceEditor
+}
+
+const (
+	RenameToolName    = "rename"
+	renameDescription = `Rename a symbol across the whole project using the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use when you need to rename a function, type, method, variable or field everywhere it is used
+- Prefer this over repeated edit calls, since the language server only changes references to the exact symbol
+HOW TO USE:
+- Provide the file containing the symbol and the 1-based line number
+- Provide the symbol name as it appears on that line so the column can be located
+- Alternatively provide the 1-based column instead of the symbol name
+- Provide the new name for the symbol
+FEATURES:
+- Updates every file that refers to the symbol in a single step
+- Shows the full multi-file diff for approval before anything is written
+- Every changed file is versioned in the session history
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Renames that create, move or delete files are not supported
+TIPS:
+- Use the references tool first if you want to see what will be affected
+`
+)
+
+func NewRenameTool(lspClients map[string]*lsp.Client, permissions permission.Service, files history.Service, workingDir string) BaseTool {
+	return &renameTool{
+		workspaceEditor{
+			lspClients:  lspClients,
+			permissions: permissions,
+			files:       files,
+			workingDir:  workingDir,
+		},
+	}
+}
+
+func (r *renameTool) Name() string {
+	return RenameToolName
+}
+
+func (r *renameTool) Info() ToolInfo {
+	parameters := lspPositionParameters()
+	parameters["new_name"] = map[string]any{
+		"type":        "string",
+		"description": "The new name for the symbol",
+	}
+	return ToolInfo{
+		Name:        RenameToolName,
+		Description: renameDescription,
+		Parameters:  parameters,
+		Required:    []string{"file_path", "line", "new_name"},
+	}
+}
+
+func (r *renameTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params RenameParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	if params.NewName == "" {
+		return NewTextErrorResponse("new_name is required"), nil
+	}
+
+	clients := lspClientsForFile(r.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available for this file"), nil
+	}
+
+	filePath, err := resolveLSPFile(ctx, r.workingDir, params.FilePath, clients)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	position, err := resolveLSPPosition(filePath, params.Line, params.Column, params.Symbol)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	document := protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filePath)}
+
+	var lastErr error
+	for _, client := range clients {
+		prepared, err := client.PrepareRename(ctx, protocol.PrepareRenameParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: document,
+				Position:     position,
+			},
+		})
+		// Servers without prepareRename support return an error; only a
+		// successful empty answer means the position can't be renamed.
+		if err == nil && prepared.Value == nil {
+			lastErr = fmt.Errorf("the symbol at this position cannot be renamed")
+			continue
+		}
+
+		edit, err := client.Rename(ctx, protocol.RenameParams{
+			TextDocument: document,
+			Position:     position,
+			NewName:      params.NewName,
+		})
+		if err != nil {
+			slog.Debug("LSP rename request failed", "client", client.GetName(), "error", err)
+			lastErr = err
+			continue
+		}
+
+		return r.apply(ctx, call, RenameToolName, fmt.Sprintf("Rename symbol to %s", params.NewName), edit)
+	}
+
+	if lastErr != nil {
+		return NewTextErrorResponse(fmt.Sprintf("rename failed: %s", lastErr)), nil
+	}
+	return NewTextErrorResponse("rename failed: no language server could rename the symbol"), nil
+}
@@ -23,7 +23,6 @@ var safeCommands = []string{
 	"ps",
 	"pwd",
 	"set",
-	"time",
 	"timeout",
 	"top",
 	"type",
@@ -144,8 +144,10 @@ func (t *sourcegraphTool) Name() string {
 
 func (t *sourcegraphTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        SourcegraphToolName,
-		Description: sourcegraphToolDescription,
+		Name:         SourcegraphToolName,
+		Description:  sourcegraphToolDescription,
+		ReadOnly:     true,
+		OutputTokens: 4000,
 		Parameters: map[string]any{
 			"query": map[string]any{
 				"type":        "string",
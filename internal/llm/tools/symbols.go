@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+)
+
+type SymbolsParams struct {
+	Query    string `json:"query,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+}
+
+type symbolsTool struct {
+	lspClients map[string]*lsp.Client
+	workingDir string
+}
+
+const (
+	SymbolsToolName    = "symbols"
+	symbolsDescription = `List symbols in a file or search symbols across the workspace using the configured language servers.
+WHEN TO USE THIS TOOL:
+- Use with file_path to get an outline of a file (types, functions, methods, fields) without reading all of it
+- Use with query to find where a type or function is declared anywhere in the project
+HOW TO USE:
+- Provide file_path to list the symbols declared in that file
+- Provide query to search symbol names across the workspace
+- Provide both to filter the symbols of a file by name
+FEATURES:
+- Returns the kind, name and location of each symbol
+- File outlines keep the nesting of symbols, e.g. methods under their class
+LIMITATIONS:
+- Requires a language server configured for the file type
+- Workspace search quality and matching rules depend on the language server
+- Results are limited to 100 symbols
+TIPS:
+- Use the returned line numbers with the view, definition or references tools
+`
+)
+
+func NewSymbolsTool(lspClients map[string]*lsp.Client, workingDir string) BaseTool {
+	return &symbolsTool{
+		lspClients: lspClients,
+		workingDir: workingDir,
+	}
+}
+
+func (s *symbolsTool) Name() string {
+	return SymbolsToolName
+}
+
+func (s *symbolsTool) Info() ToolInfo {
+	return ToolInfo{
+		Name:         SymbolsToolName,
+		Description:  symbolsDescription,
+		ReadOnly:     true,
+		OutputTokens: 4000,
+		Parameters: map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "The symbol name to search for",
+			},
+			"file_path": map[string]any{
+				"type":        "string",
+				"description": "The path to the file to list symbols for",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (s *symbolsTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
+	var params SymbolsParams
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return NewTextErrorResponse(fmt.Sprintf("error parsing parameters: %s", err)), nil
+	}
+
+	if params.Query == "" && params.FilePath == "" {
+		return NewTextErrorResponse("either query or file_path is required"), nil
+	}
+
+	clients := lspClientsForFile(s.lspClients, params.FilePath)
+	if len(clients) == 0 {
+		return NewTextErrorResponse("no LSP clients available"), nil
+	}
+
+	var lines []string
+	if params.FilePath != "" {
+		filePath, err := resolveLSPFile(ctx, s.workingDir, params.FilePath, clients)
+		if err != nil {
+			return NewTextErrorResponse(err.Error()), nil
+		}
+		lines = s.documentSymbols(ctx, clients, filePath, params.Query)
+	} else {
+		lines = s.workspaceSymbols(ctx, clients, params.Query)
+	}
+
+	if len(lines) == 0 {
+		return NewTextResponse("No symbols found"), nil
+	}
+
+	var output strings.Builder
+	if len(lines) > maxLSPLocations {
+		output.WriteString(strings.Join(lines[:maxLSPLocations], "\n"))
+		fmt.Fprintf(&output, "\n... and %d more symbols", len(lines)-maxLSPLocations)
+	} else {
+		output.WriteString(strings.Join(lines, "\n"))
+	}
+	return NewTextResponse(output.String()), nil
+}
+
+func (s *symbolsTool) documentSymbols(ctx context.Context, clients []*lsp.Client, filePath, query string) []string {
+	uri := protocol.URIFromPath(filePath)
+	query = strings.ToLower(query)
+
+	var lines []string
+	var walk func(symbols []protocol.DocumentSymbol, depth int)
+	walk = func(symbols []protocol.DocumentSymbol, depth int) {
+		for _, symbol := range symbols {
+			if query == "" || strings.Contains(strings.ToLower(symbol.Name), query) {
+				line := fmt.Sprintf("%s%s %s (line %d)", strings.Repeat("  ", depth), symbolKindName(symbol.Kind), symbol.Name, symbol.SelectionRange.Start.Line+1)
+				if symbol.Detail != "" {
+					line += " " + symbol.Detail
+				}
+				lines = append(lines, line)
+			}
+			walk(symbol.Children, depth+1)
+		}
+	}
+
+	for _, client := range clients {
+		result, err := client.DocumentSymbol(ctx, protocol.DocumentSymbolParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		})
+		if err != nil {
+			slog.Debug("LSP document symbol request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		switch v := result.Value.(type) {
+		case []protocol.DocumentSymbol:
+			walk(v, 0)
+		case []protocol.SymbolInformation:
+			for _, symbol := range v {
+				if query != "" && !strings.Contains(strings.ToLower(symbol.Name), query) {
+					continue
+				}
+				name := symbol.Name
+				if symbol.ContainerName != "" {
+					name = symbol.ContainerName + "." + name
+				}
+				lines = append(lines, fmt.Sprintf("%s %s (line %d)", symbolKindName(symbol.Kind), name, symbol.Location.Range.Start.Line+1))
+			}
+		}
+		if len(lines) > 0 {
+			// The first client that knows the file is enough; others would
+			// only repeat the same outline.
+			break
+		}
+	}
+	return lines
+}
+
+func (s *symbolsTool) workspaceSymbols(ctx context.Context, clients []*lsp.Client, query string) []string {
+	source := lspSourceLines{}
+	seen := make(map[string]bool)
+	var lines []string
+	for _, client := range clients {
+		result, err := client.Symbol(ctx, protocol.WorkspaceSymbolParams{Query: query})
+		if err != nil {
+			slog.Debug("LSP workspace symbol request failed", "client", client.GetName(), "error", err)
+			continue
+		}
+		symbols, err := result.Results()
+		if err != nil {
+			slog.Debug("Unexpected LSP workspace symbol result", "client", client.GetName(), "error", err)
+			continue
+		}
+		for _, symbol := range symbols {
+			var kind protocol.SymbolKind
+			switch v := symbol.(type) {
+			case *protocol.WorkspaceSymbol:
+				kind = v.Kind
+			case *protocol.SymbolInformation:
+				kind = v.Kind
+			}
+			location := symbol.GetLocation()
+			line := fmt.Sprintf("%s %s %s", symbolKindName(kind), symbol.GetName(), source.format(location.URI, location.Range))
+			if seen[line] {
+				continue
+			}
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
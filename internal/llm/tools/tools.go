@@ -3,6 +3,10 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
 )
 
 type ToolInfo struct {
@@ -10,6 +14,56 @@ type ToolInfo struct {
 	Description string
 	Parameters  map[string]any
 	Required    []string
+	// OutputTokens is the most tokens of the output of the tool sent to the
+	// model, DefaultOutputTokens when zero.
+	OutputTokens int
+	// ReadOnly tools don't change the files or run commands, so the agent
+	// runs several calls of them at once, unless the tool is a
+	// ReadOnlyCaller that says otherwise for a call.
+	ReadOnly bool
+}
+
+// ReadOnlyCaller is implemented by read-only tools that ask for a permission
+// for some calls, such as reading outside the working directory. Those calls
+// run on their own, so that when the user denies one, the calls that follow
+// are canceled instead of asking too.
+type ReadOnlyCaller interface {
+	ReadOnly(input string) bool
+}
+
+// DefaultOutputTokens is the output budget of the tools that don't set one.
+const DefaultOutputTokens = 10000
+
+// AgentToolName is the name of the tool that runs a sub-agent. The tool
+// lives in the agent package, but the name is here so that packages that
+// only look at tool calls don't depend on the agent.
+const AgentToolName = "agent"
+
+// TruncateOutput cuts the middle out of content when it is over a budget of
+// tokens, at about four characters per token.
+func TruncateOutput(content string, tokens int) string {
+	if tokens <= 0 {
+		tokens = DefaultOutputTokens
+	}
+	maxLength := tokens * 4
+	if len(content) <= maxLength {
+		return content
+	}
+
+	// Cut at rune boundaries so the output stays valid UTF-8.
+	startEnd := maxLength / 2
+	for startEnd > 0 && !utf8.RuneStart(content[startEnd]) {
+		startEnd--
+	}
+	endStart := len(content) - maxLength/2
+	for endStart < len(content) && !utf8.RuneStart(content[endStart]) {
+		endStart++
+	}
+	start := content[:startEnd]
+	end := content[endStart:]
+
+	truncatedLinesCount := countLines(content[startEnd:endStart])
+	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
 }
 
 type toolResponseType string
@@ -83,3 +137,21 @@ func GetContextValues(ctx context.Context) (string, string) {
 	}
 	return sessionID.(string), messageID.(string)
 }
+
+// outsideWorkingDir tells whether path, taken relative to the working
+// directory when it isn't absolute, is outside of it.
+func outsideWorkingDir(workingDir, path string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	absWorkingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return true
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	relPath, err := filepath.Rel(absWorkingDir, absPath)
+	return err != nil || strings.HasPrefix(relPath, "..")
+}
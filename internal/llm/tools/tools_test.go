@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateOutput(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "short", TruncateOutput("short", 10))
+
+	// Three-byte runes, so cutting 22 bytes from each end falls inside one.
+	content := strings.Repeat("日本語\n", 20)
+	truncated := TruncateOutput(content, 11)
+	require.True(t, utf8.ValidString(truncated))
+	require.Contains(t, truncated, "lines truncated")
+	require.Less(t, len(truncated), len(content))
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError is an argument of a tool call that doesn't match the
+// parameters of the tool. Field is empty when the arguments as a whole are
+// wrong.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// InputError lists the arguments of a tool call that are wrong, for the
+// model to fix them.
+type InputError struct {
+	Tool   string
+	Fields []FieldError
+}
+
+func (e *InputError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Invalid arguments for the %s tool:", e.Tool)
+	for _, f := range e.Fields {
+		if f.Field == "" {
+			fmt.Fprintf(&sb, "\n- %s", f.Message)
+		} else {
+			fmt.Fprintf(&sb, "\n- %s: %s", f.Field, f.Message)
+		}
+	}
+	sb.WriteString("\nFix these arguments and call the tool again.")
+	return sb.String()
+}
+
+// ValidateInput checks the arguments of a call of a tool against its
+// parameters and returns them repaired. Text around the JSON object is
+// dropped, and strings holding numbers, booleans, arrays or objects are
+// converted where the parameters expect those. Arguments that can't be
+// repaired are reported in an *InputError, including arguments that were
+// cut off: closing them could turn a command or a file content into a
+// shorter one that is still valid.
+func ValidateInput(info ToolInfo, input string) (string, error) {
+	args, err := parseInput(input)
+	if err != nil {
+		return "", &InputError{Tool: info.Name, Fields: []FieldError{{Message: err.Error()}}}
+	}
+	var v validator
+	args = v.object("", args, info.Parameters, info.Required)
+	if len(v.errs) > 0 {
+		return "", &InputError{Tool: info.Name, Fields: v.errs}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseInput(input string) (map[string]any, error) {
+	input = strings.TrimSpace(input)
+	// Some models wrap the arguments in a code block.
+	if strings.HasPrefix(input, "```") {
+		input = strings.TrimPrefix(input, "```json")
+		input = strings.TrimPrefix(input, "```")
+		input = strings.TrimSuffix(input, "```")
+		input = strings.TrimSpace(input)
+	}
+	if input == "" {
+		return map[string]any{}, nil
+	}
+
+	value, err := decodeFirst(input)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errors.New("arguments were cut off before the end of the JSON object, send them again in full")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("arguments are not valid JSON: %v", err)
+	}
+	args, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("arguments must be a JSON object, got %s", describe(value))
+	}
+	return args, nil
+}
+
+// decodeFirst decodes the first JSON value of s, ignoring what follows it.
+func decodeFirst(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var value any
+	err := dec.Decode(&value)
+	return value, err
+}
+
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) fail(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) object(path string, obj map[string]any, properties map[string]any, required []string) map[string]any {
+	for _, name := range required {
+		if value, ok := obj[name]; !ok || value == nil {
+			v.fail(joinField(path, name), "is required")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		// Optional arguments set to null are left out.
+		if obj[name] == nil {
+			delete(obj, name)
+			continue
+		}
+		if schema, ok := properties[name].(map[string]any); ok {
+			obj[name] = v.value(joinField(path, name), obj[name], schema)
+		}
+	}
+	return obj
+}
+
+func (v *validator) value(path string, value any, schema map[string]any) any {
+	types := stringList(schema["type"])
+	if len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		converted, ok := convert(value, types)
+		if !ok {
+			v.fail(path, "expected %s, got %s", strings.Join(types, " or "), describe(value))
+			return value
+		}
+		value = converted
+	}
+
+	switch x := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		value = v.object(path, x, properties, stringList(schema["required"]))
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range x {
+				x[i] = v.value(fmt.Sprintf("%s[%d]", path, i), item, items)
+			}
+		}
+	}
+
+	if enum := anyList(schema["enum"]); len(enum) > 0 {
+		if !slices.ContainsFunc(enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+			v.fail(path, "must be one of %v, got %s", enum, describe(value))
+		}
+	}
+	return value
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func hasType(value any, t string) bool {
+	switch x := value.(type) {
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "integer" {
+			_, err := x.Int64()
+			return err == nil
+		}
+		return t == "number"
+	case bool:
+		return t == "boolean"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case nil:
+		return t == "null"
+	}
+	return false
+}
+
+// convert repairs a value of the wrong type, like a number sent as a
+// string, into the first of types it can be turned into.
+func convert(value any, types []string) (any, bool) {
+	for _, t := range types {
+		switch x := value.(type) {
+		case string:
+			s := strings.TrimSpace(x)
+			switch t {
+			case "integer":
+				if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+					return json.Number(strconv.FormatInt(n, 10)), true
+				}
+			case "number":
+				if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+					return json.Number(s), true
+				}
+			case "boolean":
+				if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+					return strings.EqualFold(s, "true"), true
+				}
+			case "array", "object":
+				if parsed, err := decodeFirst(s); err == nil && hasType(parsed, t) {
+					return parsed, true
+				}
+			}
+		case json.Number:
+			switch t {
+			case "integer":
+				// 5.0 is an integer.
+				if f, err := x.Float64(); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+					return json.Number(strconv.FormatInt(int64(f), 10)), true
+				}
+			case "string":
+				return x.String(), true
+			}
+		case bool:
+			if t == "string" {
+				return strconv.FormatBool(x), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func describe(value any) string {
+	switch x := value.(type) {
+	case string:
+		if len(x) > 40 {
+			x = x[:40] + "..."
+		}
+		return fmt.Sprintf("string %q", x)
+	case json.Number:
+		return "number " + x.String()
+	case bool:
+		return fmt.Sprintf("boolean %t", x)
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// stringList reads a schema keyword that is a string or a list of them,
+// as written in Go or decoded from JSON.
+func stringList(value any) []string {
+	switch x := value.(type) {
+	case string:
+		return []string{x}
+	case []string:
+		return x
+	case []any:
+		list := make([]string, 0, len(x))
+		for _, item := range x {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func anyList(value any) []any {
+	switch x := value.(type) {
+	case []any:
+		return x
+	case []string:
+		list := make([]any, len(x))
+		for i, s := range x {
+			list[i] = s
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const errTruncated = "arguments were cut off before the end of the JSON object, send them again in full"
+
+func TestValidateInput(t *testing.T) {
+	t.Parallel()
+
+	info := ToolInfo{
+		Name: "test",
+		Parameters: map[string]any{
+			"path":   map[string]any{"type": "string"},
+			"limit":  map[string]any{"type": "integer"},
+			"ratio":  map[string]any{"type": "number"},
+			"force":  map[string]any{"type": "boolean"},
+			"format": map[string]any{"type": "string", "enum": []string{"text", "html"}},
+			"edits": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"old": map[string]any{"type": "string"}},
+					"required":   []string{"old"},
+				},
+			},
+		},
+		Required: []string{"path"},
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		fields []FieldError
+	}{
+		{name: "valid", input: `{"path":"a.go","limit":10}`, want: `{"limit":10,"path":"a.go"}`},
+		{name: "trailing garbage", input: `{"path":"a.go"}}` + "\n```", want: `{"path":"a.go"}`},
+		{name: "code block", input: "```json\n{\"path\":\"a.go\"}\n```", want: `{"path":"a.go"}`},
+		{name: "truncated", input: `{"path":"a.go","edits":[{"old":"x\"y`, fields: []FieldError{{Message: errTruncated}}},
+		{name: "truncated after comma", input: `{"path":"a.go", `, fields: []FieldError{{Message: errTruncated}}},
+		{name: "stringified values", input: `{"path":"a.go","limit":"20","ratio":" 0.5","force":"True","edits":"[{\"old\":\"x\"}]"}`, want: `{"edits":[{"old":"x"}],"force":true,"limit":20,"path":"a.go","ratio":0.5}`},
+		{name: "integral float", input: `{"path":"a.go","limit":5.0}`, want: `{"limit":5,"path":"a.go"}`},
+		{name: "number as string", input: `{"path":12}`, want: `{"path":"12"}`},
+		{name: "optional null", input: `{"path":"a.go","limit":null}`, want: `{"path":"a.go"}`},
+		{name: "unknown field kept", input: `{"path":"a.go","extra":1}`, want: `{"extra":1,"path":"a.go"}`},
+		{
+			name:  "wrong fields",
+			input: `{"limit":"ten","ratio":"NaN","format":"pdf","edits":[{"old":{}},{}]}`,
+			fields: []FieldError{
+				{Field: "path", Message: "is required"},
+				{Field: "edits[0].old", Message: "expected string, got object"},
+				{Field: "edits[1].old", Message: "is required"},
+				{Field: "format", Message: "must be one of [text html], got string \"pdf\""},
+				{Field: "limit", Message: "expected integer, got string \"ten\""},
+				{Field: "ratio", Message: "expected number, got string \"NaN\""},
+			},
+		},
+		{name: "not an object", input: `["a.go"]`, fields: []FieldError{{Message: "arguments must be a JSON object, got array"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ValidateInput(info, tt.input)
+			if tt.fields == nil {
+				require.NoError(t, err)
+				require.JSONEq(t, tt.want, got)
+				return
+			}
+			var inputErr *InputError
+			require.True(t, errors.As(err, &inputErr))
+			require.Equal(t, "test", inputErr.Tool)
+			require.Equal(t, tt.fields, inputErr.Fields)
+		})
+	}
+}
+
+func TestValidateInputTruncatedWrite(t *testing.T) {
+	t.Parallel()
+
+	info := NewWriteTool(nil, nil, nil, t.TempDir()).Info()
+	_, err := ValidateInput(info, `{"file_path":"main.go","content":"package main\n\nfunc main() {\n\tos.Rem`)
+	var inputErr *InputError
+	require.True(t, errors.As(err, &inputErr), "a write cut off mid-content must not run")
+	require.Equal(t, WriteToolName, inputErr.Tool)
+	require.Equal(t, []FieldError{{Message: errTruncated}}, inputErr.Fields)
+}
@@ -95,8 +95,10 @@ func (v *viewTool) Name() string {
 
 func (v *viewTool) Info() ToolInfo {
 	return ToolInfo{
-		Name:        ViewToolName,
-		Description: viewDescription,
+		Name:         ViewToolName,
+		Description:  viewDescription,
+		ReadOnly:     true,
+		OutputTokens: 10000,
 		Parameters: map[string]any{
 			"file_path": map[string]any{
 				"type":        "string",
@@ -115,6 +117,16 @@ func (v *viewTool) Info() ToolInfo {
 	}
 }
 
+// ReadOnly implements ReadOnlyCaller: reading outside the working directory
+// asks for a permission.
+func (v *viewTool) ReadOnly(input string) bool {
+	var params ViewParams
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		return true
+	}
+	return !outsideWorkingDir(v.workingDir, params.FilePath)
+}
+
 // Run implements Tool.
 func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error) {
 	var params ViewParams
@@ -224,7 +236,7 @@ func (v *viewTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error)
 	}
 
 	// Read the file content
-	content, lineCount, err := readTextFile(filePath, params.Offset, params.Limit)
+	content, lineCount, err := readTextFile(ctx, filePath, params.Offset, params.Limit)
 	isValidUt8 := utf8.ValidString(content)
 	if !isValidUt8 {
 		return NewTextErrorResponse("File content is not valid UTF-8"), nil
@@ -280,8 +292,8 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
-func readTextFile(filePath string, offset, limit int) (string, int, error) {
-	file, err := os.Open(filePath)
+func readTextFile(ctx context.Context, filePath string, offset, limit int) (string, int, error) {
+	file, err := openFile(ctx, filePath)
 	if err != nil {
 		return "", 0, err
 	}
@@ -299,13 +311,6 @@ func readTextFile(filePath string, offset, limit int) (string, int, error) {
 		}
 	}
 
-	if offset == 0 {
-		_, err = file.Seek(0, io.SeekStart)
-		if err != nil {
-			return "", 0, err
-		}
-	}
-
 	// Pre-allocate slice with expected capacity
 	lines := make([]string, 0, limit)
 	lineCount = offset
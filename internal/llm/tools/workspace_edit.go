@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/diff"
+	"github.com/JyotirmoyDas05/openpilot/internal/fsext"
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/util"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+)
+
+// WorkspaceEditFile describes the change a workspace edit makes to one file.
+type WorkspaceEditFile struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+type WorkspaceEditPermissionsParams struct {
+	Files []WorkspaceEditFile `json:"files"`
+	Diff  string              `json:"diff"`
+}
+
+type WorkspaceEditResponseMetadata struct {
+	Files     []WorkspaceEditFile `json:"files"`
+	Additions int                 `json:"additions"`
+	Removals  int                 `json:"removals"`
+}
+
+// workspaceEditor applies LSP workspace edits the same way the edit tools
+// apply their changes: behind a permission prompt and with every file
+// versioned in the history service.
+type workspaceEditor struct {
+	lspClients  map[string]*lsp.Client
+	permissions permission.Service
+	files       history.Service
+	workingDir  string
+}
+
+// prepare computes the new content of every file touched by the edit
+// without writing anything to disk.
+func (w workspaceEditor) prepare(ctx context.Context, edit protocol.WorkspaceEdit) ([]WorkspaceEditFile, error) {
+	var order []string
+	edits := make(map[string][][]protocol.TextEdit)
+	add := func(uri protocol.DocumentURI, textEdits []protocol.TextEdit) error {
+		path, err := uri.Path()
+		if err != nil {
+			return fmt.Errorf("invalid URI in workspace edit: %w", err)
+		}
+		if _, ok := edits[path]; !ok {
+			order = append(order, path)
+		}
+		edits[path] = append(edits[path], textEdits)
+		return nil
+	}
+
+	// Servers that send documentChanges may send changes too, for clients
+	// that don't support the former, so only one of them is applied.
+	if len(edit.DocumentChanges) == 0 {
+		for uri, textEdits := range edit.Changes {
+			if err := add(uri, textEdits); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			return nil, fmt.Errorf("workspace edit creates, renames or deletes files, which is not supported")
+		}
+		textEdits := make([]protocol.TextEdit, 0, len(change.TextDocumentEdit.Edits))
+		for _, e := range change.TextDocumentEdit.Edits {
+			textEdit, err := e.AsTextEdit()
+			if err != nil {
+				return nil, fmt.Errorf("invalid edit type: %w", err)
+			}
+			textEdits = append(textEdits, textEdit)
+		}
+		if err := add(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(order)
+
+	files := make([]WorkspaceEditFile, 0, len(order))
+	for _, path := range order {
+		content, err := readFile(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		oldContent := string(content)
+		newContent := oldContent
+		for _, textEdits := range edits[path] {
+			newContent, err = util.ApplyTextEditsToString(newContent, util.ByteOffsetEdits(newContent, textEdits))
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply edits to %s: %w", path, err)
+			}
+		}
+		if newContent == oldContent {
+			continue
+		}
+		oldUnix, _ := fsext.ToUnixLineEndings(oldContent)
+		newUnix, _ := fsext.ToUnixLineEndings(newContent)
+		_, additions, removals := diff.GenerateDiff(oldUnix, newUnix, strings.TrimPrefix(path, w.workingDir))
+		files = append(files, WorkspaceEditFile{
+			FilePath:   path,
+			OldContent: oldUnix,
+			NewContent: newUnix,
+			Additions:  additions,
+			Removals:   removals,
+		})
+	}
+	return files, nil
+}
+
+// apply asks for permission to write the prepared files, writes them,
+// records their history and notifies the language servers.
+func (w workspaceEditor) apply(ctx context.Context, call ToolCall, toolName, description string, edit protocol.WorkspaceEdit) (ToolResponse, error) {
+	files, err := w.prepare(ctx, edit)
+	if err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+	if len(files) == 0 {
+		return NewTextErrorResponse("the language server returned no changes"), nil
+	}
+
+	sessionID, messageID := GetContextValues(ctx)
+	if sessionID == "" || messageID == "" {
+		return ToolResponse{}, fmt.Errorf("session ID and message ID are required for applying a workspace edit")
+	}
+
+	var combinedDiff strings.Builder
+	for _, file := range files {
+		fileDiff, _, _ := diff.GenerateDiff(file.OldContent, file.NewContent, strings.TrimPrefix(file.FilePath, w.workingDir))
+		combinedDiff.WriteString(fileDiff)
+	}
+
+	p := w.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        fsext.PathOrPrefix(files[0].FilePath, w.workingDir),
+			ToolCallID:  call.ID,
+			ToolName:    toolName,
+			Action:      "write",
+			Description: fmt.Sprintf("%s (%d files)", description, len(files)),
+			Params: WorkspaceEditPermissionsParams{
+				Files: files,
+				Diff:  combinedDiff.String(),
+			},
+		},
+	)
+	if !p {
+		return ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := w.write(ctx, sessionID, files); err != nil {
+		return NewTextErrorResponse(err.Error()), nil
+	}
+
+	var additions, removals int
+	var summary strings.Builder
+	for _, file := range files {
+		additions += file.Additions
+		removals += file.Removals
+		fmt.Fprintf(&summary, "%s (+%d -%d)\n", file.FilePath, file.Additions, file.Removals)
+	}
+
+	waitForLspDiagnostics(ctx, files[0].FilePath, w.lspClients)
+	text := fmt.Sprintf("<result>\n%s: updated %d files\n%s</result>\n", description, len(files), summary.String())
+	text += getDiagnostics(files[0].FilePath, w.lspClients)
+
+	return WithResponseMetadata(
+		NewTextResponse(text),
+		WorkspaceEditResponseMetadata{
+			Files:     files,
+			Additions: additions,
+			Removals:  removals,
+		}), nil
+}
+
+// write writes the prepared files, or none of them: it fails when a file
+// changed since the edit was prepared, and puts back the files it wrote when
+// writing one fails. Then it records the history of the files and notifies
+// the language servers.
+func (w workspaceEditor) write(ctx context.Context, sessionID string, files []WorkspaceEditFile) error {
+	originals := make([][]byte, len(files))
+	for i, file := range files {
+		content, err := readFile(ctx, file.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", file.FilePath, err)
+		}
+		if unix, _ := fsext.ToUnixLineEndings(string(content)); unix != file.OldContent {
+			return fmt.Errorf("file %s was modified since the edit was computed, run the tool again", file.FilePath)
+		}
+		originals[i] = content
+	}
+
+	for i, file := range files {
+		newContent := file.NewContent
+		if _, isCrlf := fsext.ToUnixLineEndings(string(originals[i])); isCrlf {
+			newContent, _ = fsext.ToWindowsLineEndings(newContent)
+		}
+		if err := writeFile(ctx, file.FilePath, []byte(newContent)); err != nil {
+			for j := range i {
+				if err := writeFile(ctx, files[j].FilePath, originals[j]); err != nil {
+					slog.Error("Failed to restore file after a failed workspace edit", "file", files[j].FilePath, "error", err)
+				}
+			}
+			return fmt.Errorf("failed to write file %s: %w", file.FilePath, err)
+		}
+	}
+
+	for _, file := range files {
+		w.record(ctx, sessionID, file)
+	}
+	return nil
+}
+
+// record adds the versions of a written file to its history and tells the
+// language servers that have it open.
+func (w workspaceEditor) record(ctx context.Context, sessionID string, file WorkspaceEditFile) {
+	// Check if file exists in history
+	historyFile, err := w.files.GetByPathAndSession(ctx, file.FilePath, sessionID)
+	if err != nil {
+		_, err = w.files.Create(ctx, sessionID, file.FilePath, file.OldContent)
+		if err != nil {
+			slog.Error("Error creating file history", "file", file.FilePath, "error", err)
+		}
+	}
+	if historyFile.Content != file.OldContent {
+		// User Manually changed the content store an intermediate version
+		_, err = w.files.CreateVersion(ctx, sessionID, file.FilePath, file.OldContent)
+		if err != nil {
+			slog.Debug("Error creating file history version", "error", err)
+		}
+	}
+	// Store the new version
+	_, err = w.files.CreateVersion(ctx, sessionID, file.FilePath, file.NewContent)
+	if err != nil {
+		slog.Debug("Error creating file history version", "error", err)
+	}
+
+	// Only refresh the read time of files the agent has already read, so the
+	// edit tools still require a view of files it has never seen.
+	wasRead := !getLastReadTime(file.FilePath).IsZero()
+	recordFileWrite(file.FilePath)
+	if wasRead {
+		recordFileRead(file.FilePath)
+	}
+
+	for _, client := range w.lspClients {
+		if client.IsFileOpen(file.FilePath) {
+			_ = client.NotifyChange(ctx, file.FilePath)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceEditorPrepare(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("package x\n\nfunc Old() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("package x\r\n\r\nvar _, _ = \"é😀\", Old\r\n"), 0o644))
+
+	editor := workspaceEditor{workingDir: dir}
+	files, err := editor.prepare(t.Context(), protocol.WorkspaceEdit{
+		// Changes duplicate the document changes for older clients.
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(a): {renameEdit(2, 5)},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			documentEdit(a, renameEdit(2, 5)),
+			// Characters count UTF-16 code units: é is one and 😀 two.
+			documentEdit(b, renameEdit(2, 18)),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	require.Equal(t, a, files[0].FilePath)
+	require.Equal(t, "package x\n\nfunc New() {}\n", files[0].NewContent)
+	require.Equal(t, 1, files[0].Additions)
+	require.Equal(t, 1, files[0].Removals)
+
+	require.Equal(t, b, files[1].FilePath)
+	require.Equal(t, "package x\n\nvar _, _ = \"é😀\", Old\n", files[1].OldContent, "contents are normalized to unix line endings")
+	require.Equal(t, "package x\n\nvar _, _ = \"é😀\", New\n", files[1].NewContent)
+
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "package x\n\nfunc Old() {}\n", string(content), "prepare must not write to disk")
+
+	// Without document changes, the changes are applied.
+	files, err = editor.prepare(t.Context(), protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(a): {renameEdit(2, 5)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "package x\n\nfunc New() {}\n", files[0].NewContent)
+}
+
+func TestWorkspaceEditorWrite(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "test"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	editor := workspaceEditor{files: history.NewService(q, conn, dir), workingDir: dir}
+	prepare := func() []WorkspaceEditFile {
+		require.NoError(t, os.WriteFile(a, []byte("package x\n\nfunc Old() {}\n"), 0o644))
+		require.NoError(t, os.WriteFile(b, []byte("package x\n\nvar _ = Old\n"), 0o644))
+		files, err := editor.prepare(ctx, protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{
+				documentEdit(a, renameEdit(2, 5)),
+				documentEdit(b, renameEdit(2, 8)),
+			},
+		})
+		require.NoError(t, err)
+		return files
+	}
+	requireContent := func(path, want string) {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, want, string(content))
+	}
+
+	// A file changed since the edit was prepared fails the edit before
+	// anything is written.
+	files := prepare()
+	require.NoError(t, os.WriteFile(b, []byte("package x\n\nvar _ = Old // changed\n"), 0o644))
+	require.ErrorContains(t, editor.write(ctx, "session", files), "was modified")
+	requireContent(a, "package x\n\nfunc Old() {}\n")
+
+	// When writing a file fails, the files written before are put back.
+	files = prepare()
+	failing := WithFileSystem(ctx, failingFileSystem{path: b})
+	require.ErrorContains(t, editor.write(failing, "session", files), "failed to write file")
+	requireContent(a, "package x\n\nfunc Old() {}\n")
+	requireContent(b, "package x\n\nvar _ = Old\n")
+
+	files = prepare()
+	require.NoError(t, editor.write(ctx, "session", files))
+	requireContent(a, "package x\n\nfunc New() {}\n")
+	requireContent(b, "package x\n\nvar _ = New\n")
+}
+
+// failingFileSystem fails to write one file.
+type failingFileSystem struct {
+	path string
+}
+
+func (f failingFileSystem) ReadFile(_ context.Context, path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (f failingFileSystem) WriteFile(_ context.Context, path string, data []byte) error {
+	if path == f.path {
+		return errors.New("disk full")
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func renameEdit(line, char uint32) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: char},
+			End:   protocol.Position{Line: line, Character: char + 3},
+		},
+		NewText: "New",
+	}
+}
+
+func documentEdit(path string, edit protocol.TextEdit) protocol.DocumentChange {
+	return protocol.DocumentChange{TextDocumentEdit: &protocol.TextDocumentEdit{
+		TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(path)},
+		},
+		Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: edit}},
+	}}
+}
+
+func TestWorkspaceEditorPrepareRejectsResourceOperations(t *testing.T) {
+	t.Parallel()
+
+	editor := workspaceEditor{workingDir: t.TempDir()}
+	_, err := editor.prepare(t.Context(), protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{CreateFile: &protocol.CreateFile{URI: protocol.URIFromPath(filepath.Join(t.TempDir(), "new.go"))}},
+		},
+	})
+	require.ErrorContains(t, err, "not supported")
+}
+
+func TestMatchCodeAction(t *testing.T) {
+	t.Parallel()
+
+	candidates := []codeActionCandidate{
+		{action: protocol.CodeAction{Title: "Organize imports"}},
+		{action: protocol.CodeAction{Title: "Extract function"}},
+		{action: protocol.CodeAction{Title: "Extract variable"}},
+	}
+
+	match, err := matchCodeAction(candidates, "Extract function")
+	require.NoError(t, err)
+	require.Equal(t, "Extract function", match.action.Title)
+
+	match, err = matchCodeAction(candidates, "organize")
+	require.NoError(t, err)
+	require.Equal(t, "Organize imports", match.action.Title)
+
+	_, err = matchCodeAction(candidates, "extract")
+	require.ErrorContains(t, err, "matches several code actions")
+
+	_, err = matchCodeAction(candidates, "inline")
+	require.ErrorContains(t, err, "no code action matches")
+}
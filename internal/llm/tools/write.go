@@ -128,6 +128,8 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		filePath = filepath.Join(w.workingDir, filePath)
 	}
 
+	params.Content = formatWithLSP(ctx, w.lspClients, filePath, params.Content)
+
 	fileInfo, err := os.Stat(filePath)
 	if err == nil {
 		if fileInfo.IsDir() {
@@ -141,7 +143,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 				filePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339))), nil
 		}
 
-		oldContent, readErr := os.ReadFile(filePath)
+		oldContent, readErr := readFile(ctx, filePath)
 		if readErr == nil && string(oldContent) == params.Content {
 			return NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
 		}
@@ -156,7 +158,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 
 	oldContent := ""
 	if fileInfo != nil && !fileInfo.IsDir() {
-		oldBytes, readErr := os.ReadFile(filePath)
+		oldBytes, readErr := readFile(ctx, filePath)
 		if readErr == nil {
 			oldContent = string(oldBytes)
 		}
@@ -192,7 +194,7 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 		return ToolResponse{}, permission.ErrorPermissionDenied
 	}
 
-	err = os.WriteFile(filePath, []byte(params.Content), 0o644)
+	err = writeFile(ctx, filePath, []byte(params.Content))
 	if err != nil {
 		return ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 	}
@@ -200,7 +202,11 @@ func (w *writeTool) Run(ctx context.Context, call ToolCall) (ToolResponse, error
 	// Check if file exists in history
 	file, err := w.files.GetByPathAndSession(ctx, filePath, sessionID)
 	if err != nil {
-		_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		if fileInfo == nil {
+			_, err = w.files.CreateNew(ctx, sessionID, filePath)
+		} else {
+			_, err = w.files.Create(ctx, sessionID, filePath, oldContent)
+		}
 		if err != nil {
 			// Log error but don't fail the operation
 			return ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -20,6 +20,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/config"
 	"github.com/JyotirmoyDas05/openpilot/internal/log"
 	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/util"
 )
 
 type Client struct {
@@ -34,6 +35,9 @@ type Client struct {
 	// File types this LSP server handles (e.g., .go, .rs, .py)
 	fileTypes []string
 
+	// Whether files should be formatted after the agent writes them
+	formatOnWrite bool
+
 	// Diagnostic change callback
 	onDiagnosticsChanged func(name string, count int)
 
@@ -90,6 +94,7 @@ func NewClient(ctx context.Context, name string, config config.LSPConfig) (*Clie
 		Cmd:                   cmd,
 		name:                  name,
 		fileTypes:             config.FileTypes,
+		formatOnWrite:         config.FormatOnWrite,
 		stdin:                 stdin,
 		stdout:                bufio.NewReader(stdout),
 		stderr:                stderr,
@@ -183,13 +188,33 @@ func (c *Client) InitializeLSPClient(ctx context.Context, workspaceDir string) (
 					CodeLens: &protocol.CodeLensClientCapabilities{
 						DynamicRegistration: true,
 					},
-					DocumentSymbol: protocol.DocumentSymbolClientCapabilities{},
+					DocumentSymbol: protocol.DocumentSymbolClientCapabilities{
+						HierarchicalDocumentSymbolSupport: true,
+					},
 					CodeAction: protocol.CodeActionClientCapabilities{
 						CodeActionLiteralSupport: protocol.ClientCodeActionLiteralOptions{
 							CodeActionKind: protocol.ClientCodeActionKindOptions{
-								ValueSet: []protocol.CodeActionKind{},
+								ValueSet: []protocol.CodeActionKind{
+									protocol.QuickFix,
+									protocol.Refactor,
+									protocol.RefactorExtract,
+									protocol.RefactorInline,
+									protocol.RefactorRewrite,
+									protocol.Source,
+									protocol.SourceOrganizeImports,
+									protocol.SourceFixAll,
+								},
 							},
 						},
+						IsPreferredSupport: true,
+						DisabledSupport:    true,
+						DataSupport:        true,
+						ResolveSupport: &protocol.ClientCodeActionResolveOptions{
+							Properties: []string{"edit"},
+						},
+					},
+					Rename: &protocol.RenameClientCapabilities{
+						PrepareSupport: true,
 					},
 					PublishDiagnostics: protocol.PublishDiagnosticsClientCapabilities{
 						VersionSupport: true,
@@ -683,13 +708,19 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 }
 
 func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
-	uri := string(protocol.URIFromPath(filepath))
-
 	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("error reading file: %w", err)
 	}
 
+	return c.notifyChangeContent(ctx, filepath, string(content))
+}
+
+// notifyChangeContent sends the given content as the new text of an open
+// file, regardless of what is on disk.
+func (c *Client) notifyChangeContent(ctx context.Context, filepath string, content string) error {
+	uri := string(protocol.URIFromPath(filepath))
+
 	c.openFilesMu.Lock()
 	fileInfo, isOpen := c.openFiles[uri]
 	if !isOpen {
@@ -712,7 +743,7 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		ContentChanges: []protocol.TextDocumentContentChangeEvent{
 			{
 				Value: protocol.TextDocumentContentChangeWholeDocument{
-					Text: string(content),
+					Text: content,
 				},
 			},
 		},
@@ -721,6 +752,119 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 	return c.Notify(ctx, "textDocument/didChange", params)
 }
 
+// FormatsOnWrite reports whether files handled by this client should be
+// formatted after the agent writes them.
+func (c *Client) FormatsOnWrite() bool {
+	return c.formatOnWrite
+}
+
+// FormatContent asks the server to format content as if it were the text of
+// filepath and returns the formatted result. The server's view of the file
+// is restored to what is on disk afterwards, so this can be used before the
+// content is written. The content is indented the way the file already is.
+func (c *Client) FormatContent(ctx context.Context, filepath string, content string) (string, error) {
+	uri := protocol.URIFromPath(filepath)
+
+	if c.IsFileOpen(filepath) {
+		if err := c.notifyChangeContent(ctx, filepath, content); err != nil {
+			return "", err
+		}
+		defer func() {
+			if err := c.NotifyChange(ctx, filepath); err != nil {
+				slog.Debug("Failed to restore file contents after formatting", "file", filepath, "error", err)
+			}
+		}()
+	} else {
+		err := c.Notify(ctx, "textDocument/didOpen", protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{
+				URI:        uri,
+				LanguageID: DetectLanguageID(string(uri)),
+				Version:    1,
+				Text:       content,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			err := c.Notify(ctx, "textDocument/didClose", protocol.DidCloseTextDocumentParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			})
+			if err != nil {
+				slog.Debug("Failed to close file after formatting", "file", filepath, "error", err)
+			}
+		}()
+	}
+
+	edits, err := c.Formatting(ctx, protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Options:      formattingOptions(filepath, content),
+	})
+	if err != nil {
+		return "", fmt.Errorf("formatting failed: %w", err)
+	}
+	if len(edits) == 0 {
+		return content, nil
+	}
+	return util.ApplyTextEditsToString(content, util.ByteOffsetEdits(content, edits))
+}
+
+// formattingOptions returns the options to format content with: the
+// indentation of the file on disk, or of content when the file is new or not
+// indented, and four spaces otherwise.
+func formattingOptions(path, content string) protocol.FormattingOptions {
+	if existing, err := os.ReadFile(path); err == nil {
+		if options, ok := detectIndentation(string(existing)); ok {
+			return options
+		}
+	}
+	if options, ok := detectIndentation(content); ok {
+		return options
+	}
+	return protocol.FormattingOptions{TabSize: 4, InsertSpaces: true}
+}
+
+// detectIndentation tells whether text is mostly indented with tabs or with
+// spaces, and for spaces the most common step between a line and a more
+// indented one that follows it.
+func detectIndentation(text string) (protocol.FormattingOptions, bool) {
+	var tabs, spaces, previous int
+	steps := make(map[int]int)
+	for line := range strings.Lines(text) {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "*") {
+			// Blank lines and the aligned stars of block comments.
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		switch {
+		case indent == "":
+			previous = 0
+		case indent[0] == '\t':
+			tabs++
+		case !strings.Contains(indent, "\t"):
+			spaces++
+			if len(indent) > previous {
+				steps[len(indent)-previous]++
+			}
+			previous = len(indent)
+		}
+	}
+	if tabs == 0 && spaces == 0 {
+		return protocol.FormattingOptions{}, false
+	}
+	if tabs > spaces {
+		return protocol.FormattingOptions{TabSize: 4, InsertSpaces: false}, true
+	}
+	size, count := 4, 0
+	for step, n := range steps {
+		if step <= 8 && (n > count || n == count && step < size) {
+			size, count = step, n
+		}
+	}
+	return protocol.FormattingOptions{TabSize: uint32(size), InsertSpaces: true}, true
+}
+
 func (c *Client) CloseFile(ctx context.Context, filepath string) error {
 	cfg := config.Get()
 	uri := string(protocol.URIFromPath(filepath))
@@ -1,8 +1,16 @@
 package lsp
 
 import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -91,3 +99,174 @@ func TestHandlesFile(t *testing.T) {
 		})
 	}
 }
+
+// newFakeServerClient returns a client talking to a fake server, which
+// answers every request with the result of respond.
+func newFakeServerClient(t *testing.T, respond func(method string, params json.RawMessage) any) *Client {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	t.Cleanup(func() {
+		clientWriter.Close()
+		serverWriter.Close()
+	})
+
+	client := &Client{
+		name:                  "fake",
+		stdin:                 clientWriter,
+		stdout:                bufio.NewReader(clientReader),
+		handlers:              make(map[int32]chan *Message),
+		notificationHandlers:  make(map[string]NotificationHandler),
+		serverRequestHandlers: make(map[string]ServerRequestHandler),
+		diagnostics:           make(map[protocol.DocumentURI][]protocol.Diagnostic),
+		openFiles:             make(map[string]*OpenFileInfo),
+	}
+	go client.handleMessages()
+
+	go func() {
+		r := bufio.NewReader(serverReader)
+		for {
+			msg, err := ReadMessage(r)
+			if err != nil {
+				return
+			}
+			if msg.ID == 0 {
+				continue
+			}
+			result, err := json.Marshal(respond(msg.Method, msg.Params))
+			if err != nil {
+				return
+			}
+			if err := WriteMessage(serverWriter, &Message{JSONRPC: "2.0", ID: msg.ID, Result: result}); err != nil {
+				return
+			}
+		}
+	}()
+	return client
+}
+
+func TestFormatContent(t *testing.T) {
+	t.Parallel()
+
+	// The fake server indents the second line by one level with the
+	// options it is given.
+	client := newFakeServerClient(t, func(method string, params json.RawMessage) any {
+		var p protocol.DocumentFormattingParams
+		if method != "textDocument/formatting" || json.Unmarshal(params, &p) != nil {
+			return nil
+		}
+		indent := strings.Repeat(" ", int(p.Options.TabSize))
+		if !p.Options.InsertSpaces {
+			indent = "\t"
+		}
+		return []protocol.TextEdit{{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: 0},
+				End:   protocol.Position{Line: 1, Character: 0},
+			},
+			NewText: indent,
+		}}
+	})
+
+	dir := t.TempDir()
+	twoSpaces := filepath.Join(dir, "two.js")
+	require.NoError(t, os.WriteFile(twoSpaces, []byte("if (a) {\n  if (b) {\n    c()\n  }\n}\n"), 0o644))
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "follows the file on disk",
+			path:     twoSpaces,
+			content:  "f() {\ng()\n}\n",
+			expected: "f() {\n  g()\n}\n",
+		},
+		{
+			name:     "follows the content of a new file",
+			path:     filepath.Join(dir, "tabs.go"),
+			content:  "func f() {\ng()\n\tif a {\n\t\th()\n\t}\n}\n",
+			expected: "func f() {\n\tg()\n\tif a {\n\t\th()\n\t}\n}\n",
+		},
+		{
+			name:     "defaults to four spaces",
+			path:     filepath.Join(dir, "flat.js"),
+			content:  "f() {\ng()\n}\n",
+			expected: "f() {\n    g()\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := client.FormatContent(t.Context(), tt.path, tt.content)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, formatted)
+		})
+	}
+}
+
+func TestDetectIndentation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		text     string
+		expected protocol.FormattingOptions
+		ok       bool
+	}{
+		{
+			name: "no indentation",
+			text: "a\nb\n",
+		},
+		{
+			name:     "tabs",
+			text:     "a\n\tb\n\t\tc\n",
+			expected: protocol.FormattingOptions{TabSize: 4, InsertSpaces: false},
+			ok:       true,
+		},
+		{
+			name:     "two spaces",
+			text:     "a\n  b\n    c\n  d\n    e\n",
+			expected: protocol.FormattingOptions{TabSize: 2, InsertSpaces: true},
+			ok:       true,
+		},
+		{
+			name:     "block comments are ignored",
+			text:     "/**\n * a\n * b\n */\nf {\n    g\n}\n",
+			expected: protocol.FormattingOptions{TabSize: 4, InsertSpaces: true},
+			ok:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, ok := detectIndentation(tt.text)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, options)
+		})
+	}
+}
+
+func TestFormatContentUTF16(t *testing.T) {
+	t.Parallel()
+
+	// "😀" is two UTF-16 code units and four bytes long, so the spaces after
+	// "b" start at character 4 of the line.
+	client := newFakeServerClient(t, func(method string, params json.RawMessage) any {
+		if method != "textDocument/formatting" {
+			return nil
+		}
+		return []protocol.TextEdit{{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 0, Character: 4},
+				End:   protocol.Position{Line: 0, Character: 6},
+			},
+			NewText: " ",
+		}}
+	})
+
+	formatted, err := client.FormatContent(t.Context(), filepath.Join(t.TempDir(), "a.js"), "a😀b  c\n")
+	require.NoError(t, err)
+	require.Equal(t, "a😀b c\n", formatted)
+}
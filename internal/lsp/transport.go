@@ -18,9 +18,8 @@ func WriteMessage(w io.Writer, msg *Message) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
-	cfg := config.Get()
 
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Sending message to server", "method", msg.Method, "id", msg.ID)
 	}
 
@@ -39,7 +38,6 @@ func WriteMessage(w io.Writer, msg *Message) error {
 
 // ReadMessage reads a single LSP message from the given reader
 func ReadMessage(r *bufio.Reader) (*Message, error) {
-	cfg := config.Get()
 	// Read headers
 	var contentLength int
 	for {
@@ -49,7 +47,7 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 		}
 		line = strings.TrimSpace(line)
 
-		if cfg.Options.DebugLSP {
+		if debugLSP() {
 			slog.Debug("Received header", "line", line)
 		}
 
@@ -65,7 +63,7 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 		}
 	}
 
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Content-Length", "length", contentLength)
 	}
 
@@ -76,7 +74,7 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 		return nil, fmt.Errorf("failed to read content: %w", err)
 	}
 
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Received content", "content", string(content))
 	}
 
@@ -91,11 +89,10 @@ func ReadMessage(r *bufio.Reader) (*Message, error) {
 
 // handleMessages reads and dispatches messages in a loop
 func (c *Client) handleMessages() {
-	cfg := config.Get()
 	for {
 		msg, err := ReadMessage(c.stdout)
 		if err != nil {
-			if cfg.Options.DebugLSP {
+			if debugLSP() {
 				slog.Error("Error reading message", "error", err)
 			}
 			return
@@ -103,7 +100,7 @@ func (c *Client) handleMessages() {
 
 		// Handle server->client request (has both Method and ID)
 		if msg.Method != "" && msg.ID != 0 {
-			if cfg.Options.DebugLSP {
+			if debugLSP() {
 				slog.Debug("Received request from server", "method", msg.Method, "id", msg.ID)
 			}
 
@@ -157,11 +154,11 @@ func (c *Client) handleMessages() {
 			c.notificationMu.RUnlock()
 
 			if ok {
-				if cfg.Options.DebugLSP {
+				if debugLSP() {
 					slog.Debug("Handling notification", "method", msg.Method)
 				}
 				go handler(msg.Params)
-			} else if cfg.Options.DebugLSP {
+			} else if debugLSP() {
 				slog.Debug("No handler for notification", "method", msg.Method)
 			}
 			continue
@@ -174,12 +171,12 @@ func (c *Client) handleMessages() {
 			c.handlersMu.RUnlock()
 
 			if ok {
-				if cfg.Options.DebugLSP {
+				if debugLSP() {
 					slog.Debug("Received response for request", "id", msg.ID)
 				}
 				ch <- msg
 				close(ch)
-			} else if cfg.Options.DebugLSP {
+			} else if debugLSP() {
 				slog.Debug("No handler for response", "id", msg.ID)
 			}
 		}
@@ -188,10 +185,9 @@ func (c *Client) handleMessages() {
 
 // Call makes a request and waits for the response
 func (c *Client) Call(ctx context.Context, method string, params any, result any) error {
-	cfg := config.Get()
 	id := c.nextID.Add(1)
 
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Making call", "method", method, "id", id)
 	}
 
@@ -217,7 +213,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Request sent", "method", method, "id", id)
 	}
 
@@ -226,7 +222,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 	case <-ctx.Done():
 		return ctx.Err()
 	case resp := <-ch:
-		if cfg.Options.DebugLSP {
+		if debugLSP() {
 			slog.Debug("Received response", "id", id)
 		}
 
@@ -252,8 +248,7 @@ func (c *Client) Call(ctx context.Context, method string, params any, result any
 
 // Notify sends a notification (a request without an ID that doesn't expect a response)
 func (c *Client) Notify(ctx context.Context, method string, params any) error {
-	cfg := config.Get()
-	if cfg.Options.DebugLSP {
+	if debugLSP() {
 		slog.Debug("Sending notification", "method", method)
 	}
 
@@ -273,3 +268,10 @@ type (
 	NotificationHandler  func(params json.RawMessage)
 	ServerRequestHandler func(params json.RawMessage) (any, error)
 )
+
+// debugLSP reports whether to log the messages exchanged with the servers,
+// which is never the case before the config is loaded.
+func debugLSP() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.Options != nil && cfg.Options.DebugLSP
+}
@@ -1,11 +1,11 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/JyotirmoyDas05/openpilot/internal/lsp/protocol"
 )
@@ -22,25 +22,40 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := ApplyTextEditsToString(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEditsToString applies the given edits to content and returns the
+// result, preserving the original line endings.
+func ApplyTextEditsToString(content string, edits []protocol.TextEdit) (string, error) {
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -59,7 +74,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -78,11 +93,36 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	return newContent.String(), nil
+}
+
+// ByteOffsetEdits returns edits with the characters of their positions,
+// which LSP counts in UTF-16 code units, converted to the byte offsets in the
+// lines of content that ApplyTextEditsToString expects.
+func ByteOffsetEdits(content string, edits []protocol.TextEdit) []protocol.TextEdit {
+	lines := strings.Split(content, "\n")
+	converted := make([]protocol.TextEdit, len(edits))
+	for i, edit := range edits {
+		converted[i] = edit
+		converted[i].Range.Start.Character = byteOffset(lines, edit.Range.Start)
+		converted[i].Range.End.Character = byteOffset(lines, edit.Range.End)
 	}
+	return converted
+}
 
-	return nil
+func byteOffset(lines []string, pos protocol.Position) uint32 {
+	if int(pos.Line) >= len(lines) {
+		return pos.Character
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\r")
+	var units uint32
+	for i, r := range line {
+		if units >= pos.Character {
+			return uint32(i)
+		}
+		units += uint32(utf16.RuneLen(r))
+	}
+	return uint32(len(line))
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -122,6 +122,9 @@ type Message struct {
 	Provider  string
 	CreatedAt int64
 	UpdatedAt int64
+	// CreatedAtMs orders the message against other records created in the
+	// same second.
+	CreatedAtMs int64
 }
 
 func (m *Message) Content() TextContent {
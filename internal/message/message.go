@@ -22,11 +22,17 @@ type CreateMessageParams struct {
 type Service interface {
 	pubsub.Suscriber[Message]
 	Create(ctx context.Context, sessionID string, params CreateMessageParams) (Message, error)
+	// Copy adds a copy of the message to the given session, keeping its
+	// timestamps so copied histories stay in order.
+	Copy(ctx context.Context, sessionID string, message Message) (Message, error)
 	Update(ctx context.Context, message Message) error
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// Search finds the messages whose text matches every word of the query,
+	// best matches first.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
 }
 
 type service struct {
@@ -83,6 +89,39 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 	return message, nil
 }
 
+func (s *service) Copy(ctx context.Context, sessionID string, message Message) (Message, error) {
+	partsJSON, err := marshallParts(message.Parts)
+	if err != nil {
+		return Message{}, err
+	}
+	finishedAt := sql.NullInt64{}
+	if f := message.FinishPart(); f != nil {
+		finishedAt.Int64 = f.Time
+		finishedAt.Valid = true
+	}
+	dbMessage, err := s.q.CopyMessage(ctx, db.CopyMessageParams{
+		ID:          uuid.New().String(),
+		SessionID:   sessionID,
+		Role:        string(message.Role),
+		Parts:       string(partsJSON),
+		Model:       sql.NullString{String: message.Model, Valid: true},
+		Provider:    sql.NullString{String: message.Provider, Valid: message.Provider != ""},
+		CreatedAt:   message.CreatedAt,
+		UpdatedAt:   message.UpdatedAt,
+		FinishedAt:  finishedAt,
+		CreatedAtMs: message.CreatedAtMs,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	copied, err := s.fromDBItem(dbMessage)
+	if err != nil {
+		return Message{}, err
+	}
+	s.Publish(pubsub.CreatedEvent, copied)
+	return copied, nil
+}
+
 func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) error {
 	messages, err := s.List(ctx, sessionID)
 	if err != nil {
@@ -151,14 +190,15 @@ func (s *service) fromDBItem(item db.Message) (Message, error) {
 		return Message{}, err
 	}
 	return Message{
-		ID:        item.ID,
-		SessionID: item.SessionID,
-		Role:      MessageRole(item.Role),
-		Parts:     parts,
-		Model:     item.Model.String,
-		Provider:  item.Provider.String,
-		CreatedAt: item.CreatedAt,
-		UpdatedAt: item.UpdatedAt,
+		ID:          item.ID,
+		SessionID:   item.SessionID,
+		Role:        MessageRole(item.Role),
+		Parts:       parts,
+		Model:       item.Model.String,
+		Provider:    item.Provider.String,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+		CreatedAtMs: item.CreatedAtMs,
 	}, nil
 }
 
@@ -212,6 +252,12 @@ func marshallParts(parts []ContentPart) ([]byte, error) {
 	return json.Marshal(wrappedParts)
 }
 
+// UnmarshalParts decodes message parts in the format they are stored in the
+// database.
+func UnmarshalParts(data []byte) ([]ContentPart, error) {
+	return unmarshallParts(data)
+}
+
 func unmarshallParts(data []byte) ([]ContentPart, error) {
 	temp := []json.RawMessage{}
 
@@ -0,0 +1,58 @@
+package message
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+)
+
+type SearchResult struct {
+	MessageID    string
+	SessionID    string
+	SessionTitle string
+	Role         MessageRole
+	// Snippet is the part of the message text around the matched words.
+	Snippet   string
+	CreatedAt int64
+}
+
+func (s *service) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := s.q.SearchMessages(ctx, db.SearchMessagesParams{
+		Query:      match,
+		MaxResults: int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			MessageID:    row.ID,
+			SessionID:    row.SessionID,
+			SessionTitle: row.SessionTitle,
+			Role:         MessageRole(row.Role),
+			Snippet:      strings.Join(strings.Fields(row.Snippet), " "),
+			CreatedAt:    row.CreatedAt,
+		}
+	}
+	return results, nil
+}
+
+// ftsQuery turns free text into an FTS5 query matching every word, quoting
+// the words so punctuation isn't read as query syntax. The last word matches
+// as a prefix, so results show up while it's still being typed.
+func ftsQuery(query string) string {
+	words := strings.Fields(query)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,57 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session", Title: "Migrations"})
+	require.NoError(t, err)
+	svc := NewService(q)
+
+	msg, err := svc.Create(ctx, "session", CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: "the migration fails on startup"}},
+	})
+	require.NoError(t, err)
+
+	results, err := svc.Search(ctx, "migrations failing", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "words are stemmed")
+	require.Equal(t, msg.ID, results[0].MessageID)
+	require.Equal(t, "Migrations", results[0].SessionTitle)
+	require.Contains(t, results[0].Snippet, "startup")
+
+	results, err = svc.Search(ctx, `start "foo:`, 10)
+	require.NoError(t, err)
+	require.Empty(t, results, "every word has to match and punctuation is not query syntax")
+
+	results, err = svc.Search(ctx, "start", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "the last word matches as a prefix")
+
+	msg.Parts = []ContentPart{TextContent{Text: "the schema is out of date"}}
+	require.NoError(t, svc.Update(ctx, msg))
+	results, err = svc.Search(ctx, "migration", 10)
+	require.NoError(t, err)
+	require.Empty(t, results, "updates replace the indexed text")
+	results, err = svc.Search(ctx, "schema", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	require.NoError(t, svc.Delete(ctx, msg.ID))
+	results, err = svc.Search(ctx, "schema", 10)
+	require.NoError(t, err)
+	require.Empty(t, results, "deleted messages are removed from the index")
+}
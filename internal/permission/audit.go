@@ -0,0 +1,150 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+)
+
+// Decider is what answered a permission request.
+type Decider string
+
+const (
+	// DeciderUser is the user answering a prompt.
+	DeciderUser Decider = "user"
+	// DeciderGrant is an earlier "allow for session" or "always allow"
+	// answer of the user.
+	DeciderGrant Decider = "grant"
+	// DeciderAllowedTools is the allowed_tools list of the config.
+	DeciderAllowedTools Decider = "allowed_tools"
+	DeciderRule         Decider = "rule"
+	DeciderPolicy       Decider = "policy"
+	DeciderYolo         Decider = "yolo"
+	// DeciderAutoApprove is the approval of every request of a session,
+	// which non-interactive runs without a policy use.
+	DeciderAutoApprove Decider = "auto_approve"
+)
+
+const (
+	AuditGranted = "granted"
+	AuditDenied  = "denied"
+)
+
+// maxAuditParamsLength caps the params stored with an entry, which can hold
+// whole files.
+const maxAuditParamsLength = 500
+
+type AuditEntry struct {
+	ID         int64
+	SessionID  string
+	ToolCallID string
+	ToolName   string
+	Action     string
+	Path       string
+	// Params summarizes the params of the request: the command, paths or
+	// URL it is about, or its params as JSON.
+	Params string
+	// Decision is AuditGranted or AuditDenied.
+	Decision  string
+	Decider   Decider
+	Reason    string
+	CreatedAt int64
+}
+
+type AuditFilter struct {
+	// SessionID limits the entries to a session when it is set.
+	SessionID string
+	// Since is the Unix time of the oldest entry to return.
+	Since int64
+	Limit int64
+}
+
+// AuditLog is an append-only record of the answers to permission requests.
+type AuditLog interface {
+	Record(ctx context.Context, entry AuditEntry) error
+	// List returns the entries matching the filter, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+}
+
+type auditLog struct {
+	q db.Querier
+}
+
+func NewAuditLog(q db.Querier) AuditLog {
+	return &auditLog{q: q}
+}
+
+func (l *auditLog) Record(ctx context.Context, entry AuditEntry) error {
+	return l.q.CreatePermissionAuditEntry(ctx, db.CreatePermissionAuditEntryParams{
+		SessionID:  entry.SessionID,
+		ToolCallID: entry.ToolCallID,
+		ToolName:   entry.ToolName,
+		Action:     entry.Action,
+		Path:       entry.Path,
+		Params:     entry.Params,
+		Decision:   entry.Decision,
+		Decider:    string(entry.Decider),
+		Reason:     entry.Reason,
+	})
+}
+
+func (l *auditLog) List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var rows []db.PermissionAudit
+	var err error
+	if filter.SessionID != "" {
+		rows, err = l.q.ListSessionPermissionAudit(ctx, db.ListSessionPermissionAuditParams{
+			SessionID:  filter.SessionID,
+			Since:      filter.Since,
+			MaxResults: filter.Limit,
+		})
+	} else {
+		rows, err = l.q.ListPermissionAudit(ctx, db.ListPermissionAuditParams{
+			Since:      filter.Since,
+			MaxResults: filter.Limit,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = AuditEntry{
+			ID:         row.ID,
+			SessionID:  row.SessionID,
+			ToolCallID: row.ToolCallID,
+			ToolName:   row.ToolName,
+			Action:     row.Action,
+			Path:       row.Path,
+			Params:     row.Params,
+			Decision:   row.Decision,
+			Decider:    Decider(row.Decider),
+			Reason:     row.Reason,
+			CreatedAt:  row.CreatedAt,
+		}
+	}
+	return entries, nil
+}
+
+// summarizeParams describes the params of a request for the audit log.
+func summarizeParams(params any) string {
+	var summary string
+	if targets := requestTargets(params); len(targets) > 0 {
+		values := make([]string, len(targets))
+		for i, t := range targets {
+			values[i] = t.value
+		}
+		summary = strings.Join(values, " ")
+	} else if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return ""
+		}
+		summary = string(data)
+	}
+	if runes := []rune(summary); len(runes) > maxAuditParamsLength {
+		summary = string(runes[:maxAuditParamsLength-1]) + "…"
+	}
+	return summary
+}
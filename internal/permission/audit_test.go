@@ -0,0 +1,65 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditLog(t *testing.T) AuditLog {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewAuditLog(db.New(conn))
+}
+
+func TestPermissionService_Audit(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	audit := NewAuditLog(db.New(conn))
+
+	service := NewPermissionService("/tmp", false, nil, []Rule{{DecisionDeny, "bash(git push*)"}}, newGrantStore(t), audit)
+	events := service.Subscribe(ctx)
+
+	bash := CreatePermissionRequest{SessionID: "one", ToolCallID: "push", ToolName: "bash", Action: "execute", Params: map[string]any{"command": "git push"}}
+	require.False(t, service.Request(bash))
+
+	edit := CreatePermissionRequest{SessionID: "one", ToolCallID: "edit", ToolName: "edit", Action: "write", Path: "/tmp/a.go", Params: map[string]any{"file_path": "/tmp/a.go", "new_content": "package a"}}
+	granted := make(chan bool)
+	go func() { granted <- service.Request(edit) }()
+	service.Grant((<-events).Payload)
+	require.True(t, <-granted)
+
+	service.AutoApproveSession("two")
+	bash.SessionID, bash.ToolCallID = "two", "status"
+	bash.Params = map[string]any{"command": "git status"}
+	require.True(t, service.Request(bash))
+
+	entries, err := audit.List(ctx, AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "status", entries[0].ToolCallID, "newest first")
+	require.Equal(t, DeciderAutoApprove, entries[0].Decider)
+	require.Equal(t, AuditGranted, entries[1].Decision)
+	require.Equal(t, DeciderUser, entries[1].Decider)
+	require.Equal(t, "/tmp/a.go", entries[1].Params)
+	require.Equal(t, AuditDenied, entries[2].Decision)
+	require.Equal(t, DeciderRule, entries[2].Decider)
+	require.Contains(t, entries[2].Reason, "git push*")
+	require.Equal(t, "git push", entries[2].Params)
+
+	entries, err = audit.List(ctx, AuditFilter{SessionID: "one", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	_, err = conn.ExecContext(ctx, "DELETE FROM permission_audit")
+	require.Error(t, err, "the log is append-only")
+	_, err = conn.ExecContext(ctx, "UPDATE permission_audit SET decision = 'granted'")
+	require.Error(t, err, "the log is append-only")
+}
@@ -0,0 +1,114 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/google/uuid"
+)
+
+var ErrGrantNotFound = errors.New("permission grant not found")
+
+// Grant lets a tool perform an action in a directory without asking. It
+// doesn't extend to the directories below it. Grants are stored with the
+// project and apply to every session until they are revoked or expire.
+type Grant struct {
+	ID       string
+	ToolName string
+	Action   string
+	Path     string
+	// ExpiresAt is the Unix time the grant stops applying, or 0 when it
+	// never does.
+	ExpiresAt int64
+	CreatedAt int64
+}
+
+// Expired reports whether the grant no longer applies at the given time.
+func (g Grant) Expired(now time.Time) bool {
+	return g.ExpiresAt != 0 && g.ExpiresAt <= now.Unix()
+}
+
+func (g Grant) covers(permission PermissionRequest) bool {
+	if g.ToolName != permission.ToolName || g.Action != permission.Action {
+		return false
+	}
+	return filepath.Clean(g.Path) == filepath.Clean(permission.Path)
+}
+
+type GrantStore interface {
+	Create(ctx context.Context, permission PermissionRequest) (Grant, error)
+	// List returns the grants that haven't expired, oldest first.
+	List(ctx context.Context) ([]Grant, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type grantStore struct {
+	q      db.Querier
+	expiry time.Duration
+}
+
+// NewGrantStore creates a store whose new grants expire after the given
+// duration, or never when it is zero.
+func NewGrantStore(q db.Querier, expiry time.Duration) GrantStore {
+	return &grantStore{q: q, expiry: expiry}
+}
+
+func (s *grantStore) Create(ctx context.Context, permission PermissionRequest) (Grant, error) {
+	var expiresAt sql.NullInt64
+	if s.expiry > 0 {
+		expiresAt = sql.NullInt64{Int64: time.Now().Add(s.expiry).Unix(), Valid: true}
+	}
+	dbGrant, err := s.q.CreatePermissionGrant(ctx, db.CreatePermissionGrantParams{
+		ID:        uuid.New().String(),
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Path:      permission.Path,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return Grant{}, err
+	}
+	return fromDBGrant(dbGrant), nil
+}
+
+func (s *grantStore) List(ctx context.Context) ([]Grant, error) {
+	dbGrants, err := s.q.ListPermissionGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	grants := make([]Grant, 0, len(dbGrants))
+	for _, dbGrant := range dbGrants {
+		if g := fromDBGrant(dbGrant); !g.Expired(now) {
+			grants = append(grants, g)
+		}
+	}
+	return grants, nil
+}
+
+func (s *grantStore) Revoke(ctx context.Context, id string) error {
+	n, err := s.q.DeletePermissionGrant(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrGrantNotFound, id)
+	}
+	return nil
+}
+
+func fromDBGrant(g db.PermissionGrant) Grant {
+	return Grant{
+		ID:        g.ID,
+		ToolName:  g.ToolName,
+		Action:    g.Action,
+		Path:      g.Path,
+		ExpiresAt: g.ExpiresAt.Int64,
+		CreatedAt: g.CreatedAt,
+	}
+}
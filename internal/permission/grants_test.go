@@ -0,0 +1,108 @@
+package permission
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func newGrantStore(t *testing.T) GrantStore {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewGrantStore(db.New(conn), 0)
+}
+
+func TestGrantStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	conn, err := db.Connect(ctx, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	store := NewGrantStore(db.New(conn), 0)
+	grant, err := store.Create(ctx, PermissionRequest{ToolName: "edit", Action: "write", Path: "/work/internal"})
+	require.NoError(t, err)
+	require.Zero(t, grant.ExpiresAt)
+
+	_, err = db.New(conn).CreatePermissionGrant(ctx, db.CreatePermissionGrantParams{
+		ID:        "expired",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      "/work",
+		ExpiresAt: sql.NullInt64{Int64: time.Now().Add(-time.Hour).Unix(), Valid: true},
+	})
+	require.NoError(t, err)
+	expiring := NewGrantStore(db.New(conn), time.Hour)
+	_, err = expiring.Create(ctx, PermissionRequest{ToolName: "fetch", Action: "fetch", Path: "/work"})
+	require.NoError(t, err)
+
+	grants, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, grants, 2, "expired grants are not listed")
+	require.Equal(t, grant, grants[0])
+	require.NotZero(t, grants[1].ExpiresAt)
+
+	require.True(t, grant.covers(PermissionRequest{ToolName: "edit", Action: "write", Path: "/work/internal/"}))
+	require.False(t, grant.covers(PermissionRequest{ToolName: "edit", Action: "write", Path: "/work/internal/app"}), "grants don't cover subdirectories")
+	require.False(t, grant.covers(PermissionRequest{ToolName: "edit", Action: "write", Path: "/work"}))
+	require.False(t, grant.covers(PermissionRequest{ToolName: "edit", Action: "write", Path: "/work/internal2"}))
+	require.False(t, grant.covers(PermissionRequest{ToolName: "write", Action: "write", Path: "/work/internal"}))
+
+	require.NoError(t, store.Revoke(ctx, grant.ID))
+	require.ErrorIs(t, store.Revoke(ctx, grant.ID), ErrGrantNotFound)
+	grants, err = store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+}
+
+func TestPermissionService_GrantPersistent(t *testing.T) {
+	t.Parallel()
+
+	store := newGrantStore(t)
+	req := CreatePermissionRequest{SessionID: "one", ToolName: "edit", Action: "write", Path: "/tmp"}
+
+	service := NewPermissionService("/tmp", false, nil, nil, store, newAuditLog(t))
+	events := service.Subscribe(t.Context())
+	granted := make(chan bool)
+	go func() { granted <- service.Request(req) }()
+	service.GrantPersistent((<-events).Payload)
+	require.True(t, <-granted)
+
+	// A new service, as after a restart, doesn't ask again in any session.
+	req.SessionID = "two"
+	require.True(t, NewPermissionService("/tmp", false, nil, nil, store, newAuditLog(t)).Request(req))
+}
+
+func TestPermissionService_NoPersistentGrant(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newGrantStore(t)
+	req := CreatePermissionRequest{SessionID: "one", ToolName: "bash", Action: "execute", Path: "/tmp", NoPersistentGrant: true}
+
+	service := NewPermissionService("/tmp", false, nil, nil, store, newAuditLog(t))
+	events := service.Subscribe(ctx)
+	granted := make(chan bool)
+	go func() { granted <- service.Request(req) }()
+	event := <-events
+	require.True(t, event.Payload.NoPersistentGrant)
+	service.GrantPersistent(event.Payload)
+	require.True(t, <-granted)
+
+	grants, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, grants, "the request is only allowed once")
+
+	// Grants stored for the tool before don't apply either.
+	_, err = store.Create(ctx, PermissionRequest{ToolName: "bash", Action: "execute", Path: "/tmp"})
+	require.NoError(t, err)
+	go func() { granted <- service.Request(req) }()
+	service.Deny((<-events).Payload)
+	require.False(t, <-granted)
+}
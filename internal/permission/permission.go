@@ -3,6 +3,8 @@ package permission
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
@@ -23,6 +25,10 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// NoPersistentGrant is set by tools whose path doesn't tell what they
+	// do, such as bash, so that a grant for one request doesn't allow all the
+	// others.
+	NoPersistentGrant bool `json:"no_persistent_grant,omitempty"`
 }
 
 type PermissionNotification struct {
@@ -40,15 +46,32 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// NoPersistentGrant requests are never always allowed: stored grants
+	// don't apply to them, and GrantPersistent only grants them once.
+	NoPersistentGrant bool `json:"no_persistent_grant,omitempty"`
 }
 
 type Service interface {
 	pubsub.Suscriber[PermissionRequest]
+	// GrantPersistent grants the request and stores a grant that lets the
+	// tool perform the action in the same directory from now on.
 	GrantPersistent(permission PermissionRequest)
+	// GrantForSession grants the request and the same ones made in its
+	// session until the app exits.
+	GrantForSession(permission PermissionRequest)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(opts CreatePermissionRequest) bool
 	AutoApproveSession(sessionID string)
+	// SetPolicy makes the policy answer every permission request instead
+	// of the user. A nil policy restores asking.
+	SetPolicy(policy *Policy)
+	// CheckTool returns an error when the rules or the policy don't allow
+	// the tool to be used at all, and records the denial of the call.
+	CheckTool(sessionID, toolCallID, toolName string) error
+	// Denial returns why a rule or the policy denied the request made for
+	// the tool call, or nil when neither did.
+	Denial(toolCallID string) error
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
@@ -61,11 +84,17 @@ type permissionService struct {
 	workingDir            string
 	sessionPermissions    []PermissionRequest
 	sessionPermissionsMu  sync.RWMutex
+	grants                GrantStore
+	audit                 AuditLog
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	rules                 []compiledRule
+	policy                *Policy
+	policyMu              sync.RWMutex
+	denials               *csync.Map[string, error]
 
 	// used to make sure we only process one request at a time
 	requestMu     sync.Mutex
@@ -73,6 +102,20 @@ type permissionService struct {
 }
 
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
+	if permission.NoPersistentGrant {
+		s.Grant(permission)
+		return
+	}
+	if _, err := s.grants.Create(context.Background(), permission); err != nil {
+		slog.Error("Failed to store permission grant", "tool", permission.ToolName, "error", err)
+		// Still spare the session from asking again.
+		s.GrantForSession(permission)
+		return
+	}
+	s.Grant(permission)
+}
+
+func (s *permissionService) GrantForSession(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
@@ -123,9 +166,40 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
+	granted, decider, reason := s.request(opts)
+	s.record(opts, granted, decider, reason)
+	return granted
+}
+
+// request answers the request and tells what answered it, and why when that
+// isn't obvious from the decider.
+func (s *permissionService) request(opts CreatePermissionRequest) (bool, Decider, string) {
+	// Deny rules apply even when requests are skipped.
+	decision, rule := evaluateRules(s.rules, opts, s.workingDir)
+	if decision == DecisionDeny {
+		err := fmt.Errorf("%w by rule %q", ErrorPermissionDenied, rule.Pattern)
+		return s.deny(opts, err), DeciderRule, err.Error()
+	}
+
 	if s.skip {
-		return true
+		return true, DeciderYolo, ""
+	}
+
+	s.policyMu.RLock()
+	policy := s.policy
+	s.policyMu.RUnlock()
+	if policy != nil {
+		if err := policy.Check(opts.ToolName, opts.Action); err != nil {
+			return s.deny(opts, err), DeciderPolicy, err.Error()
+		}
+		return true, DeciderPolicy, ""
+	}
+
+	if decision == DecisionAllow {
+		return true, DeciderRule, fmt.Sprintf("allowed by rule %q", rule.Pattern)
 	}
+	// Ask rules prompt even for allowed tools and earlier grants.
+	ask := decision == DecisionAsk
 
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
@@ -136,8 +210,8 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
-		return true
+	if !ask && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
+		return true, DeciderAllowedTools, ""
 	}
 
 	s.autoApproveSessionsMu.RLock()
@@ -145,7 +219,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	s.autoApproveSessionsMu.RUnlock()
 
 	if autoApprove {
-		return true
+		return true, DeciderAutoApprove, ""
 	}
 
 	fileInfo, err := os.Stat(opts.Path)
@@ -162,33 +236,33 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		dir = s.workingDir
 	}
 	permission := PermissionRequest{
-		ID:          uuid.New().String(),
-		Path:        dir,
-		SessionID:   opts.SessionID,
-		ToolCallID:  opts.ToolCallID,
-		ToolName:    opts.ToolName,
-		Description: opts.Description,
-		Action:      opts.Action,
-		Params:      opts.Params,
-	}
-
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
-		}
+		ID:                uuid.New().String(),
+		Path:              dir,
+		SessionID:         opts.SessionID,
+		ToolCallID:        opts.ToolCallID,
+		ToolName:          opts.ToolName,
+		Description:       opts.Description,
+		Action:            opts.Action,
+		Params:            opts.Params,
+		NoPersistentGrant: opts.NoPersistentGrant,
 	}
-	s.sessionPermissionsMu.RUnlock()
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			return true
+	if !ask {
+		s.sessionPermissionsMu.RLock()
+		for _, p := range s.sessionPermissions {
+			if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
+				s.sessionPermissionsMu.RUnlock()
+				return true, DeciderGrant, "allowed for the session"
+			}
+		}
+		s.sessionPermissionsMu.RUnlock()
+
+		if !permission.NoPersistentGrant {
+			if grant, ok := s.findGrant(permission); ok {
+				return true, DeciderGrant, "always allowed by grant " + grant.ID
+			}
 		}
 	}
-	s.sessionPermissionsMu.RUnlock()
 
 	s.activeRequest = &permission
 
@@ -199,7 +273,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	// Publish the request
 	s.Publish(pubsub.CreatedEvent, permission)
 
-	return <-respCh
+	return <-respCh, DeciderUser, ""
 }
 
 func (s *permissionService) AutoApproveSession(sessionID string) {
@@ -208,6 +282,92 @@ func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Unlock()
 }
 
+func (s *permissionService) SetPolicy(policy *Policy) {
+	s.policyMu.Lock()
+	s.policy = policy
+	s.policyMu.Unlock()
+}
+
+// findGrant returns the stored grant that covers the request, if any.
+func (s *permissionService) findGrant(permission PermissionRequest) (Grant, bool) {
+	grants, err := s.grants.List(context.Background())
+	if err != nil {
+		slog.Error("Failed to list permission grants", "error", err)
+		return Grant{}, false
+	}
+	for _, g := range grants {
+		if g.covers(permission) {
+			return g, true
+		}
+	}
+	return Grant{}, false
+}
+
+func (s *permissionService) record(opts CreatePermissionRequest, granted bool, decider Decider, reason string) {
+	decision := AuditDenied
+	if granted {
+		decision = AuditGranted
+	}
+	if err := s.audit.Record(context.Background(), AuditEntry{
+		SessionID:  opts.SessionID,
+		ToolCallID: opts.ToolCallID,
+		ToolName:   opts.ToolName,
+		Action:     opts.Action,
+		Path:       opts.Path,
+		Params:     summarizeParams(opts.Params),
+		Decision:   decision,
+		Decider:    decider,
+		Reason:     reason,
+	}); err != nil {
+		slog.Error("Failed to record permission decision", "tool", opts.ToolName, "error", err)
+	}
+}
+
+// deny records why the request was denied without asking and tells the UI.
+func (s *permissionService) deny(opts CreatePermissionRequest, reason error) bool {
+	s.denials.Set(opts.ToolCallID, reason)
+	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		ToolCallID: opts.ToolCallID,
+		Denied:     true,
+	})
+	return false
+}
+
+func (s *permissionService) CheckTool(sessionID, toolCallID, toolName string) error {
+	decider, err := s.checkTool(toolName)
+	if err != nil {
+		s.record(CreatePermissionRequest{
+			SessionID:  sessionID,
+			ToolCallID: toolCallID,
+			ToolName:   toolName,
+		}, false, decider, err.Error())
+	}
+	return err
+}
+
+func (s *permissionService) checkTool(toolName string) (Decider, error) {
+	for _, r := range s.rules {
+		if r.Decision == DecisionDeny && r.specifier == "" && r.matchesTool(toolName) {
+			return DeciderRule, fmt.Errorf("%w by rule %q", ErrorPermissionDenied, r.Pattern)
+		}
+	}
+
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	if s.skip || s.policy == nil {
+		return "", nil
+	}
+	return DeciderPolicy, s.policy.CheckTool(toolName)
+}
+
+func (s *permissionService) Denial(toolCallID string) error {
+	err, ok := s.denials.Take(toolCallID)
+	if !ok {
+		return nil
+	}
+	return err
+}
+
 func (s *permissionService) SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification] {
 	return s.notificationBroker.Subscribe(ctx)
 }
@@ -220,15 +380,27 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
+// NewPermissionService creates the service. Rules must have been checked
+// with ValidateRules; invalid ones are ignored.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, rules []Rule, grants GrantStore, audit AuditLog) Service {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		if c, err := compileRule(r); err == nil {
+			compiled = append(compiled, c)
+		}
+	}
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
 		workingDir:          workingDir,
 		sessionPermissions:  make([]PermissionRequest, 0),
+		grants:              grants,
+		audit:               audit,
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
+		rules:               compiled,
 		pendingRequests:     csync.NewMap[string, chan bool](),
+		denials:             csync.NewMap[string, error](),
 	}
 }
@@ -0,0 +1,87 @@
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Policy answers permission requests without asking, for runs where nobody
+// is there to answer them. Entries are tool names ("bash") or tool and action
+// pairs ("bash:execute").
+type Policy struct {
+	// AllowTools lists the tools that may be used. When it is empty every
+	// tool that isn't denied may be used.
+	AllowTools []string `json:"allow_tools,omitempty"`
+	// DenyTools lists the tools that may not be used. It takes precedence
+	// over AllowTools.
+	DenyTools []string `json:"deny_tools,omitempty"`
+	// ReadOnly denies every request for permission, which all tools that
+	// change files, run commands or reach the network make.
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// LoadPolicy reads a policy from a JSON file.
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// IsZero reports whether the policy has no rules.
+func (p Policy) IsZero() bool {
+	return len(p.AllowTools) == 0 && len(p.DenyTools) == 0 && !p.ReadOnly
+}
+
+// Merge returns a policy with the rules of both policies.
+func (p Policy) Merge(other Policy) Policy {
+	return Policy{
+		AllowTools: append(slices.Clone(p.AllowTools), other.AllowTools...),
+		DenyTools:  append(slices.Clone(p.DenyTools), other.DenyTools...),
+		ReadOnly:   p.ReadOnly || other.ReadOnly,
+	}
+}
+
+// CheckTool returns an error when the policy doesn't allow the tool to be
+// used at all.
+func (p Policy) CheckTool(toolName string) error {
+	if slices.Contains(p.DenyTools, toolName) {
+		return policyError("the %s tool is denied", toolName)
+	}
+	if len(p.AllowTools) == 0 {
+		return nil
+	}
+	for _, entry := range p.AllowTools {
+		if tool, _, _ := strings.Cut(entry, ":"); tool == toolName {
+			return nil
+		}
+	}
+	return policyError("the %s tool is not in the allowed tools", toolName)
+}
+
+// Check returns an error when the policy doesn't grant the request.
+func (p Policy) Check(toolName, action string) error {
+	key := toolName + ":" + action
+	if slices.Contains(p.DenyTools, toolName) || slices.Contains(p.DenyTools, key) {
+		return policyError("%s is denied", key)
+	}
+	if p.ReadOnly {
+		return policyError("%s is not allowed in read-only mode", key)
+	}
+	if len(p.AllowTools) > 0 && !slices.Contains(p.AllowTools, toolName) && !slices.Contains(p.AllowTools, key) {
+		return policyError("%s is not in the allowed tools", key)
+	}
+	return nil
+}
+
+func policyError(format string, args ...any) error {
+	return fmt.Errorf("%w by policy: %s", ErrorPermissionDenied, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,68 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		tool    string
+		action  string
+		allowed bool
+	}{
+		{name: "empty policy", policy: Policy{}, tool: "bash", action: "execute", allowed: true},
+		{name: "tool allowed", policy: Policy{AllowTools: []string{"bash"}}, tool: "bash", action: "execute", allowed: true},
+		{name: "action allowed", policy: Policy{AllowTools: []string{"edit:write"}}, tool: "edit", action: "write", allowed: true},
+		{name: "other action", policy: Policy{AllowTools: []string{"edit:write"}}, tool: "edit", action: "delete", allowed: false},
+		{name: "not allowed", policy: Policy{AllowTools: []string{"view"}}, tool: "bash", action: "execute", allowed: false},
+		{name: "deny wins", policy: Policy{AllowTools: []string{"bash"}, DenyTools: []string{"bash:execute"}}, tool: "bash", action: "execute", allowed: false},
+		{name: "deny only", policy: Policy{DenyTools: []string{"fetch"}}, tool: "bash", action: "execute", allowed: true},
+		{name: "read only", policy: Policy{AllowTools: []string{"bash"}, ReadOnly: true}, tool: "bash", action: "execute", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.policy.Check(tt.tool, tt.action)
+			if tt.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrorPermissionDenied)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckTool(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{AllowTools: []string{"view", "edit:write"}, DenyTools: []string{"grep"}}
+	require.NoError(t, policy.CheckTool("view"))
+	require.NoError(t, policy.CheckTool("edit"), "a tool:action entry allows the tool")
+	require.Error(t, policy.CheckTool("grep"))
+	require.Error(t, policy.CheckTool("bash"))
+	require.NoError(t, Policy{ReadOnly: true}.CheckTool("bash"), "read-only mode only denies requests")
+}
+
+func TestPermissionService_Policy(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", false, []string{"bash"}, nil, newGrantStore(t), newAuditLog(t))
+	service.SetPolicy(&Policy{ReadOnly: true})
+
+	granted := service.Request(CreatePermissionRequest{
+		SessionID:  "session",
+		ToolCallID: "call",
+		ToolName:   "bash",
+		Action:     "execute",
+		Path:       "/tmp",
+	})
+	require.False(t, granted, "the policy takes precedence over the configured allowed tools")
+	require.ErrorContains(t, service.Denial("call"), "read-only")
+	require.NoError(t, service.Denial("call"), "a denial is reported once")
+}
@@ -0,0 +1,268 @@
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+type Decision string
+
+const (
+	DecisionAllow Decision = "allow"
+	DecisionDeny  Decision = "deny"
+	DecisionAsk   Decision = "ask"
+)
+
+// Rule decides the permission requests that match its pattern. A pattern is
+// a tool name, which may use * as a wildcard ("mcp_*"), optionally followed
+// by a specifier in parentheses:
+//
+//   - a command for bash: "bash(go test ./...)" matches the command and the
+//     command followed by arguments, "bash(git push*)" is a glob. Deny and
+//     ask rules also match commands run through wrappers like env, nohup
+//     and "sh -c", substitutions and programs given with their directory;
+//   - a path glob for tools that work on files: "edit(**/.env)", matched
+//     against paths relative to the working directory;
+//   - a URL glob for fetch and download: "fetch(https://pkg.go.dev/*)".
+type Rule struct {
+	Decision Decision
+	Pattern  string
+}
+
+var rulePattern = regexp.MustCompile(`^([^()\s]+)(?:\((.*)\))?$`)
+
+// compiledRule is a rule with its pattern split into the tool name and the
+// specifier.
+type compiledRule struct {
+	Rule
+	tool      string
+	specifier string
+}
+
+func compileRule(r Rule) (compiledRule, error) {
+	switch r.Decision {
+	case DecisionAllow, DecisionDeny, DecisionAsk:
+	default:
+		return compiledRule{}, fmt.Errorf("invalid decision %q in permission rule %q", r.Decision, r.Pattern)
+	}
+	m := rulePattern.FindStringSubmatch(strings.TrimSpace(r.Pattern))
+	if m == nil {
+		return compiledRule{}, fmt.Errorf("invalid permission rule %q", r.Pattern)
+	}
+	if _, err := path.Match(m[1], ""); err != nil {
+		return compiledRule{}, fmt.Errorf("invalid tool name in permission rule %q: %w", r.Pattern, err)
+	}
+	return compiledRule{Rule: r, tool: m[1], specifier: m[2]}, nil
+}
+
+// ValidateRules returns an error for the first rule that can't be used.
+func ValidateRules(rules []Rule) error {
+	for _, r := range rules {
+		if _, err := compileRule(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r compiledRule) matchesTool(toolName string) bool {
+	ok, _ := path.Match(r.tool, toolName)
+	return ok
+}
+
+// matches reports whether the rule applies to the request. A request can
+// have several targets, like the files of a workspace edit or the parts of a
+// compound shell command: allow rules have to match all of them, while deny
+// and ask rules match when any of them does.
+func (r compiledRule) matches(req CreatePermissionRequest, workingDir string) bool {
+	if !r.matchesTool(req.ToolName) {
+		return false
+	}
+	if r.specifier == "" {
+		return true
+	}
+	return matchTargets(r, requestTargets(req.Params), func(t target) bool {
+		switch t.kind {
+		case targetCommand:
+			return r.matchesCommand(t.value)
+		case targetPath:
+			return r.matchesPath(t.value, workingDir)
+		default:
+			return wildcardMatch(r.specifier, t.value)
+		}
+	})
+}
+
+func (r compiledRule) matchesCommand(command string) bool {
+	if r.Decision == DecisionAllow && (strings.Contains(command, "`") || strings.Contains(command, "$(")) {
+		// Substitutions run commands the rule can't see.
+		return false
+	}
+	return matchTargets(r, commandSegments(command), func(forms []string) bool {
+		if r.Decision == DecisionAllow {
+			// Allow only the command as written, so a wrapper can't smuggle
+			// in another command.
+			forms = forms[:1]
+		}
+		return slices.ContainsFunc(forms, r.matchesSegment)
+	})
+}
+
+func (r compiledRule) matchesSegment(segment string) bool {
+	if strings.Contains(r.specifier, "*") {
+		return wildcardMatch(r.specifier, segment)
+	}
+	return segment == r.specifier || strings.HasPrefix(segment, r.specifier+" ")
+}
+
+func (r compiledRule) matchesPath(p, workingDir string) bool {
+	p = filepath.Clean(p)
+	candidates := []string{filepath.ToSlash(p)}
+	if rel, err := filepath.Rel(workingDir, p); err == nil && !strings.HasPrefix(rel, "..") {
+		candidates = append(candidates, filepath.ToSlash(rel))
+	}
+	for _, c := range candidates {
+		if ok, _ := doublestar.Match(r.specifier, c); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTargets matches all targets for allow rules and any target for the
+// others.
+func matchTargets[T any](r compiledRule, targets []T, match func(T) bool) bool {
+	if len(targets) == 0 {
+		return false
+	}
+	all := r.Decision == DecisionAllow
+	for _, t := range targets {
+		if match(t) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+// evaluateRules returns the decision of the rules for the request: deny when
+// any deny rule matches, otherwise the decision of the first matching rule.
+// The decision is empty when no rule matches.
+func evaluateRules(rules []compiledRule, req CreatePermissionRequest, workingDir string) (Decision, Rule) {
+	var decision Decision
+	var matched Rule
+	for _, r := range rules {
+		if !r.matches(req, workingDir) {
+			continue
+		}
+		if r.Decision == DecisionDeny {
+			return DecisionDeny, r.Rule
+		}
+		if decision == "" {
+			decision, matched = r.Decision, r.Rule
+		}
+	}
+	return decision, matched
+}
+
+type targetKind int
+
+const (
+	targetCommand targetKind = iota
+	targetPath
+	targetURL
+)
+
+type target struct {
+	kind  targetKind
+	value string
+}
+
+// requestTargets extracts what a request is about from its params, which
+// every tool defines on its own.
+func requestTargets(params any) []target {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil
+	}
+	var fields struct {
+		Command  string `json:"command"`
+		FilePath string `json:"file_path"`
+		Path     string `json:"path"`
+		URL      string `json:"url"`
+		Files    []struct {
+			FilePath string `json:"file_path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	var targets []target
+	if fields.Command != "" {
+		targets = append(targets, target{targetCommand, fields.Command})
+	}
+	if fields.URL != "" {
+		targets = append(targets, target{targetURL, fields.URL})
+	}
+	for _, p := range []string{fields.FilePath, fields.Path} {
+		if p != "" {
+			targets = append(targets, target{targetPath, p})
+		}
+	}
+	for _, f := range fields.Files {
+		if f.FilePath != "" {
+			targets = append(targets, target{targetPath, f.FilePath})
+		}
+	}
+	return targets
+}
+
+var commandSeparators = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+
+// commandSegments returns the simple commands a shell command runs, including
+// the ones in substitutions and "sh -c" scripts, with their words unquoted.
+// Each command comes with the other forms a rule should see it in: without
+// the directory of the program and without wrappers like env or nohup.
+// Commands the shell can't parse are split on their operators instead.
+func commandSegments(command string) [][]string {
+	calls, _, err := shell.ParseCalls(command)
+	if err != nil {
+		var segments [][]string
+		for _, s := range commandSeparators.Split(command, -1) {
+			if s = strings.Join(strings.Fields(s), " "); s != "" {
+				segments = append(segments, []string{s})
+			}
+		}
+		return segments
+	}
+
+	segments := make([][]string, 0, len(calls))
+	for _, args := range calls {
+		forms := []string{strings.Join(args, " ")}
+		for ; args != nil; args = shell.UnwrapCommand(args) {
+			name := filepath.Base(args[0])
+			forms = append(forms, strings.Join(append([]string{name}, args[1:]...), " "))
+		}
+		segments = append(segments, forms)
+	}
+	return segments
+}
+
+// wildcardMatch matches s against a pattern where * matches any text,
+// including spaces and slashes.
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	ok, _ := regexp.MatchString("^"+strings.Join(parts, ".*")+"$", s)
+	return ok
+}
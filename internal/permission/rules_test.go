@@ -0,0 +1,87 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	t.Parallel()
+
+	bash := func(command string) CreatePermissionRequest {
+		return CreatePermissionRequest{ToolName: "bash", Action: "execute", Params: map[string]any{"command": command}}
+	}
+	edit := func(path string) CreatePermissionRequest {
+		return CreatePermissionRequest{ToolName: "edit", Action: "write", Params: map[string]any{"file_path": path}}
+	}
+
+	tests := []struct {
+		name     string
+		rules    []Rule
+		req      CreatePermissionRequest
+		decision Decision
+	}{
+		{name: "command prefix", rules: []Rule{{DecisionAllow, "bash(go test ./...)"}}, req: bash("go test ./... -run TestFoo"), decision: DecisionAllow},
+		{name: "prefix needs a word boundary", rules: []Rule{{DecisionAllow, "bash(go test)"}}, req: bash("go testdata/run.sh"), decision: ""},
+		{name: "tool only", rules: []Rule{{DecisionAsk, "bash"}}, req: bash("ls"), decision: DecisionAsk},
+		{name: "tool wildcard", rules: []Rule{{DecisionDeny, "mcp_*"}}, req: CreatePermissionRequest{ToolName: "mcp_github_push"}, decision: DecisionDeny},
+		{name: "glob in compound command", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("cd repo && git push --force"), decision: DecisionDeny},
+		{name: "allow needs every command", rules: []Rule{{DecisionAllow, "bash(go test*)"}}, req: bash("go test ./... ; rm -rf /"), decision: ""},
+		{name: "allow skips substitutions", rules: []Rule{{DecisionAllow, "bash(echo*)"}}, req: bash("echo $(curl example.com)"), decision: ""},
+		{name: "path glob", rules: []Rule{{DecisionDeny, "edit(**/.env)"}}, req: edit("/work/config/.env"), decision: DecisionDeny},
+		{name: "relative path glob", rules: []Rule{{DecisionAllow, "edit(internal/**)"}}, req: edit("/work/internal/app/app.go"), decision: DecisionAllow},
+		{name: "other tool", rules: []Rule{{DecisionDeny, "edit(**/.env)"}}, req: bash("cat .env"), decision: ""},
+		{name: "first match wins", rules: []Rule{{DecisionAsk, "bash(go*)"}, {DecisionAllow, "bash(go test*)"}}, req: bash("go test"), decision: DecisionAsk},
+		{name: "deny wins", rules: []Rule{{DecisionAllow, "bash"}, {DecisionDeny, "bash(git push*)"}}, req: bash("git push"), decision: DecisionDeny},
+		{name: "deny through env", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("env git push"), decision: DecisionDeny},
+		{name: "deny with assignments", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("FOO=1 git push"), decision: DecisionDeny},
+		{name: "deny in sh -c", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("sh -c 'git push origin main'"), decision: DecisionDeny},
+		{name: "deny in substitution", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("echo $(git push)"), decision: DecisionDeny},
+		{name: "deny in backticks", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("echo `git push`"), decision: DecisionDeny},
+		{name: "deny with extra whitespace", rules: []Rule{{DecisionDeny, "bash(git push)"}}, req: bash("git   push\t--force"), decision: DecisionDeny},
+		{name: "deny with quoting", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash(`'git' "push"`), decision: DecisionDeny},
+		{name: "deny with program directory", rules: []Rule{{DecisionDeny, "bash(git push*)"}}, req: bash("/usr/bin/git push"), decision: DecisionDeny},
+		{name: "allow ignores wrapped commands", rules: []Rule{{DecisionAllow, "bash(go test*)"}}, req: bash("env go test ./..."), decision: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var compiled []compiledRule
+			for _, r := range tt.rules {
+				c, err := compileRule(r)
+				require.NoError(t, err)
+				compiled = append(compiled, c)
+			}
+			decision, _ := evaluateRules(compiled, tt.req, "/work")
+			require.Equal(t, tt.decision, decision)
+		})
+	}
+}
+
+func TestPermissionService_Rules(t *testing.T) {
+	t.Parallel()
+
+	service := NewPermissionService("/tmp", true, nil, []Rule{
+		{DecisionDeny, "bash(rm -rf*)"},
+		{DecisionDeny, "fetch"},
+	}, newGrantStore(t), newAuditLog(t))
+	require.ErrorIs(t, service.CheckTool("session", "call", "fetch"), ErrorPermissionDenied)
+	require.NoError(t, service.CheckTool("session", "call", "bash"))
+
+	req := CreatePermissionRequest{ToolCallID: "call", ToolName: "bash", Action: "execute", Params: map[string]any{"command": "rm -rf /"}}
+	require.False(t, service.Request(req), "deny rules apply when requests are skipped")
+	require.ErrorContains(t, service.Denial("call"), "rm -rf*")
+
+	req.Params = map[string]any{"command": "ls"}
+	require.True(t, service.Request(req))
+}
+
+func TestValidateRules(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateRules([]Rule{{DecisionAllow, "bash(go test ./...)"}, {DecisionAsk, "mcp_*"}}))
+	require.Error(t, ValidateRules([]Rule{{"maybe", "bash"}}))
+	require.Error(t, ValidateRules([]Rule{{DecisionDeny, "bash(git push"}}))
+	require.Error(t, ValidateRules([]Rule{{DecisionDeny, "[bash"}}))
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// EventKind is what an event is about.
+type EventKind string
+
+const (
+	EventSession                EventKind = "session"
+	EventMessage                EventKind = "message"
+	EventPermission             EventKind = "permission"
+	EventPermissionNotification EventKind = "permission_notification"
+	EventFile                   EventKind = "file"
+	EventJob                    EventKind = "job"
+	EventAgent                  EventKind = "agent"
+	EventMCP                    EventKind = "mcp"
+	EventLSP                    EventKind = "lsp"
+)
+
+// Event is sent to the clients of the event stream, with the kind as the
+// name of the server-sent event.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	// Type is created, updated or deleted.
+	Type pubsub.EventType `json:"type"`
+	// SessionID is set for the events of a session.
+	SessionID string `json:"session_id,omitempty"`
+	Payload   any    `json:"payload"`
+}
+
+// keepAliveInterval is how often an idle event stream sends a comment, so
+// proxies and clients don't time it out.
+const keepAliveInterval = 15 * time.Second
+
+// streamEvents streams the events as server-sent events, only the ones of a
+// session when the session_id query parameter is set.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	sessionID := r.URL.Query().Get("session_id")
+	events := s.events.Subscribe(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if sessionID != "" && event.Payload.SessionID != sessionID {
+				continue
+			}
+			data, err := json.Marshal(event.Payload)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Payload.Kind, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// publish tracks the permission requests of an application event and sends
+// it to the clients.
+func (s *Server) publish(msg tea.Msg) {
+	var event Event
+	switch e := msg.(type) {
+	case pubsub.Event[session.Session]:
+		event = Event{Kind: EventSession, Type: e.Type, SessionID: e.Payload.ID, Payload: newSessionJSON(e.Payload)}
+	case pubsub.Event[message.Message]:
+		event = Event{Kind: EventMessage, Type: e.Type, SessionID: e.Payload.SessionID, Payload: newMessageJSON(e.Payload)}
+	case pubsub.Event[permission.PermissionRequest]:
+		s.pending.Set(e.Payload.ID, e.Payload)
+		event = Event{Kind: EventPermission, Type: e.Type, SessionID: e.Payload.SessionID, Payload: e.Payload}
+	case pubsub.Event[permission.PermissionNotification]:
+		// The request was answered, possibly by a rule or an earlier grant.
+		var sessionID string
+		for req := range s.pending.Seq() {
+			if req.ToolCallID == e.Payload.ToolCallID {
+				sessionID = req.SessionID
+				if e.Payload.Granted || e.Payload.Denied {
+					s.pending.Del(req.ID)
+				}
+			}
+		}
+		event = Event{Kind: EventPermissionNotification, Type: e.Type, SessionID: sessionID, Payload: e.Payload}
+	case pubsub.Event[history.File]:
+		event = Event{Kind: EventFile, Type: e.Type, SessionID: e.Payload.SessionID, Payload: newFileJSON(e.Payload)}
+	case pubsub.Event[shell.Job]:
+		event = Event{Kind: EventJob, Type: e.Type, SessionID: e.Payload.SessionID, Payload: newJobJSON(e.Payload)}
+	case pubsub.Event[agent.AgentEvent]:
+		payload := newAgentEventJSON(e.Payload)
+		event = Event{Kind: EventAgent, Type: e.Type, SessionID: payload.SessionID, Payload: payload}
+	case pubsub.Event[agent.MCPEvent]:
+		event = Event{Kind: EventMCP, Type: e.Type, Payload: statusJSON{
+			Name:  e.Payload.Name,
+			State: e.Payload.State.String(),
+			Error: errorString(e.Payload.Error),
+		}}
+	case pubsub.Event[app.LSPEvent]:
+		event = Event{Kind: EventLSP, Type: e.Type, Payload: statusJSON{
+			Name:            e.Payload.Name,
+			State:           lspState(e.Payload.State),
+			Error:           errorString(e.Payload.Error),
+			DiagnosticCount: e.Payload.DiagnosticCount,
+		}}
+	default:
+		return
+	}
+	s.events.Publish(event.Type, event)
+}
+
+type sessionJSON struct {
+	ID               string  `json:"id"`
+	ParentSessionID  string  `json:"parent_session_id,omitempty"`
+	ForkMessageID    string  `json:"fork_message_id,omitempty"`
+	Title            string  `json:"title"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	CreatedAt        int64   `json:"created_at"`
+	UpdatedAt        int64   `json:"updated_at"`
+}
+
+func newSessionJSON(s session.Session) sessionJSON {
+	return sessionJSON{
+		ID:               s.ID,
+		ParentSessionID:  s.ParentSessionID,
+		ForkMessageID:    s.ForkMessageID,
+		Title:            s.Title,
+		MessageCount:     s.MessageCount,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+type messageJSON struct {
+	ID           string               `json:"id"`
+	SessionID    string               `json:"session_id"`
+	Role         message.MessageRole  `json:"role"`
+	Model        string               `json:"model,omitempty"`
+	Text         string               `json:"text,omitempty"`
+	Reasoning    string               `json:"reasoning,omitempty"`
+	ToolCalls    []message.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults  []message.ToolResult `json:"tool_results,omitempty"`
+	FinishReason message.FinishReason `json:"finish_reason,omitempty"`
+	CreatedAt    int64                `json:"created_at"`
+	UpdatedAt    int64                `json:"updated_at"`
+}
+
+func newMessageJSON(msg message.Message) messageJSON {
+	return messageJSON{
+		ID:           msg.ID,
+		SessionID:    msg.SessionID,
+		Role:         msg.Role,
+		Model:        msg.Model,
+		Text:         msg.Content().Text,
+		Reasoning:    msg.ReasoningContent().Thinking,
+		ToolCalls:    msg.ToolCalls(),
+		ToolResults:  msg.ToolResults(),
+		FinishReason: msg.FinishReason(),
+		CreatedAt:    msg.CreatedAt,
+		UpdatedAt:    msg.UpdatedAt,
+	}
+}
+
+// fileJSON is a version of a file the agent changed, without its content.
+type fileJSON struct {
+	ID        string `json:"id"`
+	Path      string `json:"path"`
+	Version   int64  `json:"version"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func newFileJSON(f history.File) fileJSON {
+	return fileJSON{ID: f.ID, Path: f.Path, Version: f.Version, CreatedAt: f.CreatedAt}
+}
+
+type jobJSON struct {
+	ID         string          `json:"id"`
+	Command    string          `json:"command"`
+	WorkingDir string          `json:"working_dir"`
+	Status     shell.JobStatus `json:"status"`
+	ExitCode   int             `json:"exit_code"`
+	StartedAt  time.Time       `json:"started_at"`
+	EndedAt    *time.Time      `json:"ended_at,omitempty"`
+}
+
+func newJobJSON(job shell.Job) jobJSON {
+	out := jobJSON{
+		ID:         job.ID,
+		Command:    job.Command,
+		WorkingDir: job.WorkingDir,
+		Status:     job.Status,
+		ExitCode:   job.ExitCode,
+		StartedAt:  job.StartedAt,
+	}
+	if !job.EndedAt.IsZero() {
+		out.EndedAt = &job.EndedAt
+	}
+	return out
+}
+
+type agentEventJSON struct {
+	Type      agent.AgentEventType `json:"type"`
+	SessionID string               `json:"session_id,omitempty"`
+	Message   *messageJSON         `json:"message,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Progress  string               `json:"progress,omitempty"`
+	Done      bool                 `json:"done,omitempty"`
+}
+
+func newAgentEventJSON(e agent.AgentEvent) agentEventJSON {
+	out := agentEventJSON{
+		Type:      e.Type,
+		SessionID: e.SessionID,
+		Error:     errorString(e.Error),
+		Progress:  e.Progress,
+		Done:      e.Done,
+	}
+	if e.Message.ID != "" {
+		msg := newMessageJSON(e.Message)
+		out.Message = &msg
+		out.SessionID = e.Message.SessionID
+	}
+	return out
+}
+
+// statusJSON is the state of an MCP or LSP server.
+type statusJSON struct {
+	Name            string `json:"name"`
+	State           string `json:"state"`
+	Error           string `json:"error,omitempty"`
+	DiagnosticCount int    `json:"diagnostic_count,omitempty"`
+}
+
+func lspState(state lsp.ServerState) string {
+	switch state {
+	case lsp.StateStarting:
+		return "starting"
+	case lsp.StateReady:
+		return "ready"
+	case lsp.StateError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
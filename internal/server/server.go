@@ -0,0 +1,315 @@
+// Package server exposes an application over a local HTTP API, for clients
+// like editor plugins that drive the agent without the TUI.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/csync"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+)
+
+// Server serves the sessions, messages, permission requests and events of
+// an application.
+type Server struct {
+	app   *app.App
+	token string
+	// host is the address the server listens on.
+	host string
+
+	// ctx outlives the requests, for the runs they start.
+	ctx    context.Context
+	events *pubsub.Broker[Event]
+	// pending holds the permission requests waiting for an answer, by id.
+	pending *csync.Map[string, permission.PermissionRequest]
+	mux     *http.ServeMux
+}
+
+// New returns a server for the application, which forwards its events to
+// the clients until the context is done. Requests have to send token as a
+// bearer token, and name host, the address the server listens on, or a
+// loopback address in their Host header.
+func New(ctx context.Context, a *app.App, token, host string) *Server {
+	s := &Server{
+		app:     a,
+		token:   token,
+		host:    host,
+		ctx:     ctx,
+		events:  pubsub.NewBroker[Event](),
+		pending: csync.NewMap[string, permission.PermissionRequest](),
+		mux:     http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /sessions", s.listSessions)
+	s.mux.HandleFunc("POST /sessions", s.createSession)
+	s.mux.HandleFunc("GET /sessions/{id}", s.getSession)
+	s.mux.HandleFunc("GET /sessions/{id}/messages", s.listMessages)
+	s.mux.HandleFunc("POST /sessions/{id}/prompt", s.prompt)
+	s.mux.HandleFunc("POST /sessions/{id}/cancel", s.cancel)
+	s.mux.HandleFunc("GET /permissions", s.listPermissions)
+	s.mux.HandleFunc("POST /permissions/{id}", s.answerPermission)
+	s.mux.HandleFunc("GET /events", s.streamEvents)
+
+	go func() {
+		a.SubscribeFunc(ctx, s.publish)
+		s.events.Shutdown()
+	}()
+	return s
+}
+
+// ServeHTTP checks every request before routing it. Besides the token, web
+// pages are kept out: DNS rebinding gets past the same-origin policy but
+// not the Host check, browsers set Origin on cross-origin requests, and
+// they can't send a JSON Content-Type without a preflight.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.token == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+		return
+	}
+	if !s.allowedHost(r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("host %s is not allowed", r.Host))
+		return
+	}
+	if r.Header.Get("Origin") != "" {
+		writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+		return
+	}
+	if r.Method == http.MethodPost {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("the Content-Type must be application/json"))
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// allowedHost reports whether the Host header of a request names the
+// server: a loopback address, or the address it listens on.
+func (s *Server) allowedHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	return s.host != "" && strings.EqualFold(host, s.host) && !net.ParseIP(s.host).IsUnspecified()
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.app.Sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list sessions: %w", err))
+		return
+	}
+	out := make([]sessionJSON, 0, len(sessions))
+	for _, sess := range sessions {
+		out = append(out, newSessionJSON(sess))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+type createSessionRequest struct {
+	Title string `json:"title"`
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Title == "" {
+		req.Title = "New Session"
+	}
+	sess, err := s.app.Sessions.Create(r.Context(), req.Title)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create session: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSessionJSON(sess))
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.app.Sessions.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSessionError(w, r.PathValue("id"), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newSessionJSON(sess))
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.app.Sessions.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSessionError(w, r.PathValue("id"), err)
+		return
+	}
+	msgs, err := s.app.Messages.List(r.Context(), sess.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list messages: %w", err))
+		return
+	}
+	out := make([]messageJSON, 0, len(msgs))
+	for _, msg := range msgs {
+		out = append(out, newMessageJSON(msg))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+type promptRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+type promptResponse struct {
+	SessionID string `json:"session_id"`
+	// Queued is set when the session was busy and the prompt runs after
+	// the current one.
+	Queued bool `json:"queued"`
+}
+
+// prompt starts a run of the agent and returns right away. Its progress is
+// reported by the message and agent events.
+func (s *Server) prompt(w http.ResponseWriter, r *http.Request) {
+	var req promptRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("prompt is required"))
+		return
+	}
+	if s.app.CoderAgent == nil {
+		writeError(w, http.StatusServiceUnavailable, errors.New("no agent is configured"))
+		return
+	}
+	sess, err := s.app.Sessions.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSessionError(w, r.PathValue("id"), err)
+		return
+	}
+
+	queued := s.app.CoderAgent.IsSessionBusy(sess.ID)
+	done, err := s.app.CoderAgent.Run(s.ctx, sess.ID, req.Prompt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to start the agent: %w", err))
+		return
+	}
+	if done != nil {
+		go func() {
+			for range done {
+			}
+		}()
+	}
+	writeJSON(w, http.StatusAccepted, promptResponse{SessionID: sess.ID, Queued: queued})
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	if s.app.CoderAgent != nil {
+		s.app.CoderAgent.Cancel(r.PathValue("id"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listPermissions(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("session_id")
+	out := []permission.PermissionRequest{}
+	for req := range s.pending.Seq() {
+		if sessionID == "" || req.SessionID == sessionID {
+			out = append(out, req)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Decisions a client can answer a permission request with.
+const (
+	decisionAllow        = "allow"
+	decisionAllowSession = "allow_session"
+	decisionAllowAlways  = "allow_always"
+	decisionDeny         = "deny"
+)
+
+type permissionAnswer struct {
+	Decision string `json:"decision"`
+}
+
+func (s *Server) answerPermission(w http.ResponseWriter, r *http.Request) {
+	var answer permissionAnswer
+	if !readJSON(w, r, &answer) {
+		return
+	}
+	var respond func(permission.PermissionRequest)
+	switch answer.Decision {
+	case decisionAllow:
+		respond = s.app.Permissions.Grant
+	case decisionAllowSession:
+		respond = s.app.Permissions.GrantForSession
+	case decisionAllowAlways:
+		respond = s.app.Permissions.GrantPersistent
+	case decisionDeny:
+		respond = s.app.Permissions.Deny
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid decision %q, use %s, %s, %s or %s", answer.Decision, decisionAllow, decisionAllowSession, decisionAllowAlways, decisionDeny))
+		return
+	}
+
+	req, ok := s.pending.Take(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("permission request %s not found", r.PathValue("id")))
+		return
+	}
+	respond(req)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeSessionError(w http.ResponseWriter, id string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %s not found", id))
+		return
+	}
+	writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get session: %w", err))
+}
+
+// readJSON decodes the body of the request, which can be empty, and writes
+// an error when it is invalid.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
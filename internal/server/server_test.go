@@ -0,0 +1,226 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/app"
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "s3cret"
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	workingDir := t.TempDir()
+	a := &app.App{
+		Sessions:    session.NewService(q),
+		Messages:    message.NewService(q),
+		Permissions: permission.NewPermissionService(workingDir, false, nil, nil, permission.NewGrantStore(q, 0), permission.NewAuditLog(q)),
+	}
+	s := New(t.Context(), a, testToken, "127.0.0.1")
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func doJSON(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+func TestServer_Sessions(t *testing.T) {
+	t.Parallel()
+
+	_, ts := newTestServer(t)
+
+	var created sessionJSON
+	require.Equal(t, http.StatusCreated, doJSON(t, "POST", ts.URL+"/sessions", `{"title":"Fix tests"}`, &created))
+	require.Equal(t, "Fix tests", created.Title)
+
+	var sessions []sessionJSON
+	require.Equal(t, http.StatusOK, doJSON(t, "GET", ts.URL+"/sessions", "", &sessions))
+	require.Len(t, sessions, 1)
+	require.Equal(t, created.ID, sessions[0].ID)
+
+	var got sessionJSON
+	require.Equal(t, http.StatusOK, doJSON(t, "GET", ts.URL+"/sessions/"+created.ID, "", &got))
+	require.Equal(t, created, got)
+
+	var msgs []messageJSON
+	require.Equal(t, http.StatusOK, doJSON(t, "GET", ts.URL+"/sessions/"+created.ID+"/messages", "", &msgs))
+	require.Empty(t, msgs)
+
+	var errResp errorResponse
+	require.Equal(t, http.StatusNotFound, doJSON(t, "GET", ts.URL+"/sessions/missing", "", &errResp))
+	require.Contains(t, errResp.Error, "not found")
+	require.Equal(t, http.StatusBadRequest, doJSON(t, "POST", ts.URL+"/sessions/"+created.ID+"/prompt", `{"prompt":" "}`, nil))
+	require.Equal(t, http.StatusServiceUnavailable, doJSON(t, "POST", ts.URL+"/sessions/"+created.ID+"/prompt", `{"prompt":"hi"}`, nil))
+}
+
+func TestServer_Token(t *testing.T) {
+	t.Parallel()
+
+	_, ts := newTestServer(t)
+
+	req, err := http.NewRequestWithContext(t.Context(), "GET", ts.URL+"/sessions", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	require.Equal(t, http.StatusOK, doJSON(t, "GET", ts.URL+"/sessions", "", nil))
+}
+
+func TestServer_NoToken(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(New(t.Context(), &app.App{}, "", "127.0.0.1"))
+	t.Cleanup(ts.Close)
+
+	req, err := http.NewRequestWithContext(t.Context(), "GET", ts.URL+"/sessions", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer ")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "a server without a token accepts nothing")
+}
+
+func TestServer_RejectsBrowsers(t *testing.T) {
+	t.Parallel()
+
+	_, ts := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		host   string
+		status int
+	}{
+		{"rebound host", "GET", nil, "evil.example:4096", http.StatusForbidden},
+		{"origin", "GET", map[string]string{"Origin": "https://evil.example"}, "", http.StatusForbidden},
+		{"text body", "POST", map[string]string{"Content-Type": "text/plain"}, "", http.StatusUnsupportedMediaType},
+		{"no content type", "POST", map[string]string{"Content-Type": ""}, "", http.StatusUnsupportedMediaType},
+		{"localhost", "GET", nil, "localhost:4096", http.StatusOK},
+		{"json", "POST", map[string]string{"Content-Type": "application/json; charset=utf-8"}, "", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(t.Context(), tt.method, ts.URL+"/sessions", strings.NewReader(`{}`))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+testToken)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestServer_Permissions(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+
+	// Forward the requests like the application does.
+	requests := s.app.Permissions.Subscribe(t.Context())
+	go func() {
+		for event := range requests {
+			s.publish(event)
+		}
+	}()
+
+	granted := make(chan bool)
+	go func() {
+		granted <- s.app.Permissions.Request(permission.CreatePermissionRequest{
+			SessionID:  "session",
+			ToolCallID: "call",
+			ToolName:   "bash",
+			Action:     "execute",
+			Path:       t.TempDir(),
+		})
+	}()
+
+	var pending []permission.PermissionRequest
+	require.Eventually(t, func() bool {
+		require.Equal(t, http.StatusOK, doJSON(t, "GET", ts.URL+"/permissions?session_id=session", "", &pending))
+		return len(pending) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "bash", pending[0].ToolName)
+
+	require.Equal(t, http.StatusBadRequest, doJSON(t, "POST", ts.URL+"/permissions/"+pending[0].ID, `{"decision":"maybe"}`, nil))
+	require.Equal(t, http.StatusNoContent, doJSON(t, "POST", ts.URL+"/permissions/"+pending[0].ID, `{"decision":"deny"}`, nil))
+	require.False(t, <-granted)
+	require.Equal(t, http.StatusNotFound, doJSON(t, "POST", ts.URL+"/permissions/"+pending[0].ID, `{"decision":"allow"}`, nil))
+}
+
+func TestServer_Events(t *testing.T) {
+	t.Parallel()
+
+	s, ts := newTestServer(t)
+
+	req, err := http.NewRequestWithContext(t.Context(), "GET", ts.URL+"/events?session_id=a", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	s.publish(pubsub.Event[session.Session]{Type: pubsub.UpdatedEvent, Payload: session.Session{ID: "b", Title: "Other"}})
+	s.publish(pubsub.Event[session.Session]{Type: pubsub.UpdatedEvent, Payload: session.Session{ID: "a", Title: "Mine"}})
+
+	scanner := bufio.NewScanner(resp.Body)
+	require.True(t, scanner.Scan())
+	require.Equal(t, "event: session", scanner.Text())
+	require.True(t, scanner.Scan())
+	data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+	require.True(t, ok)
+
+	var event struct {
+		Kind      EventKind        `json:"kind"`
+		Type      pubsub.EventType `json:"type"`
+		SessionID string           `json:"session_id"`
+		Payload   sessionJSON      `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	require.Equal(t, EventSession, event.Kind)
+	require.Equal(t, pubsub.UpdatedEvent, event.Type)
+	require.Equal(t, "Mine", event.Payload.Title)
+}
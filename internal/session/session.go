@@ -17,9 +17,13 @@ type Session struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	SummaryMessageID string
+	ForkMessageID    string
 	Cost             float64
 	CreatedAt        int64
 	UpdatedAt        int64
+	// KeptMessageID is the first message kept verbatim after the summary,
+	// when the summary only covers the older turns.
+	KeptMessageID string
 }
 
 type Service interface {
@@ -27,6 +31,7 @@ type Service interface {
 	Create(ctx context.Context, title string) (Session, error)
 	CreateTitleSession(ctx context.Context, parentSessionID string) (Session, error)
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
+	CreateForkSession(ctx context.Context, parentSessionID, forkMessageID, title string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
@@ -79,6 +84,21 @@ func (s *service) CreateTitleSession(ctx context.Context, parentSessionID string
 	return session, nil
 }
 
+func (s *service) CreateForkSession(ctx context.Context, parentSessionID, forkMessageID, title string) (Session, error) {
+	dbSession, err := s.q.CreateSession(ctx, db.CreateSessionParams{
+		ID:              uuid.New().String(),
+		ParentSessionID: sql.NullString{String: parentSessionID, Valid: true},
+		Title:           title,
+		ForkMessageID:   sql.NullString{String: forkMessageID, Valid: true},
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.CreatedEvent, session)
+	return session, nil
+}
+
 func (s *service) Delete(ctx context.Context, id string) error {
 	session, err := s.Get(ctx, id)
 	if err != nil {
@@ -110,6 +130,10 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
+		KeptMessageID: sql.NullString{
+			String: session.KeptMessageID,
+			Valid:  session.KeptMessageID != "",
+		},
 		Cost: session.Cost,
 	})
 	if err != nil {
@@ -141,6 +165,8 @@ func (s service) fromDBItem(item db.Session) Session {
 		PromptTokens:     item.PromptTokens,
 		CompletionTokens: item.CompletionTokens,
 		SummaryMessageID: item.SummaryMessageID.String,
+		KeptMessageID:    item.KeptMessageID.String,
+		ForkMessageID:    item.ForkMessageID.String,
 		Cost:             item.Cost,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
@@ -28,3 +28,13 @@ package shell
 //	shell.SetWorkingDir("/tmp")
 //	cwd := shell.GetWorkingDir()
 //	env := shell.GetEnv()
+//
+// 5. For sandboxed commands (Linux only):
+//
+//	shell := shell.NewShell(&shell.Options{
+//	    WorkingDir: "/path/to/project",
+//	    Sandbox: &shell.Sandbox{
+//	        ReadWritePaths: []string{"/path/to/project"},
+//	    },
+//	})
+//	shell.Exec(ctx, "touch /etc/passwd")  // Fails
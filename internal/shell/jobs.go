@@ -0,0 +1,249 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
+)
+
+// maxJobOutput caps the output of a job that hasn't been read yet, per
+// stream. Older output is dropped first.
+const maxJobOutput = 1024 * 1024
+
+// ErrJobNotFound is returned for unknown job ids.
+var ErrJobNotFound = errors.New("job not found")
+
+type JobStatus string
+
+const (
+	JobRunning JobStatus = "running"
+	JobExited  JobStatus = "exited"
+	JobKilled  JobStatus = "killed"
+)
+
+// Job is a command running in the background.
+type Job struct {
+	ID         string
+	SessionID  string
+	Command    string
+	WorkingDir string
+	Status     JobStatus
+	// ExitCode is set once the job exited.
+	ExitCode  int
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// JobOutput is what a job wrote since its output was last read.
+type JobOutput struct {
+	Stdout string
+	Stderr string
+}
+
+// JobManager runs commands in the background and keeps track of them per
+// session.
+type JobManager interface {
+	pubsub.Suscriber[Job]
+	// Start runs the command in a copy of the shell, so it starts in the
+	// working directory and environment of the shell without changing them.
+	Start(sessionID, command string, sh *Shell) (Job, error)
+	Get(id string) (Job, error)
+	// Output returns the output of the job since the previous call.
+	Output(id string) (Job, JobOutput, error)
+	// List returns the jobs of the session, or of all sessions when
+	// sessionID is empty, oldest first.
+	List(sessionID string) []Job
+	Kill(id string) error
+	// KillAll kills the running jobs and waits for them to stop.
+	KillAll()
+}
+
+type job struct {
+	mu     sync.Mutex
+	info   Job
+	stdout jobBuffer
+	stderr jobBuffer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type jobManager struct {
+	*pubsub.Broker[Job]
+	mu     sync.Mutex
+	jobs   map[string]*job
+	nextID int
+}
+
+func NewJobManager() JobManager {
+	return &jobManager{
+		Broker: pubsub.NewBroker[Job](),
+		jobs:   make(map[string]*job),
+	}
+}
+
+func (m *jobManager) Start(sessionID, command string, sh *Shell) (Job, error) {
+	if strings.TrimSpace(command) == "" {
+		return Job{}, errors.New("missing command")
+	}
+	jobShell := sh.clone()
+
+	m.mu.Lock()
+	m.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		info: Job{
+			ID:         fmt.Sprintf("job-%d", m.nextID),
+			SessionID:  sessionID,
+			Command:    command,
+			WorkingDir: jobShell.cwd,
+			Status:     JobRunning,
+			StartedAt:  time.Now(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	j.stdout.mu = &j.mu
+	j.stderr.mu = &j.mu
+	m.jobs[j.info.ID] = j
+	m.mu.Unlock()
+	info := j.info
+	m.Publish(pubsub.CreatedEvent, info)
+
+	go func() {
+		defer close(j.done)
+		err := jobShell.run(ctx, command, &j.stdout, &j.stderr)
+
+		j.mu.Lock()
+		j.info.EndedAt = time.Now()
+		if ctx.Err() != nil {
+			j.info.Status = JobKilled
+		} else {
+			j.info.Status = JobExited
+			j.info.ExitCode = ExitCode(err)
+		}
+		info := j.info
+		j.mu.Unlock()
+		cancel()
+		m.Publish(pubsub.UpdatedEvent, info)
+	}()
+	return info, nil
+}
+
+func (m *jobManager) get(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return j, nil
+}
+
+func (m *jobManager) Get(id string) (Job, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return Job{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info, nil
+}
+
+func (m *jobManager) Output(id string) (Job, JobOutput, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return Job{}, JobOutput{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info, JobOutput{Stdout: j.stdout.take(), Stderr: j.stderr.take()}, nil
+}
+
+func (m *jobManager) List(sessionID string) []Job {
+	m.mu.Lock()
+	jobs := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	m.mu.Unlock()
+
+	list := make([]Job, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		if sessionID == "" || j.info.SessionID == sessionID {
+			list = append(list, j.info)
+		}
+		j.mu.Unlock()
+	}
+	slices.SortFunc(list, func(a, b Job) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return list
+}
+
+func (m *jobManager) Kill(id string) error {
+	j, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	j.cancel()
+	return nil
+}
+
+func (m *jobManager) KillAll() {
+	m.mu.Lock()
+	jobs := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	m.mu.Unlock()
+
+	for _, j := range jobs {
+		j.cancel()
+	}
+	timeout := time.After(5 * time.Second)
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// jobBuffer holds the output of a job until it's read. It shares the lock
+// of its job.
+type jobBuffer struct {
+	mu      *sync.Mutex
+	data    []byte
+	dropped int
+}
+
+func (b *jobBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if over := len(b.data) - maxJobOutput; over > 0 {
+		b.data = slices.Delete(b.data, 0, over)
+		b.dropped += over
+	}
+	return len(p), nil
+}
+
+// take returns the buffered output and empties the buffer. The caller must
+// hold the lock.
+func (b *jobBuffer) take() string {
+	out := string(b.data)
+	if b.dropped > 0 {
+		out = fmt.Sprintf("[%d bytes of earlier output dropped]\n", b.dropped) + out
+	}
+	b.data = b.data[:0]
+	b.dropped = 0
+	return out
+}
@@ -0,0 +1,95 @@
+package shell
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func waitForJob(t *testing.T, jobs JobManager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != JobRunning {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not stop", id)
+	return Job{}
+}
+
+func TestJobManager(t *testing.T) {
+	t.Parallel()
+
+	jobs := NewJobManager()
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+
+	job, err := jobs.Start("session", "echo out; echo err >&2; exit 3", sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != JobRunning {
+		t.Fatalf("Expected job to be running, got %s", job.Status)
+	}
+
+	job = waitForJob(t, jobs, job.ID)
+	if job.Status != JobExited || job.ExitCode != 3 {
+		t.Fatalf("Expected job to exit with code 3, got %s %d", job.Status, job.ExitCode)
+	}
+	_, output, err := jobs.Output(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Stdout != "out\n" || output.Stderr != "err\n" {
+		t.Fatalf("Unexpected output: %q %q", output.Stdout, output.Stderr)
+	}
+	if _, output, _ = jobs.Output(job.ID); output.Stdout != "" {
+		t.Fatalf("Expected output to be read once, got %q", output.Stdout)
+	}
+
+	if got := jobs.List("session"); len(got) != 1 || got[0].ID != job.ID {
+		t.Fatalf("Expected the job in its session, got %v", got)
+	}
+	if got := jobs.List("other"); len(got) != 0 {
+		t.Fatalf("Expected no jobs in another session, got %v", got)
+	}
+	if _, err := jobs.Get("missing"); err != ErrJobNotFound {
+		t.Fatalf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestJobManager_Kill(t *testing.T) {
+	t.Parallel()
+	// XXX(@andreynering): This fails on Windows. Address once possible.
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+
+	jobs := NewJobManager()
+	sh := NewShell(&Options{WorkingDir: t.TempDir()})
+
+	job, err := jobs.Start("session", "sleep 30", sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs.Kill(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job = waitForJob(t, jobs, job.ID); job.Status != JobKilled {
+		t.Fatalf("Expected job to be killed, got %s", job.Status)
+	}
+
+	job, err = jobs.Start("session", "sleep 30", sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs.KillAll()
+	if job, _ = jobs.Get(job.ID); job.Status != JobKilled {
+		t.Fatalf("Expected KillAll to stop the job, got %s", job.Status)
+	}
+}
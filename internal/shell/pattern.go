@@ -0,0 +1,358 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// CommandPattern matches simple commands by their name and arguments. It is
+// written like a command, such as "git push --force" or "curl localhost*":
+//
+//   - The first word matches the program name, without its directory.
+//   - Flags match when the command has them anywhere before "--". Long flags
+//     also match with a value ("--force" matches "--force=true") and short
+//     flags also match in groups ("-rf" matches "-fr" and "-r -f"). For the
+//     commands in flagSpellings, flags also match their other spellings
+//     ("rm -rf" matches "rm -Rf" and "rm --recursive --force"); for other
+//     commands every spelling has to be listed.
+//   - The other words match the arguments that aren't flags, in order.
+//
+// Words can use * wildcards, which match any text including slashes, quoted
+// when the shell would expand them.
+type CommandPattern struct {
+	raw         string
+	name        string
+	flags       []string
+	positionals []string
+}
+
+// ParseCommandPattern parses a pattern. It has to be a single simple command
+// made of literal words.
+func ParseCommandPattern(pattern string) (CommandPattern, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(pattern), "")
+	if err != nil {
+		return CommandPattern{}, fmt.Errorf("invalid command pattern %q: %w", pattern, err)
+	}
+	if len(file.Stmts) != 1 {
+		return CommandPattern{}, fmt.Errorf("invalid command pattern %q: expected a single command", pattern)
+	}
+	stmt := file.Stmts[0]
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 || len(call.Assigns) > 0 || len(stmt.Redirs) > 0 || stmt.Background || stmt.Negated {
+		return CommandPattern{}, fmt.Errorf("invalid command pattern %q: expected a simple command", pattern)
+	}
+
+	words := make([]string, len(call.Args))
+	for i, w := range call.Args {
+		lit, ok := wordLiteral(w)
+		if !ok {
+			return CommandPattern{}, fmt.Errorf("invalid command pattern %q: %s is not a literal word", pattern, printWord(w))
+		}
+		words[i] = lit
+	}
+
+	p := CommandPattern{raw: strings.TrimSpace(pattern), name: words[0]}
+	for _, w := range words[1:] {
+		if isFlag(w) {
+			p.flags = append(p.flags, w)
+		} else {
+			p.positionals = append(p.positionals, w)
+		}
+	}
+	return p, nil
+}
+
+func (p CommandPattern) String() string {
+	return p.raw
+}
+
+// Match reports whether the command has the name and flags of the pattern,
+// and its other words in order, with anything in between. It errs on the
+// side of matching, which suits blocking commands.
+func (p CommandPattern) Match(args []string) bool {
+	positionals, ok := p.matchCommand(args)
+	if !ok {
+		return false
+	}
+	i := 0
+	for _, arg := range positionals {
+		if i < len(p.positionals) && matchWord(p.positionals[i], arg) {
+			i++
+		}
+	}
+	return i == len(p.positionals)
+}
+
+// MatchPrefix reports whether the command has the name and flags of the
+// pattern, and starts with its other words. It errs on the side of not
+// matching, which suits allowing commands.
+func (p CommandPattern) MatchPrefix(args []string) bool {
+	positionals, ok := p.matchCommand(args)
+	if !ok || len(positionals) < len(p.positionals) {
+		return false
+	}
+	for i, want := range p.positionals {
+		if !matchWord(want, positionals[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCommand matches the name and flags and returns the other arguments.
+func (p CommandPattern) matchCommand(args []string) ([]string, bool) {
+	if len(args) == 0 || !matchWord(p.name, filepath.Base(args[0])) {
+		return nil, false
+	}
+
+	spellings := flagSpellings[filepath.Base(args[0])]
+	var flags, positionals []string
+	for i, arg := range args[1:] {
+		if arg == "--" {
+			positionals = append(positionals, args[i+2:]...)
+			break
+		}
+		if isFlag(arg) {
+			flags = append(flags, arg)
+		} else {
+			positionals = append(positionals, arg)
+		}
+	}
+	for _, want := range p.flags {
+		if !hasFlag(flags, want, spellings) {
+			return nil, false
+		}
+	}
+	return positionals, true
+}
+
+func isFlag(word string) bool {
+	return len(word) > 1 && word[0] == '-'
+}
+
+// flagSpellings lists the flags that are spelled several ways, for the
+// commands that blocked command patterns usually name.
+var flagSpellings = map[string][][]string{
+	"rm":    {{"-r", "-R", "--recursive"}, {"-f", "--force"}},
+	"cp":    {{"-r", "-R", "--recursive"}, {"-f", "--force"}},
+	"mv":    {{"-f", "--force"}},
+	"chmod": {{"-R", "--recursive"}},
+	"chown": {{"-R", "--recursive"}},
+	"git":   {{"-f", "--force"}},
+}
+
+// hasFlag reports whether the flags contain the flag or one of its other
+// spellings, also as part of a group of short flags.
+func hasFlag(flags []string, want string, spellings [][]string) bool {
+	same := []string{want}
+	for _, s := range spellings {
+		if slices.Contains(s, want) {
+			same = s
+			break
+		}
+	}
+	for _, spelling := range same {
+		if hasSpelling(flags, spelling) {
+			return true
+		}
+	}
+	if strings.HasPrefix(want, "--") || len(want) <= 2 {
+		return false
+	}
+	// A group of short flags matches when the command has each of them.
+	for _, c := range want[1:] {
+		if !hasFlag(flags, "-"+string(c), spellings) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasSpelling reports whether the flags contain the flag as written, with a
+// value for a long flag, or in a group for a single short flag.
+func hasSpelling(flags []string, want string) bool {
+	short := len(want) == 2 && want != "--"
+	for _, f := range flags {
+		if matchWord(want, f) {
+			return true
+		}
+		if strings.HasPrefix(f, "--") {
+			if name, _, ok := strings.Cut(f, "="); ok && matchWord(want, name) {
+				return true
+			}
+		} else if short && strings.ContainsRune(f[1:], rune(want[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWord matches a word against a pattern where * matches any text.
+func matchWord(pattern, word string) bool {
+	prefix, rest, found := strings.Cut(pattern, "*")
+	if !found {
+		return pattern == word
+	}
+	if !strings.HasPrefix(word, prefix) {
+		return false
+	}
+	word = word[len(prefix):]
+	for i := 0; i <= len(word); i++ {
+		if matchWord(rest, word[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCalls returns the words of the simple commands a command line runs,
+// including the ones in pipelines, subshells, command substitutions and
+// literal "sh -c" scripts, and whether it redirects output to a file. Words
+// that depend on expansions are kept as written, like "$HOME/bin".
+func ParseCalls(command string) ([][]string, bool, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, false, err
+	}
+
+	var calls [][]string
+	writes := false
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.CallExpr:
+			if len(n.Args) == 0 {
+				return true
+			}
+			args := make([]string, len(n.Args))
+			for i, w := range n.Args {
+				lit, ok := wordLiteral(w)
+				if !ok {
+					lit = printWord(w)
+				}
+				args[i] = lit
+			}
+			calls = append(calls, args)
+			if script, ok := shellScript(args); ok {
+				if inner, innerWrites, err := ParseCalls(script); err == nil {
+					calls = append(calls, inner...)
+					writes = writes || innerWrites
+				}
+			}
+		case *syntax.Redirect:
+			switch n.Op {
+			case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.RdrInOut, syntax.ClbOut:
+				if target, ok := wordLiteral(n.Word); !ok || target != "/dev/null" {
+					writes = true
+				}
+			}
+		}
+		return true
+	})
+	return calls, writes, nil
+}
+
+// shellScript returns the script of a "sh -c script" command.
+func shellScript(args []string) (string, bool) {
+	switch filepath.Base(args[0]) {
+	case "sh", "bash", "dash", "zsh", "ksh":
+	default:
+		return "", false
+	}
+	for i, arg := range args[1 : len(args)-1] {
+		if isFlag(arg) && !strings.HasPrefix(arg, "--") && strings.ContainsRune(arg, 'c') {
+			return args[i+2], true
+		}
+	}
+	return "", false
+}
+
+// wrapperFlags are the commands that run the command in their arguments,
+// with their flags that take a value.
+var wrapperFlags = map[string][]string{
+	"command": nil,
+	"env":     {"-u", "--unset", "-C", "--chdir", "-S", "--split-string"},
+	"exec":    {"-a"},
+	"nice":    {"-n", "--adjustment"},
+	"nohup":   nil,
+	"stdbuf":  {"-i", "-o", "-e"},
+	"timeout": {"-s", "--signal", "-k", "--kill-after"},
+	"xargs":   {"-a", "-d", "-E", "-I", "-L", "-n", "-P", "-s", "--arg-file", "--delimiter", "--max-args", "--max-procs", "--max-chars"},
+}
+
+// UnwrapCommand returns the command a wrapper like env, nohup or timeout
+// runs, or nil when args isn't a wrapper running a command.
+func UnwrapCommand(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	name := filepath.Base(args[0])
+	valueFlags, ok := wrapperFlags[name]
+	if !ok {
+		return nil
+	}
+	rest := args[1:]
+loop:
+	for len(rest) > 0 {
+		arg := rest[0]
+		switch {
+		case arg == "--":
+			rest = rest[1:]
+			break loop
+		case isFlag(arg):
+			if name == "command" && arg != "-p" {
+				// command -v and -V look commands up instead of running them.
+				return nil
+			}
+			rest = rest[1:]
+			if slices.Contains(valueFlags, arg) && len(rest) > 0 {
+				rest = rest[1:]
+			}
+		case name == "env" && strings.Contains(arg, "="):
+			rest = rest[1:]
+		default:
+			break loop
+		}
+	}
+	if name == "timeout" && len(rest) > 0 {
+		// Skip the duration.
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	return rest
+}
+
+// wordLiteral returns the value of a word made of literal and quoted parts.
+func wordLiteral(w *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, dp := range p.Parts {
+				lit, ok := dp.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+func printWord(w *syntax.Word) string {
+	var sb strings.Builder
+	_ = syntax.NewPrinter().Print(&sb, w)
+	return sb.String()
+}
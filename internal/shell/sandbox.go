@@ -0,0 +1,191 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Sandbox restricts what the commands of a shell can do. Programs the shell
+// starts can only read the system directories and the listed paths, can only
+// write the listed paths and the temp directory, and have no network access
+// unless allowed. It is only supported on Linux, where it uses Landlock and a
+// network namespace; on other systems sandboxed commands fail to start.
+//
+// Builtins and redirections run in the shell itself and are checked against
+// the same paths. The coreutils the shell implements are not used, so that
+// programs like rm and cp run sandboxed.
+type Sandbox struct {
+	// ReadOnlyPaths are the paths that can be read besides the system
+	// directories.
+	ReadOnlyPaths []string
+	// ReadWritePaths are the paths that can be read and written besides
+	// the temp directory, usually the working directory.
+	ReadWritePaths []string
+	// ProtectedPaths stay read-only even below ReadWritePaths. They are
+	// mounted read-only in a mount namespace, so that commands can't plant
+	// git hooks or change the data and config of the application, which
+	// all run outside the sandbox later. Paths that don't exist yet are
+	// left out, and so can still be created.
+	ProtectedPaths []string
+	AllowNetwork   bool
+}
+
+// sandboxSystemPaths are the directories commands need to run, which are
+// readable when they exist. /run is left out: Landlock doesn't restrict
+// connecting to the Unix sockets there, like the one of Docker, and these
+// are a way out of the sandbox. Only the resolver config it holds on
+// systemd systems is readable.
+var sandboxSystemPaths = []string{
+	"/bin",
+	"/sbin",
+	"/usr",
+	"/lib",
+	"/lib32",
+	"/lib64",
+	"/libx32",
+	"/etc",
+	"/opt",
+	"/nix",
+	"/run/systemd/resolve/stub-resolv.conf",
+	"/run/systemd/resolve/resolv.conf",
+	"/proc",
+	"/sys",
+	"/dev",
+}
+
+// sandboxDevicePaths are the devices commands commonly write to.
+var sandboxDevicePaths = []string{
+	"/dev/null",
+	"/dev/zero",
+	"/dev/full",
+	"/dev/tty",
+	"/dev/shm",
+}
+
+func (sb *Sandbox) readablePaths() []string {
+	return cleanPaths(append(append([]string{}, sandboxSystemPaths...), sb.ReadOnlyPaths...))
+}
+
+func (sb *Sandbox) writablePaths() []string {
+	paths := append([]string{os.TempDir()}, sandboxDevicePaths...)
+	return cleanPaths(append(paths, sb.ReadWritePaths...))
+}
+
+func (sb *Sandbox) protectedPaths() []string {
+	return cleanPaths(sb.ProtectedPaths)
+}
+
+// cleanPaths resolves the symlinks of the paths that exist, since that's how
+// the kernel sees them, and drops the others.
+func cleanPaths(paths []string) []string {
+	cleaned := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			cleaned = append(cleaned, real)
+		}
+	}
+	return cleaned
+}
+
+// allows reports whether path is in or below one of the paths.
+func allows(paths []string, path string) bool {
+	for _, p := range paths {
+		if rel, err := filepath.Rel(p, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath makes path absolute and resolves its symlinks, or those of its
+// parent directory when it doesn't exist yet.
+func resolvePath(dir, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	if real, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(real, filepath.Base(path))
+	}
+	return filepath.Clean(path)
+}
+
+func (sb *Sandbox) checkRead(dir, path string) error {
+	real := resolvePath(dir, path)
+	if allows(sb.readablePaths(), real) || allows(sb.writablePaths(), real) {
+		return nil
+	}
+	return fmt.Errorf("%s: reading is not allowed in the sandbox", path)
+}
+
+func (sb *Sandbox) checkWrite(dir, path string) error {
+	real := resolvePath(dir, path)
+	if allows(sb.writablePaths(), real) && !allows(sb.protectedPaths(), real) {
+		return nil
+	}
+	return fmt.Errorf("%s: writing is not allowed in the sandbox", path)
+}
+
+// openHandler checks the files the shell opens for redirections.
+func (sb *Sandbox) openHandler() interp.OpenHandlerFunc {
+	next := interp.DefaultOpenHandler()
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		dir := interp.HandlerCtx(ctx).Dir
+		check := sb.checkRead
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+			check = sb.checkWrite
+		}
+		if err := check(dir, path); err != nil {
+			return nil, err
+		}
+		return next(ctx, path, flag, perm)
+	}
+}
+
+// readDirHandler checks the directories the shell lists to expand globs.
+func (sb *Sandbox) readDirHandler() interp.ReadDirHandlerFunc2 {
+	next := interp.DefaultReadDirHandler2()
+	return func(ctx context.Context, path string) ([]fs.DirEntry, error) {
+		if err := sb.checkRead(interp.HandlerCtx(ctx).Dir, path); err != nil {
+			return nil, err
+		}
+		return next(ctx, path)
+	}
+}
+
+// execHandler runs programs in the sandbox.
+func (sb *Sandbox) execHandler(ctx context.Context, args []string) error {
+	hc := interp.HandlerCtx(ctx)
+	path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+	if err != nil {
+		fmt.Fprintln(hc.Stderr, err)
+		return interp.ExitStatus(127)
+	}
+
+	var env []string
+	for name, vr := range hc.Env.Each {
+		if vr.IsSet() && vr.Exported {
+			env = append(env, name+"="+vr.String())
+		}
+	}
+
+	cmd, err := sb.command(path, args, env)
+	if err != nil {
+		fmt.Fprintf(hc.Stderr, "%s: %v\n", args[0], err)
+		return interp.ExitStatus(126)
+	}
+	cmd.Dir = hc.Dir
+	cmd.Stdin = hc.Stdin
+	cmd.Stdout = hc.Stdout
+	cmd.Stderr = hc.Stderr
+	return runCommand(ctx, cmd)
+}
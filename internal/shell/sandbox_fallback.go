@@ -0,0 +1,17 @@
+//go:build !linux
+
+package shell
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+func (sb *Sandbox) command(path string, args, env []string) (*exec.Cmd, error) {
+	return nil, errors.New("the sandbox is only supported on Linux")
+}
+
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	return cmd.Run()
+}
@@ -0,0 +1,267 @@
+//go:build linux
+
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// sandboxEnv passes the sandbox of a command to the child process, which is
+// this program run again: it applies the sandbox to itself before it turns
+// into the command.
+const sandboxEnv = "OPENPILOT_SANDBOX"
+
+type sandboxSpec struct {
+	Path      string   `json:"path"`
+	Read      []string `json:"read"`
+	Write     []string `json:"write"`
+	Protected []string `json:"protected"`
+}
+
+func init() {
+	// Package initialization runs on the main thread, which matters since
+	// Landlock restricts the calling thread and the program it executes.
+	if spec, ok := os.LookupEnv(sandboxEnv); ok {
+		err := execSandboxed(spec)
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+var landlockABI = sync.OnceValues(func() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0, fmt.Errorf("landlock is not available: %w", errno)
+	}
+	return int(abi), nil
+})
+
+func (sb *Sandbox) command(path string, args, env []string) (*exec.Cmd, error) {
+	if _, err := landlockABI(); err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	protected := sb.protectedPaths()
+	spec, err := json.Marshal(sandboxSpec{
+		Path:      path,
+		Read:      sb.readablePaths(),
+		Write:     sb.writablePaths(),
+		Protected: protected,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := &exec.Cmd{
+		Path: self,
+		Args: args,
+		Env:  append(slices.Clone(env), sandboxEnv+"="+string(spec)),
+		SysProcAttr: &syscall.SysProcAttr{
+			Setpgid: true,
+		},
+	}
+	if !sb.AllowNetwork || len(protected) > 0 {
+		cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	if !sb.AllowNetwork {
+		// A new network namespace has no interfaces but loopback, which
+		// is down.
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	if len(protected) > 0 {
+		// The child mounts the protected paths read-only in its own mount
+		// namespace, and drops the capabilities it needs for that before
+		// it executes the command.
+		cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS
+		cmd.SysProcAttr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN, unix.CAP_SETPCAP}
+	}
+	return cmd, nil
+}
+
+// execSandboxed restricts the process to the paths of the spec and executes
+// the command. It only returns on failure.
+func execSandboxed(rawSpec string) error {
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(rawSpec), &spec); err != nil {
+		return fmt.Errorf("invalid spec: %w", err)
+	}
+
+	if len(spec.Protected) > 0 {
+		if err := mountReadOnly(spec.Protected); err != nil {
+			return err
+		}
+	}
+
+	abi, err := landlockABI()
+	if err != nil {
+		return err
+	}
+	handled := landlockFSAccess(abi)
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	read := uint64(unix.LANDLOCK_ACCESS_FS_EXECUTE | unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR)
+	for _, p := range spec.Read {
+		if err := addLandlockRule(ruleset, p, read&handled); err != nil {
+			return err
+		}
+	}
+	for _, p := range spec.Write {
+		if err := addLandlockRule(ruleset, p, handled); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+
+	env := slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, sandboxEnv+"=")
+	})
+	return syscall.Exec(spec.Path, os.Args, env)
+}
+
+// mountReadOnly bind mounts the paths read-only over themselves, then drops
+// the capabilities that could undo it, for the process and the command it
+// executes.
+func mountReadOnly(paths []string) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	for _, p := range paths {
+		if err := unix.Mount(p, p, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind mount %s: %w", p, err)
+		}
+		// A remount in a user namespace has to keep the flags the mount
+		// already has.
+		var st unix.Statfs_t
+		if err := unix.Statfs(p, &st); err != nil {
+			return fmt.Errorf("failed to stat %s: %w", p, err)
+		}
+		flags := uintptr(st.Flags) & (unix.MS_NOSUID | unix.MS_NODEV | unix.MS_NOEXEC | unix.MS_NOATIME | unix.MS_NODIRATIME | unix.MS_RELATIME)
+		if err := unix.Mount("", p, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|flags, ""); err != nil {
+			return fmt.Errorf("failed to make %s read-only: %w", p, err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAPBSET_DROP, unix.CAP_SYS_ADMIN, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to drop capabilities: %w", err)
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to drop capabilities: %w", err)
+	}
+	return nil
+}
+
+// landlockFSAccess returns the filesystem rights the Landlock ABI version
+// can restrict.
+func landlockFSAccess(abi int) uint64 {
+	access := uint64(unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		access |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	return access
+}
+
+// landlockFileAccess are the rights that apply to files rather than
+// directories.
+const landlockFileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+	unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_READ_FILE |
+	unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+	unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+
+func addLandlockRule(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= landlockFileAccess
+	}
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow %s: %w", path, errno)
+	}
+	return nil
+}
+
+// runCommand runs the command until it exits or the context is done, and
+// turns its exit status into the error the interpreter expects.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(cmd.Stderr, "%s: %v\n", cmd.Args[0], err)
+		return interp.ExitStatus(126)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		// Kill the whole process group, as the default handler does.
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	})
+	defer stop()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return interp.ExitStatus(128 + int(status.Signal()))
+		}
+		return interp.ExitStatus(exitErr.ExitCode())
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandbox(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	other := filepath.Join(root, "other")
+	for _, dir := range []string{work, other, filepath.Join(root, "tmp")} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The temp directory is writable in the sandbox, so move it away from
+	// the other directory.
+	t.Setenv("TMPDIR", filepath.Join(root, "tmp"))
+
+	shell := NewShell(&Options{
+		WorkingDir: work,
+		Sandbox:    &Sandbox{ReadWritePaths: []string{work}},
+	})
+	if _, stderr, err := shell.Exec(t.Context(), "true"); err != nil {
+		t.Skipf("Sandbox is not available: %v %s", err, stderr)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		allowed bool
+	}{
+		{"write in working dir", "touch inside", true},
+		{"redirect in working dir", "echo hi > inside2", true},
+		{"write outside", "touch " + filepath.Join(other, "outside"), false},
+		{"redirect outside", "echo hi > " + filepath.Join(other, "outside2"), false},
+		{"remove outside", "rm -rf " + other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := shell.Exec(t.Context(), tt.command)
+			if tt.allowed && err != nil {
+				t.Fatalf("Expected command to succeed, got %v: %s", err, stderr)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatal("Expected command to fail")
+			}
+		})
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("Expected directory outside the sandbox to remain: %v", err)
+	}
+
+	stdout, _, err := shell.Exec(t.Context(), "tail -n +3 /proc/self/net/dev | cut -d: -f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interfaces := strings.Fields(stdout); len(interfaces) != 1 || interfaces[0] != "lo" {
+		t.Fatalf("Expected only the loopback interface, got %v", interfaces)
+	}
+}
+
+func TestSandboxProtectedPaths(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	hooks := filepath.Join(work, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(work, ".git", "config"), []byte("[core]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	shell := NewShell(&Options{
+		WorkingDir: work,
+		Sandbox: &Sandbox{
+			ReadWritePaths: []string{work},
+			ProtectedPaths: []string{filepath.Join(work, ".git"), filepath.Join(work, ".missing")},
+		},
+	})
+	if _, stderr, err := shell.Exec(t.Context(), "true"); err != nil {
+		t.Skipf("Sandbox is not available: %v %s", err, stderr)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		allowed bool
+	}{
+		{"read protected", "cat .git/config", true},
+		{"write next to protected", "touch inside", true},
+		{"plant hook", "touch .git/hooks/pre-commit", false},
+		{"redirect into protected", "echo evil > .git/config", false},
+		{"append in a subshell", "sh -c 'echo evil >> .git/config'", false},
+		{"remove protected", "rm -rf .git", false},
+		{"unmount protected", "umount .git", false},
+		{"list /run", "ls /run", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := shell.Exec(t.Context(), tt.command)
+			if tt.allowed && err != nil {
+				t.Fatalf("Expected command to succeed, got %v: %s", err, stderr)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatal("Expected command to fail")
+			}
+		})
+	}
+	if content, err := os.ReadFile(filepath.Join(work, ".git", "config")); err != nil || string(content) != "[core]\n" {
+		t.Fatalf("Expected the git config to be unchanged, got %q, %v", content, err)
+	}
+	if _, err := os.Stat(filepath.Join(hooks, "pre-commit")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no hook to be planted: %v", err)
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
@@ -47,6 +49,9 @@ func (noopLogger) InfoPersist(msg string, keysAndValues ...any) {}
 // BlockFunc is a function that determines if a command should be blocked
 type BlockFunc func(args []string) bool
 
+// ErrBlocked is wrapped by the errors of commands blocked by a BlockFunc.
+var ErrBlocked = errors.New("command is not allowed for security reasons")
+
 // Shell provides cross-platform shell execution with optional state persistence
 type Shell struct {
 	env        []string
@@ -54,6 +59,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	sandbox    *Sandbox
 }
 
 // Options for creating a new shell
@@ -62,6 +68,8 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// Sandbox restricts the commands of the shell when it is set.
+	Sandbox *Sandbox
 }
 
 // NewShell creates a new shell instance with the given options
@@ -90,6 +98,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		sandbox:    opts.Sandbox,
 	}
 }
 
@@ -155,6 +164,13 @@ func (s *Shell) SetBlockFuncs(blockFuncs []BlockFunc) {
 	s.blockFuncs = blockFuncs
 }
 
+// SetSandbox sets the sandbox of the shell, or removes it when nil
+func (s *Shell) SetSandbox(sandbox *Sandbox) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sandbox = sandbox
+}
+
 // CommandsBlocker creates a BlockFunc that blocks exact command matches
 func CommandsBlocker(bannedCommands []string) BlockFunc {
 	bannedSet := make(map[string]bool)
@@ -200,7 +216,7 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 
 			for _, blockFunc := range s.blockFuncs {
 				if blockFunc(args) {
-					return fmt.Errorf("command is not allowed for security reasons: %s", strings.Join(args, " "))
+					return fmt.Errorf("%w: %s", ErrBlocked, strings.Join(args, " "))
 				}
 			}
 
@@ -209,23 +225,45 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 	}
 }
 
+// sandboxHandler runs programs in the sandbox instead of passing them on.
+func (s *Shell) sandboxHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return s.sandbox.execHandler
+	}
+}
+
 // execPOSIX executes commands using POSIX shell emulation (cross-platform)
 func (s *Shell) execPOSIX(ctx context.Context, command string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := s.run(ctx, command, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+// run executes the command, writing its output as it goes.
+func (s *Shell) run(ctx context.Context, command string, stdout, stderr io.Writer) error {
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
-		return "", "", fmt.Errorf("could not parse command: %w", err)
+		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	var stdout, stderr bytes.Buffer
-	runner, err := interp.New(
-		interp.StdIO(nil, &stdout, &stderr),
+	opts := []interp.RunnerOption{
+		interp.StdIO(nil, stdout, stderr),
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(s.env...)),
 		interp.Dir(s.cwd),
-		interp.ExecHandlers(s.blockHandler(), coreutils.ExecHandler),
-	)
+	}
+	if s.sandbox != nil {
+		opts = append(opts,
+			interp.ExecHandlers(s.blockHandler(), s.sandboxHandler()),
+			interp.OpenHandler(s.sandbox.openHandler()),
+			interp.ReadDirHandler2(s.sandbox.readDirHandler()),
+		)
+	} else {
+		opts = append(opts, interp.ExecHandlers(s.blockHandler(), coreutils.ExecHandler))
+	}
+	runner, err := interp.New(opts...)
 	if err != nil {
-		return "", "", fmt.Errorf("could not run command: %w", err)
+		return fmt.Errorf("could not run command: %w", err)
 	}
 
 	err = runner.Run(ctx, line)
@@ -235,7 +273,21 @@ func (s *Shell) execPOSIX(ctx context.Context, command string) (string, string,
 		s.env = append(s.env, fmt.Sprintf("%s=%s", name, vr.Str))
 	}
 	s.logger.InfoPersist("POSIX command finished", "command", command, "err", err)
-	return stdout.String(), stderr.String(), err
+	return err
+}
+
+// clone returns a shell with the same state, which commands can change
+// without affecting this one.
+func (s *Shell) clone() *Shell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Shell{
+		cwd:        s.cwd,
+		env:        slices.Clone(s.env),
+		logger:     s.logger,
+		blockFuncs: s.blockFuncs,
+		sandbox:    s.sandbox,
+	}
 }
 
 // IsInterrupt checks if an error is due to interruption
@@ -0,0 +1,147 @@
+package transcript
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/diff"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+)
+
+// Markdown renders the first session of the transcript as a readable
+// document, with the changes made to every file as a unified diff.
+// Sub-agent sessions are rendered inline where the agent tool was called.
+func Markdown(t Transcript) (string, error) {
+	if len(t.Sessions) == 0 {
+		return "", fmt.Errorf("transcript contains no sessions")
+	}
+	sessions := make(map[string]Session, len(t.Sessions))
+	for _, s := range t.Sessions {
+		sessions[s.ID] = s
+	}
+
+	s := t.Sessions[0]
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	fmt.Fprintf(&b, "- Session: `%s`\n", s.ID)
+	fmt.Fprintf(&b, "- Created: %s\n", time.Unix(s.CreatedAt, 0).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Tokens: %d prompt, %d completion\n", s.PromptTokens, s.CompletionTokens)
+	fmt.Fprintf(&b, "- Cost: $%.4f\n", s.Cost)
+	if err := writeMessages(&b, s, sessions, 2); err != nil {
+		return "", err
+	}
+	writeFiles(&b, s)
+	return b.String(), nil
+}
+
+func writeMessages(b *strings.Builder, s Session, sessions map[string]Session, level int) error {
+	heading := strings.Repeat("#", level)
+	for _, m := range s.Messages {
+		parts, err := message.UnmarshalParts(m.Parts)
+		if err != nil {
+			return fmt.Errorf("failed to decode message %s: %w", m.ID, err)
+		}
+
+		switch message.MessageRole(m.Role) {
+		case message.User:
+			fmt.Fprintf(b, "\n%s User\n\n", heading)
+		case message.Assistant:
+			fmt.Fprintf(b, "\n%s Assistant", heading)
+			if m.Model != "" {
+				fmt.Fprintf(b, " (%s)", m.Model)
+			}
+			b.WriteString("\n\n")
+		case message.Tool:
+			// Results are written under the calls they belong to.
+			continue
+		default:
+			fmt.Fprintf(b, "\n%s %s\n\n", heading, m.Role)
+		}
+
+		for _, part := range parts {
+			switch part := part.(type) {
+			case message.ReasoningContent:
+				if part.Thinking != "" {
+					fmt.Fprintf(b, "<details>\n<summary>Reasoning</summary>\n\n%s\n\n</details>\n\n", strings.TrimSpace(part.Thinking))
+				}
+			case message.TextContent:
+				if text := strings.TrimSpace(part.Text); text != "" {
+					b.WriteString(text + "\n\n")
+				}
+			case message.BinaryContent:
+				fmt.Fprintf(b, "*Attachment: %s (%s)*\n\n", filepath.Base(part.Path), part.MIMEType)
+			case message.ImageURLContent:
+				fmt.Fprintf(b, "*Image: %s*\n\n", part.URL)
+			case message.ToolCall:
+				fmt.Fprintf(b, "%s# Tool call: %s\n\n", heading, part.Name)
+				writeFence(b, "json", part.Input)
+				if result, ok := findToolResult(s, part.ID); ok {
+					label := "Result"
+					if result.IsError {
+						label = "Error"
+					}
+					fmt.Fprintf(b, "%s:\n\n", label)
+					writeFence(b, "", result.Content)
+				}
+				if child, ok := sessions[part.ID]; ok {
+					if err := writeMessages(b, child, sessions, min(level+2, 6)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func findToolResult(s Session, toolCallID string) (message.ToolResult, bool) {
+	for _, m := range s.Messages {
+		if message.MessageRole(m.Role) != message.Tool {
+			continue
+		}
+		parts, err := message.UnmarshalParts(m.Parts)
+		if err != nil {
+			continue
+		}
+		for _, part := range parts {
+			if result, ok := part.(message.ToolResult); ok && result.ToolCallID == toolCallID {
+				return result, true
+			}
+		}
+	}
+	return message.ToolResult{}, false
+}
+
+func writeFiles(b *strings.Builder, s Session) {
+	var paths []string
+	versions := make(map[string][]File)
+	for _, f := range s.Files {
+		if _, ok := versions[f.Path]; !ok {
+			paths = append(paths, f.Path)
+		}
+		versions[f.Path] = append(versions[f.Path], f)
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	b.WriteString("\n## Files changed\n\n")
+	for _, path := range paths {
+		first, last := versions[path][0], versions[path][len(versions[path])-1]
+		patch, additions, removals := diff.GenerateDiff(first.Content, last.Content, path)
+		fmt.Fprintf(b, "### %s (+%d -%d)\n\n", path, additions, removals)
+		writeFence(b, "diff", patch)
+	}
+}
+
+// writeFence writes content in a code block whose fence is longer than any
+// backtick run inside it.
+func writeFence(b *strings.Builder, lang, content string) {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(content, "\n"), fence)
+}
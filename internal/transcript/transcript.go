@@ -0,0 +1,289 @@
+// Package transcript exports sessions, including their messages and file
+// history, to a portable format and imports them back.
+package transcript
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+)
+
+// Version is the current version of the JSON transcript format. It must be
+// bumped whenever a change makes older readers import transcripts wrongly.
+//
+// Version 2 stores the paths of files in the working directory relative to
+// it. Version 3 marks the initial versions of files that did not exist.
+const Version = 3
+
+type Transcript struct {
+	Version    int   `json:"version"`
+	ExportedAt int64 `json:"exported_at"`
+	// Sessions holds the exported session first, followed by the sessions
+	// of the sub-agents it ran.
+	Sessions []Session `json:"sessions"`
+}
+
+type Session struct {
+	ID               string    `json:"id"`
+	ParentSessionID  string    `json:"parent_session_id,omitempty"`
+	Title            string    `json:"title"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+	SummaryMessageID string    `json:"summary_message_id,omitempty"`
+	KeptMessageID    string    `json:"kept_message_id,omitempty"`
+	ForkMessageID    string    `json:"fork_message_id,omitempty"`
+	CreatedAt        int64     `json:"created_at"`
+	UpdatedAt        int64     `json:"updated_at"`
+	Messages         []Message `json:"messages"`
+	Files            []File    `json:"files"`
+}
+
+type Message struct {
+	ID          string `json:"id"`
+	Role        string `json:"role"`
+	Model       string `json:"model,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	CreatedAtMs int64  `json:"created_at_ms,omitempty"`
+	UpdatedAt   int64  `json:"updated_at"`
+	FinishedAt  int64  `json:"finished_at,omitempty"`
+	// Parts are kept in the format the database stores them in, so text,
+	// reasoning, tool calls and results and binary attachments round-trip
+	// unchanged.
+	Parts json.RawMessage `json:"parts"`
+}
+
+type File struct {
+	ID string `json:"id"`
+	// Path is relative to the working directory, with forward slashes, for
+	// files inside it, and absolute otherwise.
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Version     int64  `json:"version"`
+	CreatedAt   int64  `json:"created_at"`
+	CreatedAtMs int64  `json:"created_at_ms,omitempty"`
+	UpdatedAt   int64  `json:"updated_at"`
+	IsNew       bool   `json:"is_new,omitempty"`
+}
+
+// Export reads the session with the given ID and the sessions of the
+// sub-agents it ran. The paths of files in workingDir are made relative to
+// it.
+func Export(ctx context.Context, q db.Querier, sessionID, workingDir string) (Transcript, error) {
+	t := Transcript{
+		Version:    Version,
+		ExportedAt: time.Now().Unix(),
+	}
+	pending := []string{sessionID}
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		s, err := exportSession(ctx, q, id, workingDir)
+		if errors.Is(err, sql.ErrNoRows) && id != sessionID {
+			// The sub-agent never got to create its session.
+			continue
+		}
+		if err != nil {
+			return Transcript{}, err
+		}
+		t.Sessions = append(t.Sessions, s)
+
+		for _, msg := range s.Messages {
+			parts, err := message.UnmarshalParts(msg.Parts)
+			if err != nil {
+				return Transcript{}, fmt.Errorf("failed to decode message %s: %w", msg.ID, err)
+			}
+			for _, part := range parts {
+				if call, ok := part.(message.ToolCall); ok && call.Name == tools.AgentToolName {
+					pending = append(pending, call.ID)
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+func exportSession(ctx context.Context, q db.Querier, id, workingDir string) (Session, error) {
+	dbSession, err := q.GetSessionByID(ctx, id)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	s := Session{
+		ID:               dbSession.ID,
+		ParentSessionID:  dbSession.ParentSessionID.String,
+		Title:            dbSession.Title,
+		PromptTokens:     dbSession.PromptTokens,
+		CompletionTokens: dbSession.CompletionTokens,
+		Cost:             dbSession.Cost,
+		SummaryMessageID: dbSession.SummaryMessageID.String,
+		KeptMessageID:    dbSession.KeptMessageID.String,
+		ForkMessageID:    dbSession.ForkMessageID.String,
+		CreatedAt:        dbSession.CreatedAt,
+		UpdatedAt:        dbSession.UpdatedAt,
+		Messages:         []Message{},
+		Files:            []File{},
+	}
+
+	dbMessages, err := q.ListMessagesBySession(ctx, id)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	for _, m := range dbMessages {
+		s.Messages = append(s.Messages, Message{
+			ID:          m.ID,
+			Role:        m.Role,
+			Model:       m.Model.String,
+			Provider:    m.Provider.String,
+			CreatedAt:   m.CreatedAt,
+			CreatedAtMs: m.CreatedAtMs,
+			UpdatedAt:   m.UpdatedAt,
+			FinishedAt:  m.FinishedAt.Int64,
+			Parts:       json.RawMessage(m.Parts),
+		})
+	}
+
+	dbFiles, err := q.ListFilesBySession(ctx, id)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to list files: %w", err)
+	}
+	for _, f := range dbFiles {
+		s.Files = append(s.Files, File{
+			ID:          f.ID,
+			Path:        relativePath(workingDir, f.Path),
+			Content:     f.Content,
+			Version:     f.Version,
+			CreatedAt:   f.CreatedAt,
+			CreatedAtMs: f.CreatedAtMs,
+			UpdatedAt:   f.UpdatedAt,
+			IsNew:       f.IsNew,
+		})
+	}
+	return s, nil
+}
+
+// Import recreates the rows of every session in the transcript. Relative
+// file paths are rebased on workingDir. Run it in a transaction so a failed
+// import leaves nothing behind.
+func Import(ctx context.Context, q db.Querier, t Transcript, workingDir string) error {
+	for _, s := range t.Sessions {
+		if _, err := q.GetSessionByID(ctx, s.ID); err == nil {
+			return fmt.Errorf("session %s already exists", s.ID)
+		}
+		_, err := q.ImportSession(ctx, db.ImportSessionParams{
+			ID:               s.ID,
+			ParentSessionID:  nullString(s.ParentSessionID),
+			Title:            s.Title,
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			Cost:             s.Cost,
+			SummaryMessageID: nullString(s.SummaryMessageID),
+			KeptMessageID:    nullString(s.KeptMessageID),
+			ForkMessageID:    nullString(s.ForkMessageID),
+			UpdatedAt:        s.UpdatedAt,
+			CreatedAt:        s.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import session %s: %w", s.ID, err)
+		}
+
+		for _, m := range s.Messages {
+			_, err := q.CopyMessage(ctx, db.CopyMessageParams{
+				ID:          m.ID,
+				SessionID:   s.ID,
+				Role:        m.Role,
+				Parts:       string(m.Parts),
+				Model:       sql.NullString{String: m.Model, Valid: true},
+				Provider:    nullString(m.Provider),
+				CreatedAt:   m.CreatedAt,
+				UpdatedAt:   m.UpdatedAt,
+				FinishedAt:  sql.NullInt64{Int64: m.FinishedAt, Valid: m.FinishedAt != 0},
+				CreatedAtMs: millis(m.CreatedAtMs, m.CreatedAt),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to import message %s: %w", m.ID, err)
+			}
+		}
+
+		for _, f := range s.Files {
+			_, err := q.ImportFile(ctx, db.ImportFileParams{
+				ID:          f.ID,
+				SessionID:   s.ID,
+				Path:        absolutePath(workingDir, f.Path),
+				Content:     f.Content,
+				Version:     f.Version,
+				CreatedAt:   f.CreatedAt,
+				UpdatedAt:   f.UpdatedAt,
+				CreatedAtMs: millis(f.CreatedAtMs, f.CreatedAt),
+				IsNew:       isNew(f, t.Version),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to import file %s: %w", f.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Decode reads a JSON transcript, rejecting versions this build can't
+// import faithfully.
+func Decode(r io.Reader) (Transcript, error) {
+	var t Transcript
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return Transcript{}, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	if t.Version < 1 || t.Version > Version {
+		return Transcript{}, fmt.Errorf("unsupported transcript version %d", t.Version)
+	}
+	if len(t.Sessions) == 0 {
+		return Transcript{}, fmt.Errorf("transcript contains no sessions")
+	}
+	return t, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func relativePath(workingDir, path string) string {
+	rel, err := filepath.Rel(workingDir, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func absolutePath(workingDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workingDir, filepath.FromSlash(path))
+}
+
+// isNew falls back to taking an empty initial version for a file that did
+// not exist, for transcripts exported before that was recorded.
+func isNew(f File, version int) bool {
+	if version >= 3 {
+		return f.IsNew
+	}
+	return f.Version == 0 && f.Content == ""
+}
+
+// millis falls back to the second timestamp for transcripts exported before
+// millisecond timestamps were recorded.
+func millis(ms, seconds int64) int64 {
+	if ms != 0 {
+		return ms
+	}
+	return seconds * 1000
+}
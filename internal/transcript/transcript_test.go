@@ -0,0 +1,98 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/db"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	exportDir, importDir := t.TempDir(), t.TempDir()
+	src := newQueries(t)
+	_, err := src.CreateSession(ctx, db.CreateSessionParams{ID: "main", Title: "Fix the build"})
+	require.NoError(t, err)
+	_, err = src.CreateSession(ctx, db.CreateSessionParams{ID: "call-1", Title: "New Agent Session"})
+	require.NoError(t, err)
+
+	msgs := message.NewService(src)
+	_, err = msgs.Create(ctx, "main", message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "fix it"}},
+	})
+	require.NoError(t, err)
+	_, err = msgs.Create(ctx, "main", message.CreateMessageParams{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.ReasoningContent{Thinking: "look around first"},
+			message.ToolCall{ID: "call-1", Name: tools.AgentToolName, Input: `{"prompt":"find it"}`, Finished: true},
+		},
+	})
+	require.NoError(t, err)
+	_, err = msgs.Create(ctx, "main", message.CreateMessageParams{
+		Role:  message.Tool,
+		Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call-1", Content: "found it"}},
+	})
+	require.NoError(t, err)
+	_, err = src.CreateFile(ctx, db.CreateFileParams{ID: "f0", SessionID: "main", Path: filepath.Join(exportDir, "main.go"), Content: "a\n", Version: 0})
+	require.NoError(t, err)
+	_, err = src.CreateFile(ctx, db.CreateFileParams{ID: "f1", SessionID: "main", Path: filepath.Join(exportDir, "main.go"), Content: "b\n", Version: 1})
+	require.NoError(t, err)
+	_, err = src.CreateFile(ctx, db.CreateFileParams{ID: "f2", SessionID: "main", Path: filepath.Join(exportDir, "new.go"), Version: 0, IsNew: true})
+	require.NoError(t, err)
+
+	exported, err := Export(ctx, src, "main", exportDir)
+	require.NoError(t, err)
+	require.Len(t, exported.Sessions, 2, "sub-agent sessions are exported with their parent")
+	require.Equal(t, "main.go", exported.Sessions[0].Files[0].Path, "paths are relative to the working directory")
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(exported))
+	decoded, err := Decode(&buf)
+	require.NoError(t, err)
+
+	dst := newQueries(t)
+	require.NoError(t, Import(ctx, dst, decoded, importDir))
+	require.Error(t, Import(ctx, dst, decoded, importDir), "existing sessions are not overwritten")
+	file, err := dst.GetFile(ctx, "f0")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(importDir, "main.go"), file.Path, "paths are rebased on the working directory")
+	file, err = dst.GetFile(ctx, "f2")
+	require.NoError(t, err)
+	require.True(t, file.IsNew)
+
+	imported, err := Export(ctx, dst, "main", importDir)
+	require.NoError(t, err)
+	imported.ExportedAt = exported.ExportedAt
+	require.Equal(t, exported, imported)
+
+	md, err := Markdown(imported)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(md, "# Fix the build\n"))
+	require.Contains(t, md, "found it")
+	require.Contains(t, md, "+b")
+}
+
+func TestDecodeRejectsUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := Decode(strings.NewReader(`{"version": 99, "sessions": [{"id": "a"}]}`))
+	require.ErrorContains(t, err, "unsupported transcript version")
+}
+
+func newQueries(t *testing.T) *db.Queries {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return db.New(conn)
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 
 	"github.com/JyotirmoyDas05/openpilot/internal/app"
-	"github.com/JyotirmoyDas05/openpilot/internal/llm/agent"
+	"github.com/JyotirmoyDas05/openpilot/internal/llm/tools"
 	"github.com/JyotirmoyDas05/openpilot/internal/message"
 	"github.com/JyotirmoyDas05/openpilot/internal/permission"
 	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
@@ -24,6 +24,8 @@ import (
 type SendMsg struct {
 	Text        string
 	Attachments []message.Attachment
+	// EditMessageID is set when the message replaces an earlier prompt.
+	EditMessageID string
 }
 
 type SessionSelectedMsg = session.Session
@@ -337,6 +339,10 @@ func (m *messageListCmp) handleMessageEvent(event pubsub.Event[message.Message])
 		case message.Tool:
 			return m.handleToolMessage(event.Payload)
 		}
+	case pubsub.DeletedEvent:
+		if event.Payload.SessionID == m.session.ID {
+			return m.handleDeletedMessage(event.Payload)
+		}
 	}
 	return nil
 }
@@ -353,6 +359,27 @@ func (m *messageListCmp) messageExists(messageID string) bool {
 	return false
 }
 
+// handleDeletedMessage removes the items of a deleted message from the list:
+// the message itself, its tool calls and its footer.
+func (m *messageListCmp) handleDeletedMessage(msg message.Message) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, item := range m.listCmp.Items() {
+		var messageID string
+		switch item := item.(type) {
+		case messages.MessageCmp:
+			messageID = item.GetMessage().ID
+		case messages.ToolCallCmp:
+			messageID = item.ParentMessageID()
+		case messages.AssistantSection:
+			messageID = item.ParentMessageID()
+		}
+		if messageID == msg.ID {
+			cmds = append(cmds, m.listCmp.DeleteItem(item.ID()))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
 // handleNewMessage routes new messages to appropriate handlers based on role.
 func (m *messageListCmp) handleNewMessage(msg message.Message) tea.Cmd {
 	switch msg.Role {
@@ -612,7 +639,7 @@ func (m *messageListCmp) convertAssistantMessage(msg message.Message, toolResult
 		options := m.buildToolCallOptions(tc, msg, toolResultMap)
 		uiMessages = append(uiMessages, messages.NewToolCallCmp(msg.ID, tc, m.app.Permissions, options...))
 		// If this tool call is the agent tool, fetch nested tool calls
-		if tc.Name == agent.AgentToolName {
+		if tc.Name == tools.AgentToolName {
 			nestedMessages, _ := m.app.Messages.List(context.Background(), tc.ID)
 			nestedToolResultMap := m.buildToolResultMap(nestedMessages)
 			nestedUIMessages := m.convertMessagesToUI(nestedMessages, nestedToolResultMap)
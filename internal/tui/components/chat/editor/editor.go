@@ -22,6 +22,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core/layout"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/commands"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/editprompt"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/filepicker"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/quit"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/styles"
@@ -61,6 +62,9 @@ type editorCmp struct {
 	readyPlaceholder   string
 	workingPlaceholder string
 
+	// ID of the earlier prompt being edited, if any
+	editMessageID string
+
 	keyMap EditorKeyMap
 
 	// File path completions
@@ -149,8 +153,10 @@ func (m *editorCmp) send() tea.Cmd {
 
 	m.textarea.Reset()
 	attachments := m.attachments
+	editMessageID := m.editMessageID
 
 	m.attachments = nil
+	m.editMessageID = ""
 	if value == "" {
 		return nil
 	}
@@ -160,8 +166,9 @@ func (m *editorCmp) send() tea.Cmd {
 
 	return tea.Batch(
 		util.CmdHandler(chat.SendMsg{
-			Text:        value,
-			Attachments: attachments,
+			Text:          value,
+			Attachments:   attachments,
+			EditMessageID: editMessageID,
 		}),
 	)
 }
@@ -218,6 +225,15 @@ func (m *editorCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case OpenEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case editprompt.PromptSelectedMsg:
+		if m.app.CoderAgent.IsSessionBusy(m.session.ID) {
+			return m, util.ReportWarn("Agent is working, please wait...")
+		}
+		m.editMessageID = msg.MessageID
+		m.attachments = msg.Attachments
+		m.textarea.SetValue(msg.Text)
+		m.textarea.MoveToEnd()
+		return m, util.ReportInfo("Editing an earlier prompt, sending it will replace the messages after it")
 	case tea.PasteMsg:
 		path := strings.ReplaceAll(string(msg), "\\ ", " ")
 		// try to get an image
@@ -526,6 +542,7 @@ func (c *editorCmp) Bindings() []key.Binding {
 // we need to move some functionality to the page level
 func (c *editorCmp) SetSession(session session.Session) tea.Cmd {
 	c.session = session
+	c.editMessageID = ""
 	return nil
 }
 
@@ -358,6 +358,7 @@ func (m *messageCmp) Spinning() bool {
 type AssistantSection interface {
 	list.Item
 	layout.Sizeable
+	ParentMessageID() string
 }
 type assistantSectionModel struct {
 	width               int
@@ -371,6 +372,10 @@ func (m *assistantSectionModel) ID() string {
 	return m.id
 }
 
+func (m *assistantSectionModel) ParentMessageID() string {
+	return m.message.ID
+}
+
 func NewAssistantSection(message message.Message, lastUserMessageTime time.Time) AssistantSection {
 	return &assistantSectionModel{
 		width:               0,
@@ -163,6 +163,8 @@ func (br baseRenderer) renderError(v *toolCallCmp, message string) string {
 // Register tool renderers
 func init() {
 	registry.register(tools.BashToolName, func() renderer { return bashRenderer{} })
+	registry.register(tools.JobOutputToolName, func() renderer { return jobRenderer{} })
+	registry.register(tools.JobKillToolName, func() renderer { return jobRenderer{} })
 	registry.register(tools.DownloadToolName, func() renderer { return downloadRenderer{} })
 	registry.register(tools.ViewToolName, func() renderer { return viewRenderer{} })
 	registry.register(tools.EditToolName, func() renderer { return editRenderer{} })
@@ -174,7 +176,13 @@ func init() {
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
-	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
+	registry.register(tools.DefinitionToolName, func() renderer { return lspPositionRenderer{} })
+	registry.register(tools.ReferencesToolName, func() renderer { return lspPositionRenderer{} })
+	registry.register(tools.HoverToolName, func() renderer { return lspPositionRenderer{} })
+	registry.register(tools.SymbolsToolName, func() renderer { return symbolsRenderer{} })
+	registry.register(tools.RenameToolName, func() renderer { return renameRenderer{} })
+	registry.register(tools.CodeActionToolName, func() renderer { return codeActionRenderer{} })
+	registry.register(tools.AgentToolName, func() renderer { return agentRenderer{} })
 }
 
 // -----------------------------------------------------------------------------
@@ -211,11 +219,14 @@ func (br bashRenderer) Render(v *toolCallCmp) string {
 
 	cmd := strings.ReplaceAll(params.Command, "\n", " ")
 	cmd = strings.ReplaceAll(cmd, "\t", "    ")
-	args := newParamBuilder().addMain(cmd).build()
+	args := newParamBuilder().
+		addMain(cmd).
+		addFlag("background", params.RunInBackground).
+		build()
 
 	return br.renderWithParams(v, "Bash", args, func() string {
 		var meta tools.BashResponseMetadata
-		if err := br.unmarshalParams(v.result.Metadata, &meta); err != nil {
+		if err := br.unmarshalParams(v.result.Metadata, &meta); err != nil || meta.JobID != "" {
 			return renderPlainContent(v, v.result.Content)
 		}
 		// for backwards compatibility with older tool calls.
@@ -230,6 +241,28 @@ func (br bashRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Job renderer
+// -----------------------------------------------------------------------------
+
+// jobRenderer handles reading the output of background jobs and killing them
+type jobRenderer struct {
+	baseRenderer
+}
+
+// Render displays the job id with plain content output
+func (jr jobRenderer) Render(v *toolCallCmp) string {
+	var params tools.JobParams
+	if err := jr.unmarshalParams(v.call.Input, &params); err != nil {
+		return jr.renderError(v, "Invalid job parameters")
+	}
+	args := newParamBuilder().addMain(params.JobID).build()
+
+	return jr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  View renderer
 // -----------------------------------------------------------------------------
@@ -589,6 +622,102 @@ func (dr diagnosticsRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  LSP navigation renderers
+// -----------------------------------------------------------------------------
+
+// lspPositionRenderer handles the LSP tools that operate on a position in a file
+type lspPositionRenderer struct {
+	baseRenderer
+}
+
+// Render displays the file, line and symbol with plain content output
+func (lr lspPositionRenderer) Render(v *toolCallCmp) string {
+	var params tools.DefinitionParams
+	var args []string
+	if err := lr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(fsext.PrettyPath(params.FilePath)).
+			addKeyValue("line", formatNonZero(params.Line)).
+			addKeyValue("symbol", params.Symbol).
+			build()
+	}
+
+	return lr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// symbolsRenderer handles file outlines and workspace symbol searches
+type symbolsRenderer struct {
+	baseRenderer
+}
+
+// Render displays the query or file with plain content output
+func (sr symbolsRenderer) Render(v *toolCallCmp) string {
+	var params tools.SymbolsParams
+	var args []string
+	if err := sr.unmarshalParams(v.call.Input, &params); err == nil {
+		builder := newParamBuilder()
+		if params.FilePath != "" {
+			builder.addMain(fsext.PrettyPath(params.FilePath)).addKeyValue("query", params.Query)
+		} else {
+			builder.addMain(params.Query)
+		}
+		args = builder.build()
+	}
+
+	return sr.renderWithParams(v, "Symbols", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// renameRenderer handles LSP symbol renames across the workspace
+type renameRenderer struct {
+	baseRenderer
+}
+
+// Render displays the symbol location and new name with plain content output
+func (rr renameRenderer) Render(v *toolCallCmp) string {
+	var params tools.RenameParams
+	var args []string
+	if err := rr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(fsext.PrettyPath(params.FilePath)).
+			addKeyValue("line", formatNonZero(params.Line)).
+			addKeyValue("symbol", params.Symbol).
+			addKeyValue("new_name", params.NewName).
+			build()
+	}
+
+	return rr.renderWithParams(v, "Rename", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// codeActionRenderer handles listing and applying LSP code actions
+type codeActionRenderer struct {
+	baseRenderer
+}
+
+// Render displays the file, line and selected action with plain content output
+func (cr codeActionRenderer) Render(v *toolCallCmp) string {
+	var params tools.CodeActionParams
+	var args []string
+	if err := cr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(fsext.PrettyPath(params.FilePath)).
+			addKeyValue("line", formatNonZero(params.Line)).
+			addKeyValue("title", params.Title).
+			addKeyValue("kind", params.Kind).
+			build()
+	}
+
+	return cr.renderWithParams(v, "Code Action", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Task renderer
 // -----------------------------------------------------------------------------
@@ -862,10 +991,14 @@ func truncateHeight(s string, h int) string {
 
 func prettifyToolName(name string) string {
 	switch name {
-	case agent.AgentToolName:
+	case tools.AgentToolName:
 		return "Agent"
 	case tools.BashToolName:
 		return "Bash"
+	case tools.JobOutputToolName:
+		return "Job Output"
+	case tools.JobKillToolName:
+		return "Job Kill"
 	case tools.DownloadToolName:
 		return "Download"
 	case tools.EditToolName:
@@ -886,6 +1019,18 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.DefinitionToolName:
+		return "Definition"
+	case tools.ReferencesToolName:
+		return "References"
+	case tools.HoverToolName:
+		return "Hover"
+	case tools.SymbolsToolName:
+		return "Symbols"
+	case tools.RenameToolName:
+		return "Rename"
+	case tools.CodeActionToolName:
+		return "Code Action"
 	default:
 		return name
 	}
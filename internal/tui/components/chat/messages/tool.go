@@ -358,7 +358,53 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 		}
 	case tools.DiagnosticsToolName:
 		return "**Project:** diagnostics"
-	case agent.AgentToolName:
+	case tools.DefinitionToolName, tools.ReferencesToolName, tools.HoverToolName:
+		var params tools.DefinitionParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath)))
+			parts = append(parts, fmt.Sprintf("**Line:** %d", params.Line))
+			if params.Symbol != "" {
+				parts = append(parts, fmt.Sprintf("**Symbol:** %s", params.Symbol))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.RenameToolName:
+		var params tools.RenameParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath)))
+			parts = append(parts, fmt.Sprintf("**Line:** %d", params.Line))
+			if params.Symbol != "" {
+				parts = append(parts, fmt.Sprintf("**Symbol:** %s", params.Symbol))
+			}
+			parts = append(parts, fmt.Sprintf("**New Name:** %s", params.NewName))
+			return strings.Join(parts, "\n")
+		}
+	case tools.CodeActionToolName:
+		var params tools.CodeActionParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath)))
+			parts = append(parts, fmt.Sprintf("**Line:** %d", params.Line))
+			if params.Title != "" {
+				parts = append(parts, fmt.Sprintf("**Action:** %s", params.Title))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.SymbolsToolName:
+		var params tools.SymbolsParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			if params.FilePath != "" {
+				parts = append(parts, fmt.Sprintf("**File:** %s", fsext.PrettyPath(params.FilePath)))
+			}
+			if params.Query != "" {
+				parts = append(parts, fmt.Sprintf("**Query:** %s", params.Query))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.AgentToolName:
 		var params agent.AgentParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
 			return fmt.Sprintf("**Task:**\n%s", params.Prompt)
@@ -395,9 +441,10 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatWriteResultForCopy()
 	case tools.FetchToolName:
 		return m.formatFetchResultForCopy()
-	case agent.AgentToolName:
+	case tools.AgentToolName:
 		return m.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName,
+		tools.DefinitionToolName, tools.ReferencesToolName, tools.SymbolsToolName:
 		return fmt.Sprintf("```\n%s\n```", m.result.Content)
 	default:
 		return m.result.Content
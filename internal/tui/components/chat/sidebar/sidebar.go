@@ -15,6 +15,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/lsp"
 	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
 	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/chat"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core/layout"
@@ -72,13 +73,15 @@ type sidebarCmp struct {
 	lspClients    map[string]*lsp.Client
 	compactMode   bool
 	history       history.Service
+	jobs          shell.JobManager
 	files         *csync.Map[string, SessionFile]
 }
 
-func New(history history.Service, lspClients map[string]*lsp.Client, compact bool) Sidebar {
+func New(history history.Service, jobs shell.JobManager, lspClients map[string]*lsp.Client, compact bool) Sidebar {
 	return &sidebarCmp{
 		lspClients:  lspClients,
 		history:     history,
+		jobs:        jobs,
 		compactMode: compact,
 		files:       csync.NewMap[string, SessionFile](),
 	}
@@ -103,6 +106,9 @@ func (m *sidebarCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.logo = m.logoBlock()
 	case pubsub.Event[history.File]:
 		return m, m.handleFileHistoryEvent(msg)
+	case pubsub.Event[shell.Job]:
+		// The jobs block reads the running jobs when it renders.
+		return m, nil
 	case pubsub.Event[session.Session]:
 		if msg.Type == pubsub.UpdatedEvent {
 			if m.session.ID == msg.Payload.ID {
@@ -165,6 +171,9 @@ func (m *sidebarCmp) View() string {
 		// Vertical layout (default)
 		if m.session.ID != "" {
 			parts = append(parts, "", m.filesBlock())
+			if jobs := m.jobsBlock(); jobs != "" {
+				parts = append(parts, "", jobs)
+			}
 		}
 		parts = append(parts,
 			"",
@@ -525,6 +534,38 @@ func (m *sidebarCmp) mcpBlock() string {
 	}, true)
 }
 
+// jobsBlock renders the background jobs running in the session, or nothing
+// when there are none.
+func (m *sidebarCmp) jobsBlock() string {
+	t := styles.CurrentTheme()
+	var running []shell.Job
+	for _, job := range m.jobs.List(m.session.ID) {
+		if job.Status == shell.JobRunning {
+			running = append(running, job)
+		}
+	}
+	if len(running) == 0 {
+		return ""
+	}
+
+	maxJobs, _, _ := m.getDynamicLimits()
+	jobList := []string{core.Section("Jobs", m.getMaxWidth()), ""}
+	for i, job := range running {
+		if i >= maxJobs {
+			jobList = append(jobList, t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf("…and %d more", len(running)-maxJobs)))
+			break
+		}
+		jobList = append(jobList, core.Status(core.StatusOpts{
+			Icon:        t.ItemBusyIcon.String(),
+			Title:       job.ID,
+			Description: job.Command,
+		}, m.getMaxWidth()))
+	}
+	return lipgloss.NewStyle().Width(m.getMaxWidth()).Render(
+		lipgloss.JoinVertical(lipgloss.Left, jobList...),
+	)
+}
+
 func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	t := styles.CurrentTheme()
 	// Format tokens in human-readable format (e.g., 110K, 1.2M)
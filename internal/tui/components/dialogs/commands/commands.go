@@ -72,9 +72,19 @@ type (
 	ToggleThinkingMsg     struct{}
 	OpenExternalEditorMsg struct{}
 	ToggleYoloModeMsg     struct{}
+	ShowGrantsMsg         struct{}
 	CompactMsg            struct {
 		SessionID string
 	}
+	RevertMsg struct {
+		SessionID string
+	}
+	ForkSessionMsg struct {
+		SessionID string
+	}
+	EditPromptMsg struct {
+		SessionID string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -308,6 +318,36 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				})
 			},
 		})
+		commands = append(commands, Command{
+			ID:          "edit_prompt",
+			Title:       "Edit Previous Prompt",
+			Description: "Edit an earlier prompt and run the agent again from there",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(EditPromptMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+		commands = append(commands, Command{
+			ID:          "fork_session",
+			Title:       "Fork Session",
+			Description: "Continue the current session from an earlier prompt in a new session",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ForkSessionMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+		commands = append(commands, Command{
+			ID:          "revert",
+			Title:       "Revert Changes",
+			Description: "Restore files changed in this session to an earlier version",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(RevertMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
 	}
 
 	// Only show thinking toggle for Anthropic models that can reason
@@ -381,6 +421,14 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "revoke_permissions",
+			Title:       "Revoke Permissions",
+			Description: "Review and revoke the permissions that were always allowed",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ShowGrantsMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
@@ -0,0 +1,190 @@
+package editprompt
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/exp/list"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/styles"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/util"
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const EditPromptDialogID dialogs.DialogID = "edit_prompt"
+
+// PromptSelectedMsg is sent when an earlier prompt is chosen to be edited
+// and sent again.
+type PromptSelectedMsg struct {
+	MessageID   string
+	Text        string
+	Attachments []message.Attachment
+}
+
+// EditPromptDialog interface for the edit prompt dialog
+type EditPromptDialog interface {
+	dialogs.DialogModel
+}
+
+type PromptsList = list.FilterableList[list.CompletionItem[PromptSelectedMsg]]
+
+type editPromptDialogCmp struct {
+	wWidth      int
+	wHeight     int
+	width       int
+	keyMap      KeyMap
+	promptsList PromptsList
+	help        help.Model
+}
+
+// NewEditPromptDialogCmp creates a dialog listing the prompts of a session,
+// newest first.
+func NewEditPromptDialogCmp(messages []message.Message) EditPromptDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	var items []list.CompletionItem[PromptSelectedMsg]
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != message.User {
+			continue
+		}
+		text := msg.Content().Text
+		var attachments []message.Attachment
+		for _, binary := range msg.BinaryContent() {
+			attachments = append(attachments, message.Attachment{
+				FilePath: binary.Path,
+				FileName: filepath.Base(binary.Path),
+				MimeType: binary.MIMEType,
+				Content:  binary.Data,
+			})
+		}
+		title, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+		items = append(items, list.NewCompletionItem(
+			title,
+			PromptSelectedMsg{MessageID: msg.ID, Text: text, Attachments: attachments},
+			list.WithCompletionID(msg.ID),
+		))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	promptsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Choose the prompt to edit"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &editPromptDialogCmp{
+		keyMap:      DefaultKeyMap(),
+		promptsList: promptsList,
+		help:        help,
+	}
+}
+
+func (e *editPromptDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(e.promptsList.Init(), e.promptsList.Focus())
+}
+
+func (e *editPromptDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.wWidth = msg.Width
+		e.wHeight = msg.Height
+		e.width = min(120, e.wWidth-8)
+		e.promptsList.SetInputWidth(e.listWidth() - 2)
+		return e, e.promptsList.SetSize(e.listWidth(), e.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, e.keyMap.Select):
+			selectedItem := e.promptsList.SelectedItem()
+			if selectedItem != nil {
+				selected := *selectedItem
+				return e, tea.Sequence(
+					util.CmdHandler(dialogs.CloseDialogMsg{}),
+					util.CmdHandler(selected.Value()),
+				)
+			}
+		case key.Matches(msg, e.keyMap.Close):
+			return e, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := e.promptsList.Update(msg)
+			e.promptsList = u.(PromptsList)
+			return e, cmd
+		}
+	}
+	return e, nil
+}
+
+func (e *editPromptDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Edit Prompt", e.width-4)),
+		e.promptsList.View(),
+		"",
+		t.S().Base.Width(e.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(e.help.View(e.keyMap)),
+	)
+	return e.style().Render(content)
+}
+
+func (e *editPromptDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := e.promptsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = e.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (e *editPromptDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(e.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (e *editPromptDialogCmp) listHeight() int {
+	return e.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (e *editPromptDialogCmp) listWidth() int {
+	return e.width - 2 // 2 for the border
+}
+
+func (e *editPromptDialogCmp) Position() (int, int) {
+	row := e.wHeight/4 - 2 // just a bit above the center
+	col := e.wWidth / 2
+	col -= e.width / 2
+	return row, col
+}
+
+func (e *editPromptDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := e.Position()
+	offset := row + 3 // Border + title
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements EditPromptDialog.
+func (e *editPromptDialogCmp) ID() dialogs.DialogID {
+	return EditPromptDialogID
+}
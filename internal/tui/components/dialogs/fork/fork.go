@@ -0,0 +1,181 @@
+package fork
+
+import (
+	"strings"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/exp/list"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/styles"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/util"
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const ForkDialogID dialogs.DialogID = "fork"
+
+// ForkPointSelectedMsg is sent when the message to fork the session at is
+// chosen.
+type ForkPointSelectedMsg struct {
+	SessionID string
+	MessageID string
+}
+
+// ForkDialog interface for the session fork dialog
+type ForkDialog interface {
+	dialogs.DialogModel
+}
+
+type ForkPointsList = list.FilterableList[list.CompletionItem[ForkPointSelectedMsg]]
+
+type forkDialogCmp struct {
+	wWidth     int
+	wHeight    int
+	width      int
+	keyMap     KeyMap
+	pointsList ForkPointsList
+	help       help.Model
+}
+
+// NewForkDialogCmp creates a dialog listing the turns of a session, newest
+// first. Forking at a turn keeps the prompt and everything the agent did in
+// response to it.
+func NewForkDialogCmp(sessionID string, messages []message.Message) ForkDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	var items []list.CompletionItem[ForkPointSelectedMsg]
+	end := len(messages) - 1
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != message.User {
+			continue
+		}
+		prompt, _, _ := strings.Cut(strings.TrimSpace(msg.Content().Text), "\n")
+		items = append(items, list.NewCompletionItem(
+			"Fork after: "+prompt,
+			ForkPointSelectedMsg{SessionID: sessionID, MessageID: messages[end].ID},
+			list.WithCompletionID(msg.ID),
+		))
+		end = i - 1
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	pointsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Choose the prompt to fork after"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &forkDialogCmp{
+		keyMap:     DefaultKeyMap(),
+		pointsList: pointsList,
+		help:       help,
+	}
+}
+
+func (f *forkDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(f.pointsList.Init(), f.pointsList.Focus())
+}
+
+func (f *forkDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.wWidth = msg.Width
+		f.wHeight = msg.Height
+		f.width = min(120, f.wWidth-8)
+		f.pointsList.SetInputWidth(f.listWidth() - 2)
+		return f, f.pointsList.SetSize(f.listWidth(), f.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, f.keyMap.Select):
+			selectedItem := f.pointsList.SelectedItem()
+			if selectedItem != nil {
+				selected := *selectedItem
+				return f, tea.Sequence(
+					util.CmdHandler(dialogs.CloseDialogMsg{}),
+					util.CmdHandler(selected.Value()),
+				)
+			}
+		case key.Matches(msg, f.keyMap.Close):
+			return f, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := f.pointsList.Update(msg)
+			f.pointsList = u.(ForkPointsList)
+			return f, cmd
+		}
+	}
+	return f, nil
+}
+
+func (f *forkDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Fork Session", f.width-4)),
+		f.pointsList.View(),
+		"",
+		t.S().Base.Width(f.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(f.help.View(f.keyMap)),
+	)
+	return f.style().Render(content)
+}
+
+func (f *forkDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := f.pointsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = f.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (f *forkDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(f.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (f *forkDialogCmp) listHeight() int {
+	return f.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (f *forkDialogCmp) listWidth() int {
+	return f.width - 2 // 2 for the border
+}
+
+func (f *forkDialogCmp) Position() (int, int) {
+	row := f.wHeight/4 - 2 // just a bit above the center
+	col := f.wWidth / 2
+	col -= f.width / 2
+	return row, col
+}
+
+func (f *forkDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := f.Position()
+	offset := row + 3 // Border + title
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements ForkDialog.
+func (f *forkDialogCmp) ID() dialogs.DialogID {
+	return ForkDialogID
+}
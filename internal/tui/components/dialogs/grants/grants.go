@@ -0,0 +1,177 @@
+package grants
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/fsext"
+	"github.com/JyotirmoyDas05/openpilot/internal/permission"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/exp/list"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/styles"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/util"
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const GrantsDialogID dialogs.DialogID = "grants"
+
+// RevokeGrantMsg is sent when a grant is chosen to be revoked.
+type RevokeGrantMsg struct {
+	Grant permission.Grant
+}
+
+// GrantsDialog interface for the permission grants dialog
+type GrantsDialog interface {
+	dialogs.DialogModel
+}
+
+type GrantsList = list.FilterableList[list.CompletionItem[RevokeGrantMsg]]
+
+type grantsDialogCmp struct {
+	wWidth     int
+	wHeight    int
+	width      int
+	keyMap     KeyMap
+	grantsList GrantsList
+	help       help.Model
+}
+
+// NewGrantsDialogCmp creates a dialog listing the permissions granted with
+// "Always Allow", to choose one to revoke.
+func NewGrantsDialogCmp(grants []permission.Grant) GrantsDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	items := make([]list.CompletionItem[RevokeGrantMsg], 0, len(grants))
+	for _, g := range grants {
+		title := fmt.Sprintf("%s %s in %s", g.ToolName, g.Action, fsext.PrettyPath(g.Path))
+		if g.ExpiresAt != 0 {
+			title += fmt.Sprintf(" (until %s)", time.Unix(g.ExpiresAt, 0).Format(time.DateOnly))
+		}
+		items = append(items, list.NewCompletionItem(
+			title,
+			RevokeGrantMsg{Grant: g},
+			list.WithCompletionID(g.ID),
+		))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	grantsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Choose a permission to revoke"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &grantsDialogCmp{
+		keyMap:     DefaultKeyMap(),
+		grantsList: grantsList,
+		help:       help,
+	}
+}
+
+func (g *grantsDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(g.grantsList.Init(), g.grantsList.Focus())
+}
+
+func (g *grantsDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		g.wWidth = msg.Width
+		g.wHeight = msg.Height
+		g.width = min(120, g.wWidth-8)
+		g.grantsList.SetInputWidth(g.listWidth() - 2)
+		return g, g.grantsList.SetSize(g.listWidth(), g.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, g.keyMap.Select):
+			selectedItem := g.grantsList.SelectedItem()
+			if selectedItem != nil {
+				selected := *selectedItem
+				return g, tea.Sequence(
+					util.CmdHandler(dialogs.CloseDialogMsg{}),
+					util.CmdHandler(selected.Value()),
+				)
+			}
+		case key.Matches(msg, g.keyMap.Close):
+			return g, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := g.grantsList.Update(msg)
+			g.grantsList = u.(GrantsList)
+			return g, cmd
+		}
+	}
+	return g, nil
+}
+
+func (g *grantsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Revoke Permissions", g.width-4)),
+		g.grantsList.View(),
+		"",
+		t.S().Base.Width(g.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(g.help.View(g.keyMap)),
+	)
+	return g.style().Render(content)
+}
+
+func (g *grantsDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := g.grantsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = g.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (g *grantsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(g.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (g *grantsDialogCmp) listHeight() int {
+	return g.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (g *grantsDialogCmp) listWidth() int {
+	return g.width - 2 // 2 for the border
+}
+
+func (g *grantsDialogCmp) Position() (int, int) {
+	row := g.wHeight/4 - 2 // just a bit above the center
+	col := g.wWidth / 2
+	col -= g.width / 2
+	return row, col
+}
+
+func (g *grantsDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := g.Position()
+	offset := row + 3 // Border + title
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements GrantsDialog.
+func (g *grantsDialogCmp) ID() dialogs.DialogID {
+	return GrantsDialogID
+}
@@ -25,6 +25,7 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowAlways     PermissionAction = "allow_always"
 	PermissionDeny            PermissionAction = "deny"
 
 	PermissionsDialogID dialogs.DialogID = "permissions"
@@ -49,7 +50,7 @@ type permissionDialogCmp struct {
 	height          int
 	permission      permission.PermissionRequest
 	contentViewPort viewport.Model
-	selectedOption  int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption  int // index in options()
 
 	// Diff view state
 	defaultDiffSplitMode bool  // true for split, false for unified
@@ -74,6 +75,9 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		opts = &Options{}
 	}
 
+	keyMap := DefaultKeyMap()
+	keyMap.AllowAlways.SetEnabled(!permission.NoPersistentGrant)
+
 	// Create viewport for content
 	contentViewport := viewport.New()
 	return &permissionDialogCmp{
@@ -81,7 +85,7 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		selectedOption:  0, // Default to "Allow"
 		permission:      permission,
 		diffSplitMode:   opts.isSplitMode(),
-		keyMap:          DefaultKeyMap(),
+		keyMap:          keyMap,
 		contentDirty:    true, // Mark as dirty initially
 	}
 }
@@ -91,7 +95,11 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	switch p.permission.ToolName {
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionToolName:
+		return true
+	}
+	return false
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -107,10 +115,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % len(p.options())
 			return p, nil
 		case key.Matches(msg, p.keyMap.Left):
-			p.selectedOption = (p.selectedOption + 2) % 3
+			n := len(p.options())
+			p.selectedOption = (p.selectedOption + n - 1) % n
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
@@ -123,6 +132,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
 			)
+		case key.Matches(msg, p.keyMap.AllowAlways):
+			return p, tea.Batch(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowAlways, Permission: p.permission}),
+			)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
@@ -218,18 +232,17 @@ func (p *permissionDialogCmp) isMouseOverDialog(x, y int) bool {
 	return x >= dialogX && x < dialogX+dialogWidth && y >= dialogY && y < dialogY+dialogHeight
 }
 
-func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
-	var action PermissionAction
-
-	switch p.selectedOption {
-	case 0:
-		action = PermissionAllow
-	case 1:
-		action = PermissionAllowForSession
-	case 2:
-		action = PermissionDeny
+// options returns the answers offered for the request, in order. Requests
+// that can't be always allowed don't offer it.
+func (p *permissionDialogCmp) options() []PermissionAction {
+	if p.permission.NoPersistentGrant {
+		return []PermissionAction{PermissionAllow, PermissionAllowForSession, PermissionDeny}
 	}
+	return []PermissionAction{PermissionAllow, PermissionAllowForSession, PermissionAllowAlways, PermissionDeny}
+}
 
+func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
+	action := p.options()[p.selectedOption]
 	return tea.Batch(
 		util.CmdHandler(PermissionResponseMsg{Action: action, Permission: p.permission}),
 		util.CmdHandler(dialogs.CloseDialogMsg{}),
@@ -240,22 +253,24 @@ func (p *permissionDialogCmp) renderButtons() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base
 
-	buttons := []core.ButtonOpts{
-		{
-			Text:           "Allow",
-			UnderlineIndex: 0, // "A"
-			Selected:       p.selectedOption == 0,
-		},
-		{
-			Text:           "Allow for Session",
-			UnderlineIndex: 10, // "S" in "Session"
-			Selected:       p.selectedOption == 1,
-		},
-		{
-			Text:           "Deny",
-			UnderlineIndex: 0, // "D"
-			Selected:       p.selectedOption == 2,
-		},
+	var buttons []core.ButtonOpts
+	for i, action := range p.options() {
+		button := core.ButtonOpts{Selected: p.selectedOption == i}
+		switch action {
+		case PermissionAllow:
+			button.Text = "Allow"
+			button.UnderlineIndex = 0 // "A"
+		case PermissionAllowForSession:
+			button.Text = "Allow for Session"
+			button.UnderlineIndex = 10 // "S" in "Session"
+		case PermissionAllowAlways:
+			button.Text = "Always Allow"
+			button.UnderlineIndex = 2 // "w" in "Always"
+		case PermissionDeny:
+			button.Text = "Deny"
+			button.UnderlineIndex = 0 // "D"
+		}
+		buttons = append(buttons, button)
 	}
 
 	content := core.SelectableButtons(buttons, "  ")
@@ -370,6 +385,20 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.RenameToolName, tools.CodeActionToolName:
+		params := p.permission.Params.(tools.WorkspaceEditPermissionsParams)
+		filesKey := t.S().Muted.Render("Files")
+		filesValue := t.S().Text.
+			Width(p.width - lipgloss.Width(filesKey)).
+			Render(fmt.Sprintf(" %d", len(params.Files)))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				filesKey,
+				filesValue,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.FetchToolName:
 		headerParts = append(headerParts, t.S().Muted.Width(p.width).Bold(true).Render("URL"))
 	case tools.ViewToolName:
@@ -424,6 +453,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.RenameToolName, tools.CodeActionToolName:
+		content = p.generateWorkspaceEditContent()
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.ViewToolName:
@@ -556,6 +587,38 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+// generateWorkspaceEditContent renders the diff of every file touched by a
+// workspace edit one after the other, scrolled as a single document.
+func (p *permissionDialogCmp) generateWorkspaceEditContent() string {
+	pr, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	var diffs []string
+	for _, file := range pr.Files {
+		formatter := core.DiffFormatter().
+			Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+			After(fsext.PrettyPath(file.FilePath), file.NewContent).
+			Width(p.contentViewPort.Width()).
+			XOffset(p.diffXOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+
+	lines := strings.Split(strings.Join(diffs, "\n\n"), "\n")
+	p.diffYOffset = min(p.diffYOffset, max(0, len(lines)-p.contentViewPort.Height()))
+	lines = lines[p.diffYOffset:]
+	if height := p.contentViewPort.Height(); height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -743,6 +806,9 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.MultiEditToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
+	case tools.RenameToolName, tools.CodeActionToolName:
+		p.width = int(float64(p.wWidth) * 0.8)
+		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.3)
@@ -0,0 +1,197 @@
+package revert
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/history"
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/exp/list"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/styles"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/util"
+	"github.com/charmbracelet/bubbles/v2/help"
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const RevertDialogID dialogs.DialogID = "revert"
+
+// RestorePointSelectedMsg is sent when a restore point is chosen. Exactly
+// one of the fields is set.
+type RestorePointSelectedMsg struct {
+	// MessageID reverts every file change made since the message was sent.
+	MessageID string
+	// FileID restores a single file to that version.
+	FileID string
+}
+
+// RevertDialog interface for the revert dialog
+type RevertDialog interface {
+	dialogs.DialogModel
+}
+
+type RestorePointsList = list.FilterableList[list.CompletionItem[RestorePointSelectedMsg]]
+
+type revertDialogCmp struct {
+	wWidth     int
+	wHeight    int
+	width      int
+	keyMap     KeyMap
+	pointsList RestorePointsList
+	help       help.Model
+}
+
+// NewRevertDialogCmp creates a dialog listing the prompts and file versions
+// of a session that can be restored, newest first.
+func NewRevertDialogCmp(messages []message.Message, files []history.File, workingDir string) RevertDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	var items []list.CompletionItem[RestorePointSelectedMsg]
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != message.User {
+			continue
+		}
+		prompt, _, _ := strings.Cut(strings.TrimSpace(msg.Content().Text), "\n")
+		items = append(items, list.NewCompletionItem(
+			"Undo changes since: "+prompt,
+			RestorePointSelectedMsg{MessageID: msg.ID},
+			list.WithCompletionID(msg.ID),
+		))
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		path, err := filepath.Rel(workingDir, file.Path)
+		if err != nil {
+			path = file.Path
+		}
+		title := fmt.Sprintf("Restore %s to v%d (%s)", path, file.Version, time.Unix(file.CreatedAt, 0).Format(time.Kitchen))
+		items = append(items, list.NewCompletionItem(
+			title,
+			RestorePointSelectedMsg{FileID: file.ID},
+			list.WithCompletionID(file.ID),
+		))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	pointsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Choose a prompt or file version"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &revertDialogCmp{
+		keyMap:     DefaultKeyMap(),
+		pointsList: pointsList,
+		help:       help,
+	}
+}
+
+func (r *revertDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(r.pointsList.Init(), r.pointsList.Focus())
+}
+
+func (r *revertDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		r.width = min(120, r.wWidth-8)
+		r.pointsList.SetInputWidth(r.listWidth() - 2)
+		return r, r.pointsList.SetSize(r.listWidth(), r.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Select):
+			selectedItem := r.pointsList.SelectedItem()
+			if selectedItem != nil {
+				selected := *selectedItem
+				return r, tea.Sequence(
+					util.CmdHandler(dialogs.CloseDialogMsg{}),
+					util.CmdHandler(selected.Value()),
+				)
+			}
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := r.pointsList.Update(msg)
+			r.pointsList = u.(RestorePointsList)
+			return r, cmd
+		}
+	}
+	return r, nil
+}
+
+func (r *revertDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Revert Changes", r.width-4)),
+		r.pointsList.View(),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return r.style().Render(content)
+}
+
+func (r *revertDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := r.pointsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = r.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (r *revertDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (r *revertDialogCmp) listHeight() int {
+	return r.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (r *revertDialogCmp) listWidth() int {
+	return r.width - 2 // 2 for the border
+}
+
+func (r *revertDialogCmp) Position() (int, int) {
+	row := r.wHeight/4 - 2 // just a bit above the center
+	col := r.wWidth / 2
+	col -= r.width / 2
+	return row, col
+}
+
+func (r *revertDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := r.Position()
+	offset := row + 3 // Border + title
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+// ID implements RevertDialog.
+func (r *revertDialogCmp) ID() dialogs.DialogID {
+	return RevertDialogID
+}
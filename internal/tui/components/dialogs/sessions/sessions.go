@@ -1,6 +1,12 @@
 package sessions
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/message"
 	"github.com/JyotirmoyDas05/openpilot/internal/session"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/chat"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
@@ -10,12 +16,18 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/util"
 	"github.com/charmbracelet/bubbles/v2/help"
 	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
 )
 
 const SessionsDialogID dialogs.DialogID = "sessions"
 
+const (
+	searchDebounce = 200 * time.Millisecond
+	searchLimit    = 50
+)
+
 // SessionDialog interface for the session switching dialog
 type SessionDialog interface {
 	dialogs.DialogModel
@@ -23,6 +35,16 @@ type SessionDialog interface {
 
 type SessionsList = list.FilterableList[list.CompletionItem[session.Session]]
 
+// searchMsg runs the search for query once typing has paused.
+type searchMsg struct {
+	query string
+}
+
+type searchResultsMsg struct {
+	query   string
+	results []message.SearchResult
+}
+
 type sessionDialogCmp struct {
 	selectedInx       int
 	wWidth            int
@@ -32,10 +54,19 @@ type sessionDialogCmp struct {
 	keyMap            KeyMap
 	sessionsList      SessionsList
 	help              help.Model
+
+	// In search mode the dialog lists the messages matching the search
+	// input instead of filtering sessions by title.
+	searching   bool
+	messages    message.Service
+	sessions    map[string]session.Session
+	searchInput textinput.Model
+	resultsList SessionsList
 }
 
-// NewSessionDialogCmp creates a new session switching dialog
-func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionDialog {
+// NewSessionDialogCmp creates a new session switching dialog. Messages are
+// used to search the content of all sessions.
+func NewSessionDialogCmp(sessions []session.Session, selectedID string, messages message.Service) SessionDialog {
 	t := styles.CurrentTheme()
 	listKeyMap := list.DefaultKeyMap()
 	keyMap := DefaultKeyMap()
@@ -44,11 +75,13 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 	listKeyMap.DownOneItem = keyMap.Next
 	listKeyMap.UpOneItem = keyMap.Previous
 
-	items := make([]list.CompletionItem[session.Session], len(sessions))
-	if len(sessions) > 0 {
-		for i, session := range sessions {
-			items[i] = list.NewCompletionItem(session.Title, session, list.WithCompletionID(session.ID))
+	items := make([]list.CompletionItem[session.Session], 0, len(sessions))
+	for _, entry := range lineage(sessions) {
+		title := entry.session.Title
+		if entry.depth > 0 {
+			title = strings.Repeat("  ", entry.depth-1) + "↳ " + title
 		}
+		items = append(items, list.NewCompletionItem(title, entry.session, list.WithCompletionID(entry.session.ID)))
 	}
 
 	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
@@ -61,6 +94,24 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 			list.WithWrapNavigation(),
 		),
 	)
+	resultsList := list.NewFilterableList(
+		[]list.CompletionItem[session.Session]{},
+		list.WithFilterInputHidden(),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	searchInput := textinput.New()
+	searchInput.Placeholder = "Search messages in all sessions"
+	searchInput.SetVirtualCursor(false)
+	searchInput.SetStyles(t.S().TextInput)
+
+	byID := make(map[string]session.Session, len(sessions))
+	for _, sess := range sessions {
+		byID[sess.ID] = sess
+	}
+
 	help := help.New()
 	help.Styles = t.S().Help
 	s := &sessionDialogCmp{
@@ -68,6 +119,10 @@ func NewSessionDialogCmp(sessions []session.Session, selectedID string) SessionD
 		keyMap:            DefaultKeyMap(),
 		sessionsList:      sessionsList,
 		help:              help,
+		messages:          messages,
+		sessions:          byID,
+		searchInput:       searchInput,
+		resultsList:       resultsList,
 	}
 
 	return s
@@ -89,12 +144,40 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.width = min(120, s.wWidth-8)
 		s.sessionsList.SetInputWidth(s.listWidth() - 2)
 		cmds = append(cmds, s.sessionsList.SetSize(s.listWidth(), s.listHeight()))
+		s.searchInput.SetWidth(s.listWidth() - 2)
+		cmds = append(cmds, s.resultsList.SetSize(s.listWidth(), s.listHeight()-s.searchInputHeight()))
 		if s.selectedSessionID != "" {
 			cmds = append(cmds, s.sessionsList.SetSelected(s.selectedSessionID))
 		}
 		return s, tea.Batch(cmds...)
+	case searchMsg:
+		if msg.query != s.searchInput.Value() {
+			return s, nil
+		}
+		return s, s.search(msg.query)
+	case searchResultsMsg:
+		if msg.query != s.searchInput.Value() {
+			return s, nil
+		}
+		items := make([]list.CompletionItem[session.Session], 0, len(msg.results))
+		for _, result := range msg.results {
+			sess, ok := s.sessions[result.SessionID]
+			if !ok {
+				continue
+			}
+			title := fmt.Sprintf("%s · %s", sess.Title, result.Snippet)
+			items = append(items, list.NewCompletionItem(title, sess, list.WithCompletionID(result.MessageID)))
+		}
+		return s, s.resultsList.SetItems(items)
 	case tea.KeyPressMsg:
+		if s.searching {
+			return s, s.updateSearch(msg)
+		}
 		switch {
+		case key.Matches(msg, s.keyMap.Search):
+			s.searching = true
+			s.sessionsList.Blur()
+			return s, tea.Batch(s.searchInput.Focus(), s.resultsList.Focus())
 		case key.Matches(msg, s.keyMap.Select):
 			selectedItem := s.sessionsList.SelectedItem()
 			if selectedItem != nil {
@@ -117,12 +200,88 @@ func (s *sessionDialogCmp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return s, nil
 }
 
+// updateSearch handles key presses in search mode.
+func (s *sessionDialogCmp) updateSearch(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, s.keyMap.Search):
+		s.searching = false
+		s.searchInput.Blur()
+		s.resultsList.Blur()
+		return s.sessionsList.Focus()
+	case key.Matches(msg, s.keyMap.Select):
+		selectedItem := s.resultsList.SelectedItem()
+		if selectedItem == nil {
+			return nil
+		}
+		selected := *selectedItem
+		return tea.Sequence(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			util.CmdHandler(
+				chat.SessionSelectedMsg(selected.Value()),
+			),
+		)
+	case key.Matches(msg, s.keyMap.Close):
+		return util.CmdHandler(dialogs.CloseDialogMsg{})
+	case key.Matches(msg, s.keyMap.Next), key.Matches(msg, s.keyMap.Previous):
+		u, cmd := s.resultsList.Update(msg)
+		s.resultsList = u.(SessionsList)
+		return cmd
+	}
+
+	previous := s.searchInput.Value()
+	var cmd tea.Cmd
+	s.searchInput, cmd = s.searchInput.Update(msg)
+	query := s.searchInput.Value()
+	if query == previous {
+		return cmd
+	}
+	if strings.TrimSpace(query) == "" {
+		return tea.Batch(cmd, s.resultsList.SetItems([]list.CompletionItem[session.Session]{}))
+	}
+	return tea.Batch(cmd, tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return searchMsg{query: query}
+	}))
+}
+
+func (s *sessionDialogCmp) search(query string) tea.Cmd {
+	if s.messages == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		results, err := s.messages.Search(context.Background(), query, searchLimit)
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return searchResultsMsg{query: query, results: results}
+	}
+}
+
+func (s *sessionDialogCmp) searchInputHeight() int {
+	return lipgloss.Height(s.searchInputStyle().Render(s.searchInput.View()))
+}
+
+func (s *sessionDialogCmp) searchInputStyle() lipgloss.Style {
+	return styles.CurrentTheme().S().Base.PaddingLeft(1).PaddingBottom(1)
+}
+
 func (s *sessionDialogCmp) View() string {
 	t := styles.CurrentTheme()
+	title := "Switch Session"
 	listView := s.sessionsList.View()
+	if s.searching {
+		title = "Search Sessions"
+		listView = lipgloss.JoinVertical(
+			lipgloss.Left,
+			s.searchInputStyle().Render(s.searchInput.View()),
+			s.resultsList.View(),
+		)
+	}
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
-		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Session", s.width-4)),
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, s.width-4)),
 		listView,
 		"",
 		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(s.help.View(s.keyMap)),
@@ -132,6 +291,13 @@ func (s *sessionDialogCmp) View() string {
 }
 
 func (s *sessionDialogCmp) Cursor() *tea.Cursor {
+	if s.searching {
+		cursor := s.searchInput.Cursor()
+		if cursor != nil {
+			cursor = s.moveCursor(cursor)
+		}
+		return cursor
+	}
 	if cursor, ok := s.sessionsList.(util.Cursor); ok {
 		cursor := cursor.Cursor()
 		if cursor != nil {
@@ -177,3 +343,39 @@ func (s *sessionDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
 func (s *sessionDialogCmp) ID() dialogs.DialogID {
 	return SessionsDialogID
 }
+
+type lineageEntry struct {
+	session session.Session
+	depth   int
+}
+
+// lineage orders sessions so every fork is listed right below the session it
+// was forked from, keeping the original order among siblings.
+func lineage(sessions []session.Session) []lineageEntry {
+	ids := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		ids[s.ID] = true
+	}
+	children := make(map[string][]session.Session)
+	var roots []session.Session
+	for _, s := range sessions {
+		if s.ForkMessageID != "" && ids[s.ParentSessionID] {
+			children[s.ParentSessionID] = append(children[s.ParentSessionID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	entries := make([]lineageEntry, 0, len(sessions))
+	var walk func(s session.Session, depth int)
+	walk = func(s session.Session, depth int) {
+		entries = append(entries, lineageEntry{session: s, depth: depth})
+		for _, child := range children[s.ID] {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	return entries
+}
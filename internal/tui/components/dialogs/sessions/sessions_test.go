@@ -0,0 +1,29 @@
+package sessions
+
+import (
+	"testing"
+
+	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineage(t *testing.T) {
+	t.Parallel()
+
+	sessions := []session.Session{
+		{ID: "fork-of-fork", ParentSessionID: "fork", ForkMessageID: "m2"},
+		{ID: "fork", ParentSessionID: "root", ForkMessageID: "m1"},
+		{ID: "other"},
+		{ID: "orphan", ParentSessionID: "deleted", ForkMessageID: "m3"},
+		{ID: "root"},
+	}
+
+	var got []string
+	var depths []int
+	for _, entry := range lineage(sessions) {
+		got = append(got, entry.session.ID)
+		depths = append(depths, entry.depth)
+	}
+	require.Equal(t, []string{"other", "orphan", "root", "fork", "fork-of-fork"}, got)
+	require.Equal(t, []int{0, 0, 0, 1, 2}, depths)
+}
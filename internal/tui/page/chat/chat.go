@@ -11,6 +11,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/permission"
 	"github.com/JyotirmoyDas05/openpilot/internal/pubsub"
 	"github.com/JyotirmoyDas05/openpilot/internal/session"
+	"github.com/JyotirmoyDas05/openpilot/internal/shell"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/anim"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/chat"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/chat/editor"
@@ -22,6 +23,7 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/core/layout"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/commands"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/editprompt"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/filepicker"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/models"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/page"
@@ -118,7 +120,7 @@ func New(app *app.App) ChatPage {
 		app:         app,
 		keyMap:      DefaultKeyMap(),
 		header:      header.New(app.LSPClients),
-		sidebar:     sidebar.New(app.History, app.LSPClients, false),
+		sidebar:     sidebar.New(app.History, app.Jobs, app.LSPClients, false),
 		chat:        chat.New(app),
 		editor:      editor.New(app),
 		splash:      splash.New(),
@@ -222,12 +224,12 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case CancelTimerExpiredMsg:
 		p.isCanceling = false
 		return p, nil
-	case editor.OpenEditorMsg:
+	case editor.OpenEditorMsg, editprompt.PromptSelectedMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
 	case chat.SendMsg:
-		return p, p.sendMessage(msg.Text, msg.Attachments)
+		return p, p.sendMessage(msg.Text, msg.Attachments, msg.EditMessageID)
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
 	case splash.SubmitAPIKeyMsg:
@@ -305,7 +307,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
-	case pubsub.Event[history.File], sidebar.SessionFilesMsg:
+	case pubsub.Event[history.File], pubsub.Event[shell.Job], sidebar.SessionFilesMsg:
 		u, cmd := p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
@@ -321,7 +323,7 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
 
-		cmd := p.sendMessage(msg.Content, nil)
+		cmd := p.sendMessage(msg.Content, nil, "")
 		if cmd != nil {
 			return p, cmd
 		}
@@ -677,9 +679,15 @@ func (p *chatPage) toggleDetails() {
 	p.setShowDetails(!p.showingDetails)
 }
 
-func (p *chatPage) sendMessage(text string, attachments []message.Attachment) tea.Cmd {
+func (p *chatPage) sendMessage(text string, attachments []message.Attachment, editMessageID string) tea.Cmd {
 	session := p.session
 	var cmds []tea.Cmd
+	if editMessageID != "" && session.ID != "" {
+		if _, err := p.app.RewindSession(context.Background(), session.ID, editMessageID); err != nil {
+			return util.ReportError(err)
+		}
+		cmds = append(cmds, util.ReportInfo("The previous conversation was kept in a fork of this session"))
+	}
 	if p.session.ID == "" {
 		newSession, err := p.app.Sessions.Create(context.Background(), "New Session")
 		if err != nil {
@@ -20,10 +20,14 @@ import (
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/commands"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/compact"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/editprompt"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/filepicker"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/fork"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/grants"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/models"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/permissions"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/quit"
+	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/revert"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/components/dialogs/sessions"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/page"
 	"github.com/JyotirmoyDas05/openpilot/internal/tui/page/chat"
@@ -155,7 +159,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, func() tea.Msg {
 			allSessions, _ := a.app.Sessions.List(context.Background())
 			return dialogs.OpenDialogMsg{
-				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+				Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, a.app.Messages),
 			}
 		}
 
@@ -170,12 +174,100 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: compact.NewCompactDialogCmp(a.app.CoderAgent, msg.SessionID, true),
 		})
+	// Edit prompt
+	case commands.EditPromptMsg:
+		return a, func() tea.Msg {
+			msgs, err := a.app.Messages.List(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: editprompt.NewEditPromptDialogCmp(msgs),
+			}
+		}
+	// Fork
+	case commands.ForkSessionMsg:
+		return a, func() tea.Msg {
+			msgs, err := a.app.Messages.List(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: fork.NewForkDialogCmp(msg.SessionID, msgs),
+			}
+		}
+	case fork.ForkPointSelectedMsg:
+		if a.app.CoderAgent != nil && a.app.CoderAgent.IsSessionBusy(msg.SessionID) {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		return a, func() tea.Msg {
+			forked, err := a.app.ForkSession(context.Background(), msg.SessionID, msg.MessageID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return cmpChat.SessionSelectedMsg(forked)
+		}
+	// Revert
+	case commands.RevertMsg:
+		return a, func() tea.Msg {
+			ctx := context.Background()
+			msgs, err := a.app.Messages.List(ctx, msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			files, err := a.app.History.ListBySession(ctx, msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: revert.NewRevertDialogCmp(msgs, files, a.app.Config().WorkingDir()),
+			}
+		}
+	case revert.RestorePointSelectedMsg:
+		return a, func() tea.Msg {
+			ctx := context.Background()
+			if msg.FileID != "" {
+				file, err := a.app.History.Restore(ctx, msg.FileID)
+				if err != nil {
+					return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+				}
+				return util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Restored %s", file.Path)}
+			}
+			files, err := a.app.RevertSinceMessage(ctx, msg.MessageID)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			if len(files) == 0 {
+				return util.InfoMsg{Type: util.InfoTypeWarn, Msg: "No file changes to revert"}
+			}
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Reverted %d files", len(files))}
+		}
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
 		})
 	case commands.ToggleYoloModeMsg:
 		a.app.Permissions.SetSkipRequests(!a.app.Permissions.SkipRequests())
+	case commands.ShowGrantsMsg:
+		return a, func() tea.Msg {
+			list, err := a.app.Grants.List(context.Background())
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			if len(list) == 0 {
+				return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "No permissions are always allowed"}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: grants.NewGrantsDialogCmp(list),
+			}
+		}
+	case grants.RevokeGrantMsg:
+		return a, func() tea.Msg {
+			if err := a.app.Grants.Revoke(context.Background(), msg.Grant.ID); err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: fmt.Sprintf("Revoked %s %s", msg.Grant.ToolName, msg.Grant.Action)}
+		}
 	case commands.ToggleHelpMsg:
 		a.status.ToggleFullHelp()
 		a.showingFullHelp = !a.showingFullHelp
@@ -229,6 +321,8 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
 		case permissions.PermissionAllowForSession:
+			a.app.Permissions.GrantForSession(msg.Permission)
+		case permissions.PermissionAllowAlways:
 			a.app.Permissions.GrantPersistent(msg.Permission)
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
@@ -243,21 +337,16 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			u, dialogCmd := a.dialog.Update(payload)
 			a.dialog = u.(dialogs.DialogCmp)
 			cmds = append(cmds, dialogCmd)
-		}
-
-		// Handle auto-compact logic
-		if payload.Done && payload.Type == agent.AgentEventTypeResponse && a.selectedSessionID != "" {
-			// Get current session to check token usage
-			session, err := a.app.Sessions.Get(context.Background(), a.selectedSessionID)
-			if err == nil {
-				model := a.app.CoderAgent.Model()
-				contextWindow := model.ContextWindow
-				tokens := session.CompletionTokens + session.PromptTokens
-				if (tokens >= int64(float64(contextWindow)*0.95)) && !config.Get().Options.DisableAutoSummarize { // Show compact confirmation dialog
-					cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
-						Model: compact.NewCompactDialogCmp(a.app.CoderAgent, a.selectedSessionID, false),
-					}))
-				}
+		} else if payload.Type == agent.AgentEventTypeSummarize && payload.SessionID == a.selectedSessionID {
+			// The agent compacts the conversation on its own when it
+			// outgrows the context window.
+			switch {
+			case payload.Error != nil:
+				cmds = append(cmds, util.ReportError(payload.Error))
+			case payload.Done:
+				cmds = append(cmds, util.ReportInfo("Conversation compacted"))
+			default:
+				cmds = append(cmds, util.ReportInfo(payload.Progress))
 			}
 		}
 
@@ -426,7 +515,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			func() tea.Msg {
 				allSessions, _ := a.app.Sessions.List(context.Background())
 				return dialogs.OpenDialogMsg{
-					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
+					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID, a.app.Messages),
 				}
 			},
 		)